@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// fileSettings holds the values read from CONFIG_FILE, keyed by environment variable name
+// Environment variables always take precedence over these
+var fileSettings = map[string]string{}
+
+// usedSettings records which file settings were consulted, to flag unknown keys
+var usedSettings = map[string]bool{}
+
+// lookupSetting returns the environment variable key, falling back to CONFIG_FILE
+func lookupSetting(key string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	usedSettings[key] = true
+	return fileSettings[key]
+}
+
+// loadConfigFile reads a YAML config file whose keys are the environment variable
+// names, case-insensitive and with - or _ (e.g. reconcile_interval: 30s)
+// Lists may be written as YAML sequences or comma-separated strings
+func loadConfigFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CONFIG_FILE %s: %w", path, err)
+	}
+
+	var raw map[string]any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse CONFIG_FILE %s: %w", path, err)
+	}
+
+	settings := make(map[string]string, len(raw))
+	for key, value := range raw {
+		name := strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+		str, err := settingString(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid setting %s in CONFIG_FILE %s: %w", key, path, err)
+		}
+		settings[name] = str
+	}
+	return settings, nil
+}
+
+// settingString flattens a YAML value into the string form its environment variable would take
+func settingString(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case int, bool, float64:
+		return fmt.Sprint(v), nil
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			if _, isList := item.([]any); isList {
+				return "", fmt.Errorf("nested lists are not supported")
+			}
+			str, err := settingString(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, str)
+		}
+		return strings.Join(items, ","), nil
+	default:
+		return "", fmt.Errorf("must be a string, number, boolean or list, got %T", value)
+	}
+}
+
+// unknownSettings lists CONFIG_FILE keys that no setting read, usually typos
+func unknownSettings() []string {
+	var unknown []string
+	for key := range fileSettings {
+		if !usedSettings[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadConfigFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "env names, lowercase and lists",
+			content: `
+RECONCILE_INTERVAL: 30s
+tailscale-tailnet: example.com
+log_level: debug
+max_services: 50
+keep_services_on_shutdown: true
+default_service_tags:
+  - tag:web
+  - tag:prod
+include_containers: "web-.*,api"
+`,
+			want: map[string]string{
+				"RECONCILE_INTERVAL":        "30s",
+				"TAILSCALE_TAILNET":         "example.com",
+				"LOG_LEVEL":                 "debug",
+				"MAX_SERVICES":              "50",
+				"KEEP_SERVICES_ON_SHUTDOWN": "true",
+				"DEFAULT_SERVICE_TAGS":      "tag:web,tag:prod",
+				"INCLUDE_CONTAINERS":        "web-.*,api",
+			},
+		},
+		{
+			name:    "malformed yaml",
+			content: "reconcile_interval: [30s\n",
+			wantErr: true,
+		},
+		{
+			name:    "nested mapping",
+			content: "tailscale:\n  socket: /tmp/ts.sock\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "docktail.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := loadConfigFile(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadConfigFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("loadConfigFile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := loadConfigFile(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Errorf("loadConfigFile() on a missing file should fail")
+	}
+}
@@ -0,0 +1,60 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/docker/docker/api/types/versions"
+	"github.com/docker/docker/client"
+)
+
+// minAPIVersion is the oldest Docker API version whose events endpoint
+// supports the type/event filters used by WatchEvents (Docker Engine 1.12)
+const minAPIVersion = "1.24"
+
+// negotiateAPIVersion settles on the API version to talk to the daemon with.
+// DOCKER_API_VERSION pins the version and skips negotiation. An unreachable
+// daemon is tolerated (the client default is kept) unless required is set,
+// but a version too old for the event filters is always a fatal error.
+func negotiateAPIVersion(ctx context.Context, cli *client.Client, required bool) error {
+	pinned := os.Getenv(client.EnvOverrideAPIVersion) != ""
+
+	ping, err := cli.Ping(ctx)
+	if err != nil {
+		if required {
+			return err
+		}
+		log.Warn().
+			Err(err).
+			Str("api_version", cli.ClientVersion()).
+			Msg("Could not negotiate Docker API version, using client default")
+	} else {
+		cli.NegotiateAPIVersionPing(ping)
+	}
+
+	version := cli.ClientVersion()
+	if err := checkAPIVersion(version); err != nil {
+		return err
+	}
+	if ping.APIVersion != "" {
+		if err := checkAPIVersion(ping.APIVersion); err != nil {
+			return fmt.Errorf("Docker daemon %w", err)
+		}
+	}
+
+	log.Info().
+		Str("api_version", version).
+		Str("daemon_api_version", ping.APIVersion).
+		Bool("pinned", pinned).
+		Msg("Docker API version negotiated")
+	return nil
+}
+
+// checkAPIVersion rejects API versions older than minAPIVersion
+func checkAPIVersion(version string) error {
+	if versions.LessThan(version, minAPIVersion) {
+		return fmt.Errorf("API version %s is too old: DockTail requires Docker API %s or newer (Docker Engine 1.12+) for event filtering", version, minAPIVersion)
+	}
+	return nil
+}
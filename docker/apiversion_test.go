@@ -0,0 +1,23 @@
+package docker
+
+import "testing"
+
+func TestCheckAPIVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		wantErr bool
+	}{
+		{"1.51", false},
+		{"1.24", false},
+		{"1.41", false},
+		{"1.23", true},
+		{"1.12", true},
+	}
+
+	for _, tt := range tests {
+		err := checkAPIVersion(tt.version)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("checkAPIVersion(%q) error = %v, wantErr %v", tt.version, err, tt.wantErr)
+		}
+	}
+}
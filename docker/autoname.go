@@ -0,0 +1,76 @@
+package docker
+
+import (
+	"strings"
+
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+// maxServiceNameLength is the DNS label limit service names have to fit in
+const maxServiceNameLength = 63
+
+// autoNameSuffixLength is how much of the container ID disambiguates colliding derived names
+const autoNameSuffixLength = 6
+
+// sanitizeServiceName derives a service name from a container name: lowercased, runs of
+// characters other than a-z and 0-9 replaced by a single dash, no leading or trailing dashes,
+// and cut to the DNS label length. The svc: prefix is added by the Tailscale layer
+func sanitizeServiceName(containerName string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(containerName) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	name := strings.TrimRight(b.String(), "-")
+	if len(name) > maxServiceNameLength {
+		name = strings.TrimRight(name[:maxServiceNameLength], "-")
+	}
+	return name
+}
+
+// autoServiceName returns the service name for a container without docktail.service.name
+func autoServiceName(containerName string) (string, error) {
+	name := sanitizeServiceName(containerName)
+	if name == "" {
+		return "", newParseError(ErrMissingLabel, "missing required label: %s (AUTO_SERVICE_NAME can't derive a name from container %q)", apptypes.LabelService, containerName)
+	}
+	return name, nil
+}
+
+// disambiguateAutoNames appends a short container ID to derived service names that collide with
+// another container's service name, so two containers never share a service by accident
+// Explicit names are left alone; they win over derived ones
+func disambiguateAutoNames(services []*apptypes.ContainerService) {
+	owners := make(map[string]int, len(services))
+	for _, svc := range services {
+		owners[svc.ServiceName]++
+	}
+
+	for _, svc := range services {
+		if !svc.AutoName || owners[svc.ServiceName] < 2 {
+			continue
+		}
+		suffix := svc.ContainerID
+		if len(suffix) > autoNameSuffixLength {
+			suffix = suffix[:autoNameSuffixLength]
+		}
+		base := svc.ServiceName
+		if maxBase := maxServiceNameLength - len(suffix) - 1; len(base) > maxBase {
+			base = strings.TrimRight(base[:maxBase], "-")
+		}
+		name := base + "-" + suffix
+
+		log.Warn().
+			Str("container", svc.ContainerName).
+			Str("derived_name", svc.ServiceName).
+			Str("service", name).
+			Msg("Derived service name collides with another container, appending the container ID")
+		svc.ServiceName = name
+	}
+}
@@ -0,0 +1,69 @@
+package docker
+
+import (
+	"strings"
+	"testing"
+
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+func TestSanitizeServiceName(t *testing.T) {
+	tests := []struct {
+		containerName string
+		want          string
+	}{
+		{"web", "web"},
+		{"MyApp", "myapp"},
+		{"myproject-web-1", "myproject-web-1"},
+		{"myproject_web_1", "myproject-web-1"},
+		{"api.v2", "api-v2"},
+		{"__web__", "web"},
+		{"web--__--db", "web-db"},
+		{"grafana ☃ dashboards", "grafana-dashboards"},
+		{"123", "123"},
+		{"___", ""},
+		{strings.Repeat("a", 62) + "_bc", strings.Repeat("a", 62)},
+		{strings.Repeat("a", 70), strings.Repeat("a", 63)},
+	}
+
+	for _, tt := range tests {
+		if got := sanitizeServiceName(tt.containerName); got != tt.want {
+			t.Errorf("sanitizeServiceName(%q) = %q, want %q", tt.containerName, got, tt.want)
+		}
+	}
+
+	if _, err := autoServiceName("___"); err == nil {
+		t.Errorf("autoServiceName() should fail when nothing of the container name is usable")
+	}
+}
+
+func TestDisambiguateAutoNames(t *testing.T) {
+	explicit := &apptypes.ContainerService{ContainerID: "aaaaaaaaaaaa", ContainerName: "web", ServiceName: "web-1"}
+	dashed := &apptypes.ContainerService{ContainerID: "bbbbbbbbbbbb", ContainerName: "web-1", ServiceName: "web-1", AutoName: true}
+	underscored := &apptypes.ContainerService{ContainerID: "cccccccccccc", ContainerName: "web_1", ServiceName: "web-1", AutoName: true}
+	unique := &apptypes.ContainerService{ContainerID: "dddddddddddd", ContainerName: "db", ServiceName: "db", AutoName: true}
+
+	disambiguateAutoNames([]*apptypes.ContainerService{explicit, dashed, underscored, unique})
+
+	tests := []struct {
+		svc  *apptypes.ContainerService
+		want string
+	}{
+		{explicit, "web-1"},
+		{dashed, "web-1-bbbbbb"},
+		{underscored, "web-1-cccccc"},
+		{unique, "db"},
+	}
+	for _, tt := range tests {
+		if tt.svc.ServiceName != tt.want {
+			t.Errorf("%s: ServiceName = %q, want %q", tt.svc.ContainerName, tt.svc.ServiceName, tt.want)
+		}
+	}
+
+	long := &apptypes.ContainerService{ContainerID: "eeeeeeeeeeee", ServiceName: strings.Repeat("x", 63), AutoName: true}
+	longTwin := &apptypes.ContainerService{ContainerID: "ffffffffffff", ServiceName: strings.Repeat("x", 63), AutoName: true}
+	disambiguateAutoNames([]*apptypes.ContainerService{long, longTwin})
+	if len(long.ServiceName) != maxServiceNameLength || !strings.HasSuffix(long.ServiceName, "-eeeeee") {
+		t.Errorf("suffixed name %q must still fit in %d characters", long.ServiceName, maxServiceNameLength)
+	}
+}
@@ -0,0 +1,54 @@
+package docker
+
+import (
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+// parseBasicAuth reads the docktail.service.basic-auth.* labels into a credential, nil if none are set
+// The password is given as a bcrypt hash, inline (basic-auth.hash) or in a file such as a Docker
+// secret (basic-auth.secret); plain-text passwords are never accepted
+func parseBasicAuth(labels map[string]string, serviceProtocol string) (*apptypes.BasicAuth, error) {
+	user := labels[apptypes.LabelBasicAuthUser]
+	hash := labels[apptypes.LabelBasicAuthHash]
+	secret := labels[apptypes.LabelBasicAuthSecret]
+	if user == "" && hash == "" && secret == "" {
+		return nil, nil
+	}
+
+	if serviceProtocol != "http" && serviceProtocol != "https" {
+		return nil, newParseError(ErrConflictingLabels, "basic auth requires service-protocol http or https (got %s)", serviceProtocol)
+	}
+	if user == "" || strings.Contains(user, ":") {
+		return nil, newParseError(ErrInvalidLabel, "invalid %s value '%s': must be a non-empty user name without ':'", apptypes.LabelBasicAuthUser, user)
+	}
+	switch {
+	case hash != "" && secret != "":
+		return nil, newParseError(ErrConflictingLabels, "%s and %s are mutually exclusive", apptypes.LabelBasicAuthHash, apptypes.LabelBasicAuthSecret)
+	case hash == "" && secret == "":
+		return nil, newParseError(ErrMissingLabel, "%s requires %s or %s", apptypes.LabelBasicAuthUser, apptypes.LabelBasicAuthHash, apptypes.LabelBasicAuthSecret)
+	}
+
+	label := apptypes.LabelBasicAuthHash
+	if secret != "" {
+		label = apptypes.LabelBasicAuthSecret
+		if !strings.HasPrefix(secret, "/") {
+			return nil, newParseError(ErrInvalidLabel, "invalid %s value '%s': must be an absolute path", label, secret)
+		}
+		data, err := os.ReadFile(secret)
+		if err != nil {
+			return nil, newParseError(ErrInvalidLabel, "invalid %s value '%s': %v", label, secret, err)
+		}
+		hash = strings.TrimSpace(string(data))
+	}
+	if _, err := bcrypt.Cost([]byte(hash)); err != nil {
+		// The hash itself is a credential, so it's left out of the message
+		return nil, newParseError(ErrInvalidLabel, "invalid %s value: not a bcrypt hash (%v)", label, err)
+	}
+
+	return &apptypes.BasicAuth{User: user, PasswordHash: hash, SecretFile: secret}, nil
+}
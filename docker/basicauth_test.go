@@ -0,0 +1,64 @@
+package docker
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+func TestParseBasicAuth(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("hunter2"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := filepath.Join(t.TempDir(), "htpasswd-hash")
+	if err := os.WriteFile(secret, append(hash, '\n'), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		labels   map[string]string
+		protocol string
+		wantNil  bool
+		wantErr  error
+	}{
+		{"no labels", map[string]string{}, "https", true, nil},
+		{"inline hash", map[string]string{apptypes.LabelBasicAuthUser: "admin", apptypes.LabelBasicAuthHash: string(hash)}, "https", false, nil},
+		{"secret file", map[string]string{apptypes.LabelBasicAuthUser: "admin", apptypes.LabelBasicAuthSecret: secret}, "http", false, nil},
+		{"tcp service", map[string]string{apptypes.LabelBasicAuthUser: "admin", apptypes.LabelBasicAuthHash: string(hash)}, "tcp", false, ErrConflictingLabels},
+		{"plain-text password", map[string]string{apptypes.LabelBasicAuthUser: "admin", apptypes.LabelBasicAuthHash: "hunter2"}, "https", false, ErrInvalidLabel},
+		{"missing user", map[string]string{apptypes.LabelBasicAuthHash: string(hash)}, "https", false, ErrInvalidLabel},
+		{"user with colon", map[string]string{apptypes.LabelBasicAuthUser: "ad:min", apptypes.LabelBasicAuthHash: string(hash)}, "https", false, ErrInvalidLabel},
+		{"missing password", map[string]string{apptypes.LabelBasicAuthUser: "admin"}, "https", false, ErrMissingLabel},
+		{"hash and secret", map[string]string{apptypes.LabelBasicAuthUser: "admin", apptypes.LabelBasicAuthHash: string(hash), apptypes.LabelBasicAuthSecret: secret}, "https", false, ErrConflictingLabels},
+		{"relative secret", map[string]string{apptypes.LabelBasicAuthUser: "admin", apptypes.LabelBasicAuthSecret: "hash.txt"}, "https", false, ErrInvalidLabel},
+		{"missing secret", map[string]string{apptypes.LabelBasicAuthUser: "admin", apptypes.LabelBasicAuthSecret: secret + ".missing"}, "https", false, ErrInvalidLabel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth, err := parseBasicAuth(tt.labels, tt.protocol)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("parseBasicAuth() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseBasicAuth() error = %v", err)
+			}
+			if (auth == nil) != tt.wantNil {
+				t.Fatalf("parseBasicAuth() = %+v, want nil %v", auth, tt.wantNil)
+			}
+			if auth != nil && (auth.User != "admin" || auth.PasswordHash != string(hash)) {
+				t.Errorf("parseBasicAuth() = %+v", auth)
+			}
+		})
+	}
+}
@@ -2,77 +2,398 @@ package docker
 
 import (
 	"context"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/docker/cli/cli/connhelper"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/events"
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/client"
 	"github.com/docker/go-connections/nat"
-	"github.com/rs/zerolog/log"
 
 	apptypes "github.com/marvinvr/docktail/types"
 )
 
+// validTargetProtocols are the protocols a container backend can speak
+var validTargetProtocols = map[string]bool{
+	"http":               true,
+	"https":              true,
+	"https+insecure":     true,
+	"tcp":                true,
+	"tls-terminated-tcp": true,
+}
+
+// validHostname matches a DNS hostname such as web.tailnet-1234.ts.net
+var validHostname = regexp.MustCompile(`^(?i)[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?(\.[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?)*$`)
+
 // Client wraps the Docker client with our business logic
 type Client struct {
-	cli         *client.Client
-	defaultTags []string
+	mu  sync.RWMutex
+	cli *client.Client
+	// opts and dockerHost are kept so Reconnect can build an identical client
+	opts       []client.Opt
+	dockerHost string
+
+	defaultTags           []string
+	waitReadyTimeout      time.Duration
+	defaultTargetProtocol string
+	configSource          string
+	nameFilter            *nameFilter
+	publishedHost         string
+	runtime               string
+	defaultHTTPSInsecure  bool
+	networkPriority       []string
+	tagsMerge             bool
+	events                []string
+	strictLabels          bool
+	autoServiceName       bool
+	ipRetries             int
+	ipRetryDelay          time.Duration
+	stageSelector         string
+	skipReachability      bool
 }
 
+// ClientConfig holds configuration for creating a Docker client
+type ClientConfig struct {
+	DefaultTags           []string
+	WaitReadyTimeout      time.Duration // Global wait for direct-mode backends to accept connections (0 = don't wait)
+	DefaultTargetProtocol string        // Backend protocol when the label is unset (empty = infer from container port)
+	ConfigSource          string        // Where to read service configuration: labels, env, or both (empty = labels)
+	IncludeContainers     []string      // Regex allow-list of container names (empty = all)
+	ExcludeContainers     []string      // Regex deny-list of container names, applied before the allow-list
+	PublishedHost         string        // Destination host for published ports when direct mode is disabled (empty = localhost)
+	PublishedVia          string        // How published ports are reached: host (PublishedHost) or gateway (empty = host)
+	PublishedGateway      string        // Gateway IP used with PublishedVia gateway (empty = resolve from Docker)
+	Runtime               string        // Container runtime behind the API socket: docker or podman (empty = docker)
+	DefaultHTTPSInsecure  bool          // Default port-443 backends to https+insecure instead of https
+	NetworkPriority       []string      // Preferred network name suffixes, in order, when no network label is set
+	TagsMerge             bool          // Append container tags to the defaults instead of replacing them
+	Events                []string      // Container events that trigger a reconciliation (empty = DefaultEvents)
+	StrictLabels          bool          // Require explicit ports and protocols instead of inferring them
+	AutoServiceName       bool          // Derive missing service names from container names
+	Socket                string        // Docker API unix socket path, used when DOCKER_HOST is unset (empty = DefaultSocket)
+	Context               string        // Docker CLI context to connect to when DOCKER_HOST is unset (empty = the CLI's current context)
+	IPRetries             int           // Re-inspects of a direct-mode container that has no IP yet (0 = none)
+	IPRetryDelay          time.Duration // Delay before each re-inspect
+	StageSelector         string        // Only manage containers whose docktail.service.stage equals this (empty = all)
+	SkipReachability      bool          // Skip the best-effort TCP dial to direct-mode backends unless a container opts back in
+}
+
+// DefaultSocket is the Docker API socket used when neither DOCKER_HOST nor DOCKER_SOCKET is set
+const DefaultSocket = "/var/run/docker.sock"
+
 // NewClient creates a new Docker client
-func NewClient(defaultTags []string) (*Client, error) {
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+// DOCKER_HOST wins over cfg.Socket, so a full endpoint URL can still be given
+// Without either, the Docker CLI context (cfg.Context or the CLI's current context) is used
+// An ssh:// DOCKER_HOST is routed through the Docker CLI connection helper,
+// which tunnels the API over "ssh <host> docker system dial-stdio"
+func NewClient(cfg ClientConfig) (*Client, error) {
+	if cfg.DefaultTargetProtocol != "" && !validTargetProtocols[cfg.DefaultTargetProtocol] {
+		return nil, fmt.Errorf("invalid DEFAULT_TARGET_PROTOCOL: %s (must be http, https, https+insecure, tcp, or tls-terminated-tcp)", cfg.DefaultTargetProtocol)
+	}
+
+	configSource := cfg.ConfigSource
+	switch configSource {
+	case "":
+		configSource = ConfigSourceLabels
+	case ConfigSourceLabels, ConfigSourceEnv, ConfigSourceBoth:
+	default:
+		return nil, fmt.Errorf("invalid CONFIG_SOURCE: %s (must be labels, env, or both)", cfg.ConfigSource)
+	}
+
+	nameFilter, err := newNameFilter(cfg.IncludeContainers, cfg.ExcludeContainers)
+	if err != nil {
+		return nil, err
+	}
+
+	publishedHost := cfg.PublishedHost
+	if publishedHost == "" {
+		publishedHost = "localhost"
+	}
+
+	switch cfg.PublishedVia {
+	case "", PublishedViaHost, PublishedViaGateway:
+	default:
+		return nil, fmt.Errorf("invalid PUBLISHED_VIA: %s (must be host or gateway)", cfg.PublishedVia)
+	}
+
+	runtime := cfg.Runtime
+	switch runtime {
+	case "":
+		runtime = RuntimeDocker
+	case RuntimeDocker, RuntimePodman:
+	default:
+		return nil, fmt.Errorf("invalid CONTAINER_RUNTIME: %s (must be docker or podman)", cfg.Runtime)
+	}
+
+	if cfg.Socket != "" && !strings.HasPrefix(cfg.Socket, "/") {
+		return nil, fmt.Errorf("invalid DOCKER_SOCKET: %s (must be an absolute path)", cfg.Socket)
+	}
+
+	watchedEvents := cfg.Events
+	if len(watchedEvents) == 0 {
+		watchedEvents = DefaultEvents
+	}
+
+	opts := []client.Opt{client.FromEnv, client.WithAPIVersionNegotiation()}
+
+	dockerHost := os.Getenv(client.EnvOverrideHost)
+
+	// Docker Desktop, Colima and friends point the CLI at their socket with a context, not DOCKER_HOST
+	// An explicit DOCKER_CONTEXT beats DOCKER_SOCKET; the CLI's current context only replaces the default socket
+	if dockerHost == "" && (cfg.Context != "" || cfg.Socket == "" || cfg.Socket == DefaultSocket) {
+		dockerCtx, err := resolveContext(dockerConfigDir(), cfg.Context)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load Docker context: %w", err)
+		}
+		if dockerCtx != nil {
+			dockerHost = dockerCtx.Host
+			opts = append(opts, dockerCtx.clientOpts()...)
+			log.Info().
+				Str("docker_context", dockerCtx.Name).
+				Str("docker_host", dockerHost).
+				Bool("tls", dockerCtx.TLSDir != "").
+				Msg("Using Docker context")
+		}
+	}
+
+	if dockerHost == "" {
+		socket := cfg.Socket
+		if runtime == RuntimePodman && (socket == "" || socket == DefaultSocket) {
+			// Podman serves a Docker-compatible API, just on a different socket
+			dockerHost = podmanHost()
+			log.Info().
+				Str("podman_host", dockerHost).
+				Msg("Using Podman API socket")
+		} else {
+			if socket == "" {
+				socket = DefaultSocket
+			}
+			dockerHost = "unix://" + socket
+		}
+		opts = append(opts, client.WithHost(dockerHost))
+	}
+
+	isSSH := strings.HasPrefix(dockerHost, "ssh://")
+	if isSSH {
+		helper, err := connhelper.GetConnectionHelper(dockerHost)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set up SSH connection to %s: %w", dockerHost, err)
+		}
+		opts = append(opts,
+			client.WithHTTPClient(&http.Client{
+				Transport: &http.Transport{DialContext: helper.Dialer},
+			}),
+			client.WithHost(helper.Host),
+			client.WithDialContext(helper.Dialer),
+		)
+		log.Info().
+			Str("docker_host", dockerHost).
+			Msg("Connecting to remote Docker daemon over SSH")
+	}
+
+	cli, err := client.NewClientWithOpts(opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Docker client: %w", err)
 	}
 
-	return &Client{cli: cli, defaultTags: defaultTags}, nil
+	// The SSH tunnel is only established on first use, so verify it up front
+	// rather than failing later inside the reconciliation loop
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := negotiateAPIVersion(ctx, cli, isSSH); err != nil {
+		_ = cli.Close()
+		if isSSH {
+			return nil, fmt.Errorf("failed to connect to Docker daemon over SSH (%s): %w", dockerHost, err)
+		}
+		return nil, err
+	}
+
+	log.Info().
+		Str("docker_host", dockerHost).
+		Msg("Connected to Docker daemon")
+
+	if cfg.PublishedVia == PublishedViaGateway {
+		publishedHost = resolvePublishedGateway(ctx, cli, cfg.PublishedGateway)
+	}
+
+	return &Client{
+		cli:                   cli,
+		opts:                  opts,
+		dockerHost:            dockerHost,
+		defaultTags:           cfg.DefaultTags,
+		waitReadyTimeout:      cfg.WaitReadyTimeout,
+		defaultTargetProtocol: cfg.DefaultTargetProtocol,
+		configSource:          configSource,
+		nameFilter:            nameFilter,
+		publishedHost:         publishedHost,
+		runtime:               runtime,
+		defaultHTTPSInsecure:  cfg.DefaultHTTPSInsecure,
+		networkPriority:       cfg.NetworkPriority,
+		tagsMerge:             cfg.TagsMerge,
+		events:                watchedEvents,
+		strictLabels:          cfg.StrictLabels,
+		autoServiceName:       cfg.AutoServiceName,
+		ipRetries:             cfg.IPRetries,
+		ipRetryDelay:          cfg.IPRetryDelay,
+		stageSelector:         cfg.StageSelector,
+		skipReachability:      cfg.SkipReachability,
+	}, nil
 }
 
 // Close closes the Docker client
 func (c *Client) Close() error {
-	return c.cli.Close()
+	return c.api().Close()
 }
 
-// WatchEvents streams Docker container events
+// WatchEvents streams the configured Docker container events
 func (c *Client) WatchEvents(ctx context.Context) (<-chan events.Message, <-chan error) {
-	eventsChan, errChan := c.cli.Events(ctx, events.ListOptions{
-		Filters: filters.NewArgs(
-			filters.Arg("type", "container"),
-			filters.Arg("event", "start"),
-			filters.Arg("event", "stop"),
-			filters.Arg("event", "die"),
-			filters.Arg("event", "restart"),
-		),
+	eventsChan, errChan := c.api().Events(ctx, events.ListOptions{
+		Filters: eventFilters(c.events),
 	})
 
 	return eventsChan, errChan
 }
 
+// Ping checks that the Docker daemon is reachable
+func (c *Client) Ping(ctx context.Context) error {
+	if _, err := c.api().Ping(ctx); err != nil {
+		return fmt.Errorf("docker daemon unreachable: %w", err)
+	}
+	return nil
+}
+
+// ParseError records an enabled container that was skipped because its configuration is invalid
+// or, with Err wrapping ErrRestarting or ErrPaused, because it is restarting or paused
+type ParseError struct {
+	ContainerID   string
+	ContainerName string
+	Err           error
+}
+
 // GetEnabledContainers returns all running containers with docktail.service.enable=true
-func (c *Client) GetEnabledContainers(ctx context.Context) ([]*apptypes.ContainerService, error) {
-	containers, err := c.cli.ContainerList(ctx, container.ListOptions{
-		Filters: filters.NewArgs(
+// (or DOCKTAIL_SERVICE_ENABLE=true when CONFIG_SOURCE includes env)
+// Containers that fail to parse are skipped and reported in the returned ParseError slice
+func (c *Client) GetEnabledContainers(ctx context.Context) ([]*apptypes.ContainerService, []ParseError, error) {
+	// Environment variables aren't filterable, so env modes have to look at every running container
+	listOptions := container.ListOptions{}
+	if c.configSource == ConfigSourceLabels {
+		listOptions.Filters = filters.NewArgs(
 			filters.Arg("label", apptypes.LabelEnable+"=true"),
-		),
-	})
+		)
+		if c.stageSelector != "" {
+			listOptions.Filters.Add("label", apptypes.LabelStage+"="+c.stageSelector)
+		}
+	}
+
+	containers, err := c.api().ContainerList(ctx, listOptions)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list containers: %w", err)
+		return nil, nil, fmt.Errorf("failed to list containers: %w", err)
 	}
 
 	var services []*apptypes.ContainerService
+	var parseErrors []ParseError
 	for _, cont := range containers {
-		service, err := c.parseContainer(ctx, cont.ID, cont.Labels)
+		containerName := strings.TrimPrefix(cont.Names[0], "/")
+
+		if ok, reason := c.nameFilter.allowed(containerName); !ok {
+			log.Debug().
+				Str("container_id", cont.ID[:12]).
+				Str("container_name", containerName).
+				Str("reason", reason).
+				Msg("Container filtered out by name, skipping")
+			continue
+		}
+
+		labels, err := c.containerConfig(ctx, cont.ID, cont.Labels)
 		if err != nil {
 			log.Warn().
 				Err(err).
 				Str("container_id", cont.ID[:12]).
-				Str("container_name", strings.TrimPrefix(cont.Names[0], "/")).
+				Str("container_name", containerName).
+				Msg("Failed to read container configuration, skipping")
+			continue
+		}
+
+		// Env modes can't filter the list, so the stage is checked here too
+		if !c.inStage(labels) {
+			log.Debug().
+				Str("container_id", cont.ID[:12]).
+				Str("container_name", containerName).
+				Str("stage", labels[apptypes.LabelStage]).
+				Msg("Container not in STAGE_SELECTOR stage, skipping")
+			continue
+		}
+
+		// A paused container still shows up as running but can't answer requests
+		if cont.State == container.StatePaused {
+			if labels[apptypes.LabelEnable] != "true" {
+				continue
+			}
+			err := pausedError(labels)
+			switch {
+			case err == nil:
+				log.Debug().
+					Str("container_id", cont.ID[:12]).
+					Str("container_name", containerName).
+					Msg("Container is paused, skipping")
+				continue
+			case errors.Is(err, ErrPaused):
+				log.Debug().
+					Str("container_id", cont.ID[:12]).
+					Str("container_name", containerName).
+					Msg("Container is paused, keeping its service as is")
+			default:
+				log.Warn().
+					Err(err).
+					Str("container_id", cont.ID[:12]).
+					Str("container_name", containerName).
+					Msg("Failed to parse container, skipping")
+			}
+			parseErrors = append(parseErrors, ParseError{
+				ContainerID:   cont.ID[:12],
+				ContainerName: containerName,
+				Err:           err,
+			})
+			continue
+		}
+
+		service, err := c.parseContainer(ctx, cont.ID, labels)
+		if errors.Is(err, ErrRestarting) {
+			log.Debug().
+				Str("container_id", cont.ID[:12]).
+				Str("container_name", containerName).
+				Msg("Container is restarting, keeping its service as is")
+			parseErrors = append(parseErrors, ParseError{
+				ContainerID:   cont.ID[:12],
+				ContainerName: containerName,
+				Err:           err,
+			})
+			continue
+		}
+		if err != nil {
+			log.Warn().
+				Err(err).
+				Str("container_id", cont.ID[:12]).
+				Str("container_name", containerName).
 				Msg("Failed to parse container, skipping")
+			parseErrors = append(parseErrors, ParseError{
+				ContainerID:   cont.ID[:12],
+				ContainerName: containerName,
+				Err:           err,
+			})
 			continue
 		}
 		if service != nil {
@@ -80,7 +401,27 @@ func (c *Client) GetEnabledContainers(ctx context.Context) ([]*apptypes.Containe
 		}
 	}
 
-	return services, nil
+	disambiguateAutoNames(services)
+	return services, parseErrors, nil
+}
+
+// containerConfig returns the DockTail configuration for a container, keyed by label name
+// Labels are used as-is; in env modes the container's Config.Env is read via ContainerInspect
+func (c *Client) containerConfig(ctx context.Context, containerID string, labels map[string]string) (map[string]string, error) {
+	if c.configSource == ConfigSourceLabels {
+		return labels, nil
+	}
+
+	inspect, err := c.api().ContainerInspect(ctx, containerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect container: %w", err)
+	}
+
+	var env []string
+	if inspect.Config != nil {
+		env = inspect.Config.Env
+	}
+	return mergeConfig(c.configSource, labels, env), nil
 }
 
 // parseContainer extracts service configuration from container labels
@@ -90,15 +431,187 @@ func (c *Client) parseContainer(ctx context.Context, containerID string, labels
 		return nil, nil
 	}
 
+	// Get container details for port bindings
+	inspect, err := c.api().ContainerInspect(ctx, containerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect container: %w", err)
+	}
+
+	return c.parseInspect(ctx, labels, inspect)
+}
+
+// InspectEnabledContainer re-inspects a single container the way GetEnabledContainers lists it,
+// for reconciling one container without listing them all
+// An enabled container that fails to parse is returned as a ParseError; a removed container or
+// one that is not enabled, running or allowed returns neither
+func (c *Client) InspectEnabledContainer(ctx context.Context, containerID string) (*apptypes.ContainerService, *ParseError, error) {
+	inspect, err := c.api().ContainerInspect(ctx, containerID)
+	if client.IsErrNotFound(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to inspect container: %w", err)
+	}
+
+	if inspect.State == nil || !inspect.State.Running {
+		return nil, nil, nil
+	}
+	containerName := strings.TrimPrefix(inspect.Name, "/")
+	if ok, _ := c.nameFilter.allowed(containerName); !ok {
+		return nil, nil, nil
+	}
+
+	labels := c.inspectConfig(inspect)
+	if labels[apptypes.LabelEnable] != "true" || !c.inStage(labels) {
+		return nil, nil, nil
+	}
+	if inspect.State.Paused {
+		if err := pausedError(labels); err != nil {
+			return nil, &ParseError{ContainerID: inspect.ID[:12], ContainerName: containerName, Err: err}, nil
+		}
+		return nil, nil, nil
+	}
+
+	service, err := c.parseInspect(ctx, labels, inspect)
+	if err != nil {
+		return nil, &ParseError{ContainerID: inspect.ID[:12], ContainerName: containerName, Err: err}, nil
+	}
+	return service, nil, nil
+}
+
+// ExplainContainer resolves the service of one container, by name or ID, exactly as a reconciliation would
+// Unlike InspectEnabledContainer, every reason for not serving the container is reported as an error
+func (c *Client) ExplainContainer(ctx context.Context, nameOrID string) (*apptypes.ContainerService, error) {
+	inspect, err := c.api().ContainerInspect(ctx, nameOrID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect container %s: %w", nameOrID, err)
+	}
+
+	containerName := strings.TrimPrefix(inspect.Name, "/")
+	switch {
+	case inspect.State == nil || !inspect.State.Running:
+		return nil, fmt.Errorf("container %s is not running", containerName)
+	case inspect.State.Paused:
+		return nil, fmt.Errorf("container %s is paused", containerName)
+	}
+	if ok, reason := c.nameFilter.allowed(containerName); !ok {
+		return nil, fmt.Errorf("container %s is filtered out: it %s", containerName, reason)
+	}
+
+	labels := c.inspectConfig(inspect)
+	if labels[apptypes.LabelEnable] != "true" {
+		return nil, fmt.Errorf("container %s is not enabled (%s is not true)", containerName, apptypes.LabelEnable)
+	}
+	if !c.inStage(labels) {
+		return nil, fmt.Errorf("container %s is not in stage %s (%s is %q)", containerName, c.stageSelector, apptypes.LabelStage, labels[apptypes.LabelStage])
+	}
+
+	return c.parseInspect(ctx, labels, inspect)
+}
+
+// pausedError returns how a paused container is reported: ErrPaused to keep its service as is
+// (docktail.service.keep-on-pause, default true), nil to drop it like a stopped container,
+// or a label error if keep-on-pause is invalid
+func pausedError(labels map[string]string) error {
+	value := labels[apptypes.LabelKeepOnPause]
+	if value == "" {
+		return ErrPaused
+	}
+	keep, err := strconv.ParseBool(value)
+	if err != nil {
+		return newParseError(ErrInvalidLabel, "invalid %s value '%s': must be true or false", apptypes.LabelKeepOnPause, value)
+	}
+	if keep {
+		return ErrPaused
+	}
+	return nil
+}
+
+// inStage reports whether a container belongs to the STAGE_SELECTOR stage (always true when unset)
+func (c *Client) inStage(labels map[string]string) bool {
+	return c.stageSelector == "" || labels[apptypes.LabelStage] == c.stageSelector
+}
+
+// inspectConfig returns the DockTail configuration of an inspected container, keyed by label name
+func (c *Client) inspectConfig(inspect container.InspectResponse) map[string]string {
+	var labels map[string]string
+	var env []string
+	if inspect.Config != nil {
+		labels = inspect.Config.Labels
+		env = inspect.Config.Env
+	}
+	if c.configSource == ConfigSourceLabels {
+		return labels
+	}
+	return mergeConfig(c.configSource, labels, env)
+}
+
+// parseInspect builds the service for an enabled container from its configuration and inspect data
+func (c *Client) parseInspect(ctx context.Context, labels map[string]string, inspect container.InspectResponse) (*apptypes.ContainerService, error) {
+	containerID := inspect.ID
+
+	// Mid-restart the container has no IP yet; its service stays as it was until it's back
+	if inspect.State != nil && inspect.State.Restarting {
+		return nil, ErrRestarting
+	}
+
+	// Shared label blocks can derive values from the container, e.g. service={{ .Name }}
+	labels, err := renderLabels(labels, inspect)
+	if err != nil {
+		return nil, err
+	}
+
 	// Validate required labels
 	serviceName := labels[apptypes.LabelService]
+	autoName := false
 	if serviceName == "" {
-		return nil, fmt.Errorf("missing required label: %s", apptypes.LabelService)
+		if !c.autoServiceName {
+			return nil, newParseError(ErrMissingLabel, "missing required label: %s", apptypes.LabelService)
+		}
+		var err error
+		if serviceName, err = autoServiceName(strings.TrimPrefix(inspect.Name, "/")); err != nil {
+			return nil, err
+		}
+		autoName = true
 	}
 
+	// A unix socket backend or a served path replaces the container IP/port destination entirely
+	unixSocket := labels[apptypes.LabelUnixSocket]
+	servePath := labels[apptypes.LabelServePath]
 	targetPort := labels[apptypes.LabelTarget]
-	if targetPort == "" {
-		return nil, fmt.Errorf("missing required label: %s", apptypes.LabelTarget)
+	if servePath != "" {
+		if err := validateServePath(labels, servePath); err != nil {
+			return nil, err
+		}
+	} else if unixSocket != "" {
+		if err := validateUnixSocket(labels, unixSocket); err != nil {
+			return nil, err
+		}
+	} else {
+		if targetPort == "" {
+			return nil, newParseError(ErrMissingLabel, "missing required label: %s", apptypes.LabelTarget)
+		}
+		if err := validatePort("target", targetPort); err != nil {
+			return nil, err
+		}
+	}
+
+	// The port the backend actually listens on, when it differs from the advertised target
+	destPortLabel := labels[apptypes.LabelDestPort]
+	if destPortLabel != "" {
+		if unixSocket != "" || servePath != "" {
+			return nil, newParseError(ErrConflictingLabels, "%s cannot be combined with %s or %s", apptypes.LabelDestPort, apptypes.LabelUnixSocket, apptypes.LabelServePath)
+		}
+		if err := validatePort("dest", destPortLabel); err != nil {
+			return nil, err
+		}
+	}
+
+	// STRICT_LABELS: no guessing, every port and protocol must be spelled out
+	if c.strictLabels {
+		if err := requireLabels(labels, apptypes.LabelPort, apptypes.LabelServiceProtocol, apptypes.LabelTargetProtocol); err != nil {
+			return nil, err
+		}
 	}
 
 	// Optional labels with smart defaults - these work in both directions:
@@ -106,14 +619,35 @@ func (c *Client) parseContainer(ctx context.Context, containerID string, labels
 	// - If service-protocol=https and service-port unset → defaults to 443
 	port := labels[apptypes.LabelPort]
 	serviceProtocol := labels[apptypes.LabelServiceProtocol]
+	if port != "" {
+		if err := validatePort("service", port); err != nil {
+			return nil, err
+		}
+	}
 
 	// Smart defaults for target/container protocol based on CONTAINER port
 	// This needs to be parsed FIRST since it affects service protocol defaults
 	protocol := labels[apptypes.LabelTargetProtocol]
-	if protocol == "" {
+	if protocol == "" && unixSocket != "" {
+		// Tailscale only proxies HTTP over unix sockets
+		protocol = "http"
+	} else if protocol == "" && servePath != "" {
+		// Files are served by tailscaled itself, there is no backend protocol
+		protocol = "http"
+	} else if protocol == "" && c.defaultTargetProtocol != "" {
+		// Global override (DEFAULT_TARGET_PROTOCOL) replaces the port-based guess
+		protocol = c.defaultTargetProtocol
+		log.Debug().
+			Str("container", containerID[:12]).
+			Str("defaulted_protocol", protocol).
+			Msg("Container protocol not specified, using DEFAULT_TARGET_PROTOCOL")
+	} else if protocol == "" {
 		// Default based on container port
-		switch targetPort {
-		case "443":
+		switch {
+		case targetPort == "443" && c.defaultHTTPSInsecure:
+			// Internal TLS backends almost always use self-signed certificates
+			protocol = "https+insecure"
+		case targetPort == "443":
 			protocol = "https"
 		default:
 			protocol = "http"
@@ -126,15 +660,22 @@ func (c *Client) parseContainer(ctx context.Context, containerID string, labels
 	}
 
 	// Validate target protocol
-	validProtocols := map[string]bool{
-		"http":               true,
-		"https":              true,
-		"https+insecure":     true,
-		"tcp":                true,
-		"tls-terminated-tcp": true,
+	if !validTargetProtocols[protocol] {
+		return nil, newParseError(ErrInvalidProtocol, "invalid protocol: %s (must be http, https, https+insecure, tcp, or tls-terminated-tcp)", protocol)
 	}
-	if !validProtocols[protocol] {
-		return nil, fmt.Errorf("invalid protocol: %s (must be http, https, https+insecure, tcp, or tls-terminated-tcp)", protocol)
+
+	// A backend CA only makes sense for TLS backends; it implies https when the protocol is guessed
+	targetCA := labels[apptypes.LabelTargetCA]
+	if targetCA != "" {
+		if labels[apptypes.LabelTargetProtocol] == "" && protocol == "http" {
+			protocol = "https"
+		}
+		if protocol != "https" && protocol != "https+insecure" {
+			return nil, newParseError(ErrConflictingLabels, "%s requires %s=https (got %s)", apptypes.LabelTargetCA, apptypes.LabelTargetProtocol, protocol)
+		}
+		if err := validateTargetCA(targetCA); err != nil {
+			return nil, err
+		}
 	}
 
 	// Smart defaults based on both fields
@@ -207,17 +748,79 @@ func (c *Client) parseContainer(ctx context.Context, containerID string, labels
 		"tls-terminated-tcp": true,
 	}
 	if !validServiceProtocols[serviceProtocol] {
-		return nil, fmt.Errorf("invalid service-protocol: %s (must be http, https, tcp, or tls-terminated-tcp)", serviceProtocol)
+		return nil, newParseError(ErrInvalidProtocol, "invalid service-protocol: %s (must be http, https, tcp, or tls-terminated-tcp)", serviceProtocol)
 	}
 
-	// Get container details for port bindings
-	inspect, err := c.cli.ContainerInspect(ctx, containerID)
+	basicAuth, err := parseBasicAuth(labels, serviceProtocol)
 	if err != nil {
-		return nil, fmt.Errorf("failed to inspect container: %w", err)
+		return nil, err
+	}
+
+	tcpTimeout, idleTimeout, err := parseTCPTimeouts(labels, serviceProtocol)
+	if err != nil {
+		return nil, err
+	}
+
+	// TLS SNI only applies when Tailscale terminates TLS for a raw TCP stream
+	tlsSNI := labels[apptypes.LabelTLSSNI]
+	if tlsSNI != "" {
+		if serviceProtocol != "tls-terminated-tcp" {
+			return nil, newParseError(ErrConflictingLabels, "%s is only valid with service-protocol tls-terminated-tcp (got %s)", apptypes.LabelTLSSNI, serviceProtocol)
+		}
+		if !validHostname.MatchString(tlsSNI) {
+			return nil, newParseError(ErrInvalidLabel, "invalid %s value '%s': must be a DNS hostname", apptypes.LabelTLSSNI, tlsSNI)
+		}
+	}
+
+	// The redirect needs port 80 for itself and an HTTPS endpoint to point at
+	httpsRedirect := false
+	if value := labels[apptypes.LabelHTTPSRedirect]; value != "" {
+		var err error
+		httpsRedirect, err = strconv.ParseBool(value)
+		if err != nil {
+			return nil, newParseError(ErrInvalidLabel, "invalid %s value '%s': must be true or false", apptypes.LabelHTTPSRedirect, value)
+		}
+	}
+	if httpsRedirect {
+		if serviceProtocol != "https" {
+			return nil, newParseError(ErrConflictingLabels, "%s requires service-protocol https (got %s)", apptypes.LabelHTTPSRedirect, serviceProtocol)
+		}
+		if port == "80" {
+			return nil, newParseError(ErrConflictingLabels, "%s needs port 80 for the redirect, but the HTTPS service-port is also 80", apptypes.LabelHTTPSRedirect)
+		}
+	}
+
+	// Served by tailscaled's file handler while the health checker marks the backend down
+	maintenancePage := labels[apptypes.LabelMaintenancePage]
+	if maintenancePage != "" {
+		if !strings.HasPrefix(maintenancePage, "/") {
+			return nil, newParseError(ErrInvalidLabel, "invalid %s value '%s': must be an absolute path", apptypes.LabelMaintenancePage, maintenancePage)
+		}
+		if serviceProtocol != "http" && serviceProtocol != "https" {
+			return nil, newParseError(ErrConflictingLabels, "%s requires service-protocol http or https (got %s)", apptypes.LabelMaintenancePage, serviceProtocol)
+		}
+	}
+
+	if servePath != "" && serviceProtocol != "http" && serviceProtocol != "https" {
+		return nil, newParseError(ErrConflictingLabels, "%s requires service-protocol http or https (got %s)", apptypes.LabelServePath, serviceProtocol)
 	}
 
 	containerName := strings.TrimPrefix(inspect.Name, "/")
 
+	// Sidecars (network_mode: container:<other>) have no network of their own, so the
+	// IP, published ports and DNS name all come from the container owning the namespace
+	dnsName := containerName
+	networkOwner := ""
+	if unixSocket == "" && servePath == "" && inspect.HostConfig != nil && inspect.HostConfig.NetworkMode.IsContainer() {
+		owner, err := c.inspectNetworkOwner(ctx, inspect.HostConfig.NetworkMode.ConnectedContainer(), containerName)
+		if err != nil {
+			return nil, err
+		}
+		inspect = owner
+		networkOwner = strings.TrimPrefix(owner.Name, "/")
+		dnsName = networkOwner
+	}
+
 	// Check if container uses host networking
 	isHostNetwork := inspect.HostConfig != nil && string(inspect.HostConfig.NetworkMode) == "host"
 	// Check if container uses no networking
@@ -226,53 +829,120 @@ func (c *Client) parseContainer(ctx context.Context, containerID string, labels
 	// Direct container IP proxying is enabled by default
 	// Set docktail.service.direct=false to use published port bindings instead
 	isDirectMode := labels[apptypes.LabelDirect] != "false"
+	if isDirectMode && unixSocket == "" && servePath == "" && !isHostNetwork && !isNoNetwork && c.runtime == RuntimePodman && !hasRoutableNetwork(inspect) {
+		// Rootless Podman (slirp4netns/pasta) reports no container IP, but published ports still work
+		isDirectMode = false
+		log.Info().
+			Str("container", containerName).
+			Msg("Podman container has no routable network (slirp4netns/pasta), falling back to published ports")
+	}
 	specifiedNetwork := labels[apptypes.LabelNetwork]
 
 	// Variables for destination configuration
 	var destIP string
 	var destPort string
+	var destNetwork string
 
-	if isHostNetwork {
+	if unixSocket != "" {
+		// The socket path is resolved by tailscaled, so it must be mounted where tailscaled can see it
+		log.Info().
+			Str("container", containerName).
+			Str("unix_socket", unixSocket).
+			Msg("Proxying to unix socket (no IP or port needed)")
+	} else if servePath != "" {
+		// Like the socket, the path is opened by tailscaled
+		log.Info().
+			Str("container", containerName).
+			Str("serve_path", servePath).
+			Msg("Serving files from path instead of proxying (no IP or port needed)")
+	} else if isHostNetwork {
 		// For host networking, the container port IS the host port on localhost
 		destIP = "localhost"
-		destPort = targetPort
+		destPort = backendPort(targetPort, destPortLabel)
 		log.Info().
 			Str("container", containerName).
-			Str("port", targetPort).
+			Str("port", destPort).
 			Msg("Container uses host networking, port is directly accessible on localhost")
 	} else if isDirectMode {
 		// Direct mode: proxy to container IP instead of published host port
 		if isNoNetwork {
-			return nil, fmt.Errorf("container '%s' uses network_mode: none, cannot use direct mode", containerName)
+			return nil, newParseError(ErrConflictingLabels, "container '%s' uses network_mode: none, cannot use direct mode", containerName)
 		}
 
 		// Get container IP from network settings
-		containerIP, networkName, err := c.getContainerIP(inspect, specifiedNetwork, containerName)
+		containerIP, networkName, fresh, err := c.containerIPWithRetry(ctx, inspect, specifiedNetwork, containerName)
 		if err != nil {
+			if networkOwner != "" {
+				return nil, fmt.Errorf("container '%s' shares the network of '%s': %w", containerName, networkOwner, err)
+			}
 			return nil, err
 		}
 
+		inspect = fresh
 		destIP = containerIP
-		destPort = targetPort // Use container port directly
+		destPort = backendPort(targetPort, destPortLabel) // Use container port directly
+		destNetwork = networkName
 
-		// Optional reachability check - just for debugging, doesn't block configuration
-		if err := c.checkReachability(containerIP, targetPort); err != nil {
+		// Proxy to the container name instead of its IP so recreates don't leave a stale IP behind
+		// Docker's embedded DNS only exists on user-defined networks
+		if labels[apptypes.LabelUseDNS] == "true" {
+			if networkName == "bridge" {
+				return nil, newParseError(ErrConflictingLabels, "container '%s' sets %s=true but is on the default bridge network, which has no DNS; attach it to a user-defined network", containerName, apptypes.LabelUseDNS)
+			}
+			destIP = dnsName
+			log.Debug().
+				Str("container", containerName).
+				Str("network", networkName).
+				Msg("Using container name as destination host (DNS mode)")
+		}
+
+		waitReady, err := c.waitReadyFor(labels[apptypes.LabelWaitReady])
+		if err != nil {
+			return nil, err
+		}
+
+		skipReachability, err := c.skipReachabilityFor(labels[apptypes.LabelSkipReachability])
+		if err != nil {
+			return nil, err
+		}
+
+		if waitReady > 0 {
+			// Opt-in blocking check - don't configure a backend that isn't listening yet
+			if err := c.waitForReachable(ctx, containerIP, destPort, waitReady); err != nil {
+				log.Warn().
+					Str("container", containerName).
+					Str("container_ip", containerIP).
+					Str("port", destPort).
+					Dur("timeout", waitReady).
+					Msg("Container did not become reachable in time, skipping this loop")
+				return nil, newParseError(ErrBackendUnreachable, "container '%s' not reachable at %s after %s: %w", containerName, net.JoinHostPort(containerIP, destPort), waitReady, err)
+			}
+		} else if skipReachability {
+			// A bare TCP dial is meaningless for some backends and slow for ones still starting
+			log.Debug().
+				Str("container", containerName).
+				Msg("Reachability check skipped")
+		} else if err := c.checkReachability(containerIP, destPort); err != nil {
+			// Optional reachability check - just for debugging, doesn't block configuration
 			log.Debug().
 				Str("container", containerName).
 				Str("container_ip", containerIP).
-				Str("port", targetPort).
+				Str("port", destPort).
 				Msg("Container not yet reachable (may still be starting)")
 		}
 
 		log.Info().
 			Str("container", containerName).
 			Str("container_ip", containerIP).
-			Str("container_port", targetPort).
+			Str("container_port", destPort).
 			Str("network", networkName).
-			Str("will_proxy_to", fmt.Sprintf("%s:%s", containerIP, targetPort)).
+			Str("will_proxy_to", fmt.Sprintf("%s:%s", destIP, destPort)).
 			Msg("Proxying directly to container IP (no port publishing required)")
 	} else {
 		// Direct mode disabled (docktail.service.direct=false) - need published port bindings
+		if destPortLabel != "" {
+			return nil, newParseError(ErrConflictingLabels, "container '%s' sets %s but has direct mode disabled; use %s to choose the published port", containerName, apptypes.LabelDestPort, apptypes.LabelHostPort)
+		}
 		targetPortKey := nat.Port(fmt.Sprintf("%s/tcp", targetPort))
 		var hostPort string
 
@@ -281,28 +951,41 @@ func (c *Client) parseContainer(ctx context.Context, containerID string, labels
 			Str("looking_for_port", string(targetPortKey)).
 			Msg("Direct mode disabled, looking for published port binding")
 
-		if inspect.HostConfig != nil && inspect.HostConfig.PortBindings != nil {
-			if bindings, ok := inspect.HostConfig.PortBindings[targetPortKey]; ok && len(bindings) > 0 {
-				// Use the first host port binding
-				hostPort = bindings[0].HostPort
-				log.Debug().
-					Str("container", containerName).
-					Str("target_port", targetPort).
-					Str("host_port", hostPort).
-					Msg("Detected published port binding")
+		pinnedPort := labels[apptypes.LabelHostPort]
+		if pinnedPort != "" {
+			if err := validatePort("host", pinnedPort); err != nil {
+				return nil, err
 			}
 		}
 
-		// If no port binding found, check NetworkSettings.Ports as fallback
-		if hostPort == "" && inspect.NetworkSettings != nil && inspect.NetworkSettings.Ports != nil {
-			if bindings, ok := inspect.NetworkSettings.Ports[targetPortKey]; ok && len(bindings) > 0 {
-				hostPort = bindings[0].HostPort
-				log.Debug().
+		candidates := []string{}
+		if inspect.HostConfig != nil && inspect.HostConfig.PortBindings != nil {
+			candidates = hostPorts(inspect.HostConfig.PortBindings[targetPortKey])
+		}
+		// Ports published without a fixed host port only show up in NetworkSettings.Ports
+		if len(candidates) == 0 && inspect.NetworkSettings != nil && inspect.NetworkSettings.Ports != nil {
+			candidates = hostPorts(inspect.NetworkSettings.Ports[targetPortKey])
+		}
+
+		if len(candidates) > 0 {
+			var err error
+			hostPort, err = selectHostPort(candidates, pinnedPort)
+			if err != nil {
+				return nil, fmt.Errorf("container '%s': %w", containerName, err)
+			}
+			if len(candidates) > 1 && pinnedPort == "" {
+				log.Warn().
 					Str("container", containerName).
 					Str("target_port", targetPort).
-					Str("host_port", hostPort).
-					Msg("Detected published port from NetworkSettings")
+					Strs("host_ports", candidates).
+					Str("using", hostPort).
+					Msgf("Container port is published to several host ports, set %s to choose one", apptypes.LabelHostPort)
 			}
+			log.Debug().
+				Str("container", containerName).
+				Str("target_port", targetPort).
+				Str("host_port", hostPort).
+				Msg("Detected published port binding")
 		}
 
 		if hostPort == "" {
@@ -320,7 +1003,7 @@ func (c *Client) parseContainer(ctx context.Context, containerID string, labels
 				Strs("available_ports", availablePorts).
 				Msg("Port not found in bindings (direct mode is disabled)")
 
-			return nil, fmt.Errorf(
+			return nil, newParseError(ErrPortNotPublished,
 				"container port %s is NOT published to host (direct mode disabled via docktail.service.direct=false). "+
 					"Fix: Add 'ports: [\"%s:%s\"]' to container '%s' in docker-compose.yaml, "+
 					"or remove 'docktail.service.direct=false' to use container IP directly. "+
@@ -329,166 +1012,331 @@ func (c *Client) parseContainer(ctx context.Context, containerID string, labels
 			)
 		}
 
-		destIP = "localhost"
+		// PUBLISHED_HOST (or the gateway with PUBLISHED_VIA=gateway) lets DockTail reach the host
+		// when it isn't using host networking itself
+		// Funnel entries share this destination host
+		destIP = c.publishedHost
 		destPort = hostPort
 
 		log.Info().
 			Str("container", containerName).
 			Str("container_port", targetPort).
 			Str("host_port", hostPort).
-			Str("will_proxy_to", fmt.Sprintf("localhost:%s", hostPort)).
+			Str("will_proxy_to", net.JoinHostPort(destIP, hostPort)).
 			Msg("Direct mode disabled - using published port binding")
 	}
 
 	// Parse tags
-	var tags []string
-	if tagsStr := labels[apptypes.LabelTags]; tagsStr != "" {
-		// Split by comma and trim spaces
-		parts := strings.Split(tagsStr, ",")
-		for _, part := range parts {
-			if trimmed := strings.TrimSpace(part); trimmed != "" {
-				// Warn if tag doesn't follow Tailscale convention
-				if !strings.HasPrefix(trimmed, "tag:") {
-					log.Warn().
-						Str("container", containerName).
-						Str("tag", trimmed).
-						Msg("Tag should start with 'tag:' prefix per Tailscale convention")
-				}
-				tags = append(tags, trimmed)
-			}
+	tags := parseTags(labels[apptypes.LabelTags], containerName)
+
+	tagsMode := labels[apptypes.LabelTagsMode]
+	if tagsMode == "" {
+		tagsMode = "replace"
+		if c.tagsMerge {
+			tagsMode = "append"
 		}
-	} else {
+	}
+	switch {
+	case tagsMode != "replace" && tagsMode != "append":
+		return nil, newParseError(ErrInvalidLabel, "invalid %s: %s (must be replace or append)", apptypes.LabelTagsMode, tagsMode)
+	case len(tags) == 0:
 		// Use default tags if no override provided
 		tags = make([]string, len(c.defaultTags))
 		copy(tags, c.defaultTags)
+	case tagsMode == "append":
+		// Defaults first, then container tags, without duplicates
+		tags = mergeTags(c.defaultTags, tags)
+	}
+
+	// Service comment shown in the admin console, so every managed service is identifiable
+	comment := labels[apptypes.LabelComment]
+	if comment == "" {
+		comment = fmt.Sprintf("managed by docktail: %s", containerName)
 	}
 
 	// Parse funnel configuration (COMPLETELY INDEPENDENT of serve)
 	funnelEnabled := labels[apptypes.LabelFunnelEnable] == "true"
-	var funnelPort, funnelTargetPort, funnelFunnelPort, funnelProtocol string
+	var funnels []apptypes.FunnelConfig
+
+	// Tags allowed to reach the service, pushed as a policy grant with ACL_SYNC=true
+	allowedTags := parseTags(labels[apptypes.LabelAllowedTags], containerName)
+
+	// Funnel-enabled services can carry their own tags, e.g. for a stricter ACL on public services
+	funnelTags := parseTags(labels[apptypes.LabelFunnelTags], containerName)
+	if len(funnelTags) > 0 && !funnelEnabled {
+		log.Warn().
+			Str("container", containerName).
+			Msgf("%s is set but funnel is not enabled, using the service tags", apptypes.LabelFunnelTags)
+		funnelTags = nil
+	}
 
 	if funnelEnabled {
-		// Get funnel-specific container port (like service.port but for funnel)
-		funnelPort = labels[apptypes.LabelFunnelPort]
-		if funnelPort == "" {
-			return nil, fmt.Errorf("funnel enabled but missing required label: %s (container port)", apptypes.LabelFunnelPort)
+		var err error
+		funnels, err = c.parseFunnels(labels, inspect, isHostNetwork, isDirectMode, containerName)
+		if err != nil {
+			return nil, err
 		}
-
-		// Get funnel protocol
-		funnelProtocol = labels[apptypes.LabelFunnelProtocol]
-		if funnelProtocol == "" {
-			funnelProtocol = "https" // Default to HTTPS
-			log.Debug().
-				Str("container", containerID[:12]).
-				Msg("Funnel protocol not specified, defaulting to HTTPS")
+		targetProtocol, err := funnelTargetProtocol(labels[apptypes.LabelFunnelTarget], protocol)
+		if err != nil {
+			return nil, err
 		}
+		for i := range funnels {
+			if funnels[i].Protocol == "https" {
+				funnels[i].TargetProtocol = targetProtocol
+			}
+		}
+	} else if labels[apptypes.LabelFunnelTarget] != "" {
+		log.Warn().
+			Str("container", containerName).
+			Msgf("%s is set but funnel is not enabled, ignoring it", apptypes.LabelFunnelTarget)
+	}
 
-		// Get public-facing funnel port (funnel-port)
-		funnelFunnelPort = labels[apptypes.LabelFunnelFunnelPort]
-		if funnelFunnelPort == "" {
-			funnelFunnelPort = "443" // Default to 443
-			log.Debug().
-				Str("container", containerID[:12]).
-				Msg("Funnel public port not specified, defaulting to 443")
+	// Funnel-only containers are public without an internal svc: service
+	funnelOnly := false
+	if value := labels[apptypes.LabelFunnelOnly]; value != "" {
+		var err error
+		funnelOnly, err = strconv.ParseBool(value)
+		if err != nil {
+			return nil, newParseError(ErrInvalidLabel, "invalid %s value '%s': must be true or false", apptypes.LabelFunnelOnly, value)
 		}
+	}
+	if funnelOnly && !funnelEnabled {
+		return nil, newParseError(ErrConflictingLabels, "%s requires %s=true", apptypes.LabelFunnelOnly, apptypes.LabelFunnelEnable)
+	}
 
-		// Validate funnel-port for HTTPS (must be 443, 8443, or 10000)
-		if funnelProtocol == "https" || funnelProtocol == "http" {
-			validFunnelPorts := map[string]bool{
-				"443":   true,
-				"8443":  true,
-				"10000": true,
-			}
-			if !validFunnelPorts[funnelFunnelPort] {
-				return nil, fmt.Errorf("invalid funnel-port: %s for HTTPS/HTTP (must be 443, 8443, or 10000)", funnelFunnelPort)
+	// Known-broken best-effort backends can opt out of being retried every loop
+	skipOnError := false
+	switch onError := labels[apptypes.LabelOnError]; onError {
+	case "", "retry":
+	case "skip":
+		skipOnError = true
+	default:
+		return nil, newParseError(ErrInvalidLabel, "invalid %s value '%s': must be retry or skip", apptypes.LabelOnError, onError)
+	}
+
+	return &apptypes.ContainerService{
+		ContainerID:     containerID[:12],
+		ContainerName:   containerName,
+		ServiceName:     serviceName,
+		AutoName:        autoName,
+		Port:            port,
+		TargetPort:      destPort,
+		ServiceProtocol: serviceProtocol,
+		Protocol:        protocol,
+		Tags:            tags,
+		FunnelTags:      funnelTags,
+		AllowedTags:     allowedTags,
+		IPAddress:       destIP,
+		FunnelEnabled:   funnelEnabled,
+		FunnelOnly:      funnelOnly,
+		Funnels:         funnels,
+		Comment:         comment,
+		TLSSNI:          strings.ToLower(tlsSNI),
+		Group:           labels[apptypes.LabelGroup],
+		UnixSocket:      unixSocket,
+		HTTPSRedirect:   httpsRedirect,
+		MaintenancePage: maintenancePage,
+		ServePath:       servePath,
+		Network:         destNetwork,
+		Tailnet:         labels[apptypes.LabelTailnet],
+		SkipOnError:     skipOnError,
+		TargetCA:        targetCA,
+		BasicAuth:       basicAuth,
+		TCPTimeout:      tcpTimeout,
+		IdleTimeout:     idleTimeout,
+		StartedAt:       startedAt(inspect),
+	}, nil
+}
+
+// startedAt returns when the container was last started, zero if Docker doesn't report it
+func startedAt(inspect container.InspectResponse) time.Time {
+	if inspect.State == nil {
+		return time.Time{}
+	}
+	started, err := time.Parse(time.RFC3339Nano, inspect.State.StartedAt)
+	if err != nil {
+		return time.Time{}
+	}
+	return started
+}
+
+// parseTags splits a comma-separated tag list, warning about tags without the tag: prefix
+func parseTags(value, containerName string) []string {
+	var tags []string
+	for _, part := range strings.Split(value, ",") {
+		if trimmed := strings.TrimSpace(part); trimmed != "" {
+			// Warn if tag doesn't follow Tailscale convention
+			if !strings.HasPrefix(trimmed, "tag:") {
+				log.Warn().
+					Str("container", containerName).
+					Str("tag", trimmed).
+					Msg("Tag should start with 'tag:' prefix per Tailscale convention")
 			}
+			tags = append(tags, trimmed)
 		}
+	}
+	return tags
+}
 
-		// Validate funnel protocol
-		validFunnelProtocols := map[string]bool{
-			"https":              true,
-			"tcp":                true,
-			"tls-terminated-tcp": true,
+// unixSocketConflicts are labels that only make sense for IP/port backends
+var unixSocketConflicts = []string{
+	apptypes.LabelTarget,
+	apptypes.LabelDirect,
+	apptypes.LabelNetwork,
+	apptypes.LabelUseDNS,
+	apptypes.LabelWaitReady,
+}
+
+// requireLabels returns an error naming the first missing label, for STRICT_LABELS=true
+func requireLabels(labels map[string]string, names ...string) error {
+	for _, name := range names {
+		if labels[name] == "" {
+			return newParseError(ErrMissingLabel, "missing required label: %s (STRICT_LABELS=true disables defaults)", name)
 		}
-		if !validFunnelProtocols[funnelProtocol] {
-			return nil, fmt.Errorf("invalid funnel protocol: %s (must be https, tcp, or tls-terminated-tcp)", funnelProtocol)
+	}
+	return nil
+}
+
+// validateUnixSocket checks a unix-socket label and rejects labels that conflict with it
+func validateUnixSocket(labels map[string]string, socketPath string) error {
+	if !strings.HasPrefix(socketPath, "/") {
+		return newParseError(ErrInvalidLabel, "invalid %s value '%s': must be an absolute path", apptypes.LabelUnixSocket, socketPath)
+	}
+	for _, label := range unixSocketConflicts {
+		if labels[label] != "" {
+			return newParseError(ErrConflictingLabels, "%s conflicts with %s: a unix socket backend has no container IP or port", label, apptypes.LabelUnixSocket)
 		}
+	}
+	if protocol := labels[apptypes.LabelTargetProtocol]; protocol != "" && protocol != "http" {
+		return newParseError(ErrInvalidProtocol, "invalid protocol %s with %s: unix socket backends must speak http", protocol, apptypes.LabelUnixSocket)
+	}
+	if labels[apptypes.LabelFunnelEnable] == "true" {
+		return newParseError(ErrConflictingLabels, "%s conflicts with %s: funnel requires a TCP backend", apptypes.LabelFunnelEnable, apptypes.LabelUnixSocket)
+	}
+	return nil
+}
 
-		// Find the published host port for the funnel container port
-		if isHostNetwork {
-			// For host networking, the container port IS the host port
-			funnelTargetPort = funnelPort
-		} else if isDirectMode {
-			// Direct mode: use container port directly (funnel will use same destIP as service)
-			funnelTargetPort = funnelPort
-		} else {
-			funnelPortKey := nat.Port(fmt.Sprintf("%s/tcp", funnelPort))
-			if inspect.HostConfig != nil && inspect.HostConfig.PortBindings != nil {
-				if bindings, ok := inspect.HostConfig.PortBindings[funnelPortKey]; ok && len(bindings) > 0 {
-					funnelTargetPort = bindings[0].HostPort
-				}
-			}
-			if funnelTargetPort == "" && inspect.NetworkSettings != nil && inspect.NetworkSettings.Ports != nil {
-				if bindings, ok := inspect.NetworkSettings.Ports[funnelPortKey]; ok && len(bindings) > 0 {
-					funnelTargetPort = bindings[0].HostPort
-				}
-			}
+// servePathConflicts are labels that only make sense for proxied backends
+var servePathConflicts = append([]string{
+	apptypes.LabelUnixSocket,
+	apptypes.LabelMaintenancePage,
+	apptypes.LabelTargetProtocol,
+}, unixSocketConflicts...)
 
-			if funnelTargetPort == "" {
-				return nil, fmt.Errorf("funnel container port %s is NOT published to host (direct mode disabled). Add it to ports in docker-compose, or remove 'docktail.service.direct=false'", funnelPort)
-			}
+// validateTargetCA checks that a target-ca label points at a readable PEM certificate bundle
+func validateTargetCA(path string) error {
+	if !strings.HasPrefix(path, "/") {
+		return newParseError(ErrInvalidLabel, "invalid %s value '%s': must be an absolute path", apptypes.LabelTargetCA, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return newParseError(ErrInvalidLabel, "invalid %s value '%s': %v", apptypes.LabelTargetCA, path, err)
+	}
+	if !x509.NewCertPool().AppendCertsFromPEM(data) {
+		return newParseError(ErrInvalidLabel, "invalid %s value '%s': no PEM certificates found", apptypes.LabelTargetCA, path)
+	}
+	return nil
+}
+
+// validateServePath checks a serve-path label and rejects labels that conflict with it
+func validateServePath(labels map[string]string, servePath string) error {
+	if !strings.HasPrefix(servePath, "/") {
+		return newParseError(ErrInvalidLabel, "invalid %s value '%s': must be an absolute path", apptypes.LabelServePath, servePath)
+	}
+	for _, label := range servePathConflicts {
+		if labels[label] != "" {
+			return newParseError(ErrConflictingLabels, "%s conflicts with %s: served files have no backend to proxy to", label, apptypes.LabelServePath)
 		}
+	}
+	if labels[apptypes.LabelFunnelEnable] == "true" {
+		return newParseError(ErrConflictingLabels, "%s conflicts with %s: funnel requires a TCP backend", apptypes.LabelFunnelEnable, apptypes.LabelServePath)
+	}
+	if _, err := os.Stat(servePath); err != nil {
+		return newParseError(ErrInvalidLabel, "invalid %s value '%s': %v", apptypes.LabelServePath, servePath, err)
+	}
+	return nil
+}
 
-		log.Info().
-			Str("container", containerName).
-			Str("funnel_container_port", funnelPort).
-			Str("funnel_host_port", funnelTargetPort).
-			Str("funnel_public_port", funnelFunnelPort).
-			Str("funnel_protocol", funnelProtocol).
-			Msg("Funnel enabled for public internet access")
+// mergeTags returns base followed by extra, dropping duplicates while preserving order
+func mergeTags(base, extra []string) []string {
+	merged := make([]string, 0, len(base)+len(extra))
+	seen := make(map[string]bool, len(base)+len(extra))
+	for _, tag := range append(append([]string{}, base...), extra...) {
+		if !seen[tag] {
+			seen[tag] = true
+			merged = append(merged, tag)
+		}
 	}
+	return merged
+}
 
-	return &apptypes.ContainerService{
-		ContainerID:      containerID[:12],
-		ContainerName:    containerName,
-		ServiceName:      serviceName,
-		Port:             port,
-		TargetPort:       destPort,
-		ServiceProtocol:  serviceProtocol,
-		Protocol:         protocol,
-		Tags:             tags,
-		IPAddress:        destIP,
-		FunnelEnabled:    funnelEnabled,
-		FunnelPort:       funnelPort,       // Container port for funnel
-		FunnelTargetPort: funnelTargetPort, // Host port for funnel (or container port in direct mode)
-		FunnelFunnelPort: funnelFunnelPort, // Public port for funnel
-		FunnelProtocol:   funnelProtocol,
-	}, nil
+// hostPorts lists the distinct host ports of a container port's bindings, in binding order
+// IPv4 and IPv6 bindings of the same host port are listed once
+func hostPorts(bindings []nat.PortBinding) []string {
+	var ports []string
+	for _, binding := range bindings {
+		if binding.HostPort != "" && !slices.Contains(ports, binding.HostPort) {
+			ports = append(ports, binding.HostPort)
+		}
+	}
+	return ports
+}
+
+// selectHostPort picks the host port to proxy to: the docktail.service.host-port label if set,
+// which must be one of the candidates, otherwise the first binding
+func selectHostPort(candidates []string, pinned string) (string, error) {
+	if pinned == "" {
+		return candidates[0], nil
+	}
+	if !slices.Contains(candidates, pinned) {
+		return "", newParseError(ErrPortNotPublished, "%s=%s is not published for this port (published host ports: %v)", apptypes.LabelHostPort, pinned, candidates)
+	}
+	return pinned, nil
+}
+
+// backendPort returns the port the backend listens on: the dest-port label if set, otherwise the target port
+func backendPort(targetPort, destPort string) string {
+	if destPort != "" {
+		return destPort
+	}
+	return targetPort
+}
+
+// validatePort checks that a port label is an integer in 1-65535
+func validatePort(kind, value string) error {
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 1 || port > 65535 {
+		return newParseError(ErrInvalidLabel, "invalid %s port '%s': must be an integer 1-65535", kind, value)
+	}
+	return nil
 }
 
 // getContainerIP extracts the container's IP address from the specified or default network
 func (c *Client) getContainerIP(inspect container.InspectResponse, specifiedNetwork string, containerName string) (string, string, error) {
 	if inspect.NetworkSettings == nil || inspect.NetworkSettings.Networks == nil {
-		return "", "", fmt.Errorf("container '%s' has no network settings", containerName)
+		return "", "", newParseError(ErrNoContainerIP, "container '%s' has no network settings", containerName)
 	}
 
 	networks := inspect.NetworkSettings.Networks
+	names := getNetworkNames(networks)
+	sort.Strings(names)
 
 	// If a specific network is specified, use it
 	if specifiedNetwork != "" {
 		// Try exact match first
 		if network, ok := networks[specifiedNetwork]; ok {
 			if network.IPAddress == "" {
-				return "", "", fmt.Errorf("container '%s' has no IP address on network '%s'", containerName, specifiedNetwork)
+				return "", "", newParseError(ErrNoContainerIP, "container '%s' has no IP address on network '%s'", containerName, specifiedNetwork)
 			}
 			return network.IPAddress, specifiedNetwork, nil
 		}
 
 		// Try suffix match (handles docker-compose project prefixes like "projectname_backend")
-		for networkName, network := range networks {
-			if strings.HasSuffix(networkName, "_"+specifiedNetwork) {
+		for _, networkName := range names {
+			network := networks[networkName]
+			if network != nil && strings.HasSuffix(networkName, "_"+specifiedNetwork) {
 				if network.IPAddress == "" {
-					return "", "", fmt.Errorf("container '%s' has no IP address on network '%s'", containerName, networkName)
+					return "", "", newParseError(ErrNoContainerIP, "container '%s' has no IP address on network '%s'", containerName, networkName)
 				}
 				log.Debug().
 					Str("container", containerName).
@@ -499,18 +1347,32 @@ func (c *Client) getContainerIP(inspect container.InspectResponse, specifiedNetw
 			}
 		}
 
-		return "", "", fmt.Errorf("container '%s' is not connected to network '%s' (available: %v)", containerName, specifiedNetwork, getNetworkNames(networks))
+		return "", "", newParseError(ErrNoContainerIP, "container '%s' is not connected to network '%s' (available: %v)", containerName, specifiedNetwork, getNetworkNames(networks))
 	}
 
-	// No network specified - try common defaults then fall back to first available
-	// Priority: bridge > first available
-	if network, ok := networks["bridge"]; ok && network.IPAddress != "" {
+	// No network specified - try preferred networks then fall back to first available
+	// Priority: NETWORK_PRIORITY suffixes in order > bridge > first available (sorted by name)
+
+	for _, suffix := range c.networkPriority {
+		for _, networkName := range names {
+			if network := networks[networkName]; network != nil && network.IPAddress != "" && strings.HasSuffix(networkName, suffix) {
+				log.Debug().
+					Str("container", containerName).
+					Str("network", networkName).
+					Str("priority", suffix).
+					Msg("Using preferred network from NETWORK_PRIORITY")
+				return network.IPAddress, networkName, nil
+			}
+		}
+	}
+
+	if network, ok := networks["bridge"]; ok && network != nil && network.IPAddress != "" {
 		return network.IPAddress, "bridge", nil
 	}
 
-	// Fall back to first available network with an IP
-	for networkName, network := range networks {
-		if network.IPAddress != "" {
+	// Fall back to first available network with an IP, in name order so the choice is stable
+	for _, networkName := range names {
+		if network := networks[networkName]; network != nil && network.IPAddress != "" {
 			log.Debug().
 				Str("container", containerName).
 				Str("network", networkName).
@@ -520,7 +1382,57 @@ func (c *Client) getContainerIP(inspect container.InspectResponse, specifiedNetw
 		}
 	}
 
-	return "", "", fmt.Errorf("container '%s' has no IP address on any network", containerName)
+	return "", "", newParseError(ErrNoContainerIP, "container '%s' has no IP address on any network", containerName)
+}
+
+// inspectNetworkOwner inspects the container whose network namespace containerName joined
+// with network_mode: container:<ref>
+func (c *Client) inspectNetworkOwner(ctx context.Context, ref string, containerName string) (container.InspectResponse, error) {
+	owner, err := c.api().ContainerInspect(ctx, ref)
+	if err != nil {
+		return owner, newParseError(ErrNoContainerIP, "container '%s' shares the network of container '%s', which could not be found: %w", containerName, ref, err)
+	}
+	ownerName := strings.TrimPrefix(owner.Name, "/")
+	if owner.State == nil || !owner.State.Running {
+		return owner, newParseError(ErrNoContainerIP, "container '%s' shares the network of container '%s', which is not running", containerName, ownerName)
+	}
+	if owner.HostConfig != nil && owner.HostConfig.NetworkMode.IsContainer() {
+		return owner, newParseError(ErrNoContainerIP, "container '%s' shares the network of container '%s', which itself shares the network of '%s'", containerName, ownerName, owner.HostConfig.NetworkMode.ConnectedContainer())
+	}
+
+	log.Debug().
+		Str("container", containerName).
+		Str("network_owner", ownerName).
+		Msg("Container shares another container's network, using its IP and ports")
+	return owner, nil
+}
+
+// containerIPWithRetry is getContainerIP, re-inspecting the container up to c.ipRetries times,
+// c.ipRetryDelay apart, while it has no IP yet
+// The latest inspect is returned so the rest of the parse sees the same state
+func (c *Client) containerIPWithRetry(ctx context.Context, inspect container.InspectResponse, specifiedNetwork string, containerName string) (string, string, container.InspectResponse, error) {
+	ip, networkName, err := c.getContainerIP(inspect, specifiedNetwork, containerName)
+	for attempt := 1; attempt <= c.ipRetries && errors.Is(err, ErrNoContainerIP); attempt++ {
+		log.Debug().
+			Str("container", containerName).
+			Int("attempt", attempt).
+			Dur("delay", c.ipRetryDelay).
+			Msg("Container has no IP yet, inspecting again")
+
+		select {
+		case <-ctx.Done():
+			return "", "", inspect, ctx.Err()
+		case <-time.After(c.ipRetryDelay):
+		}
+
+		fresh, inspectErr := c.api().ContainerInspect(ctx, inspect.ID)
+		if inspectErr != nil {
+			return "", "", inspect, fmt.Errorf("failed to re-inspect container: %w", inspectErr)
+		}
+		inspect = fresh
+		ip, networkName, err = c.getContainerIP(inspect, specifiedNetwork, containerName)
+	}
+	return ip, networkName, inspect, err
 }
 
 // getNetworkNames returns a list of network names from the networks map
@@ -542,3 +1454,60 @@ func (c *Client) checkReachability(ip string, port string) error {
 	_ = conn.Close()
 	return nil
 }
+
+// skipReachabilityFor resolves the skip-reachability label against the global SKIP_REACHABILITY
+func (c *Client) skipReachabilityFor(label string) (bool, error) {
+	if label == "" {
+		return c.skipReachability, nil
+	}
+	skip, err := strconv.ParseBool(label)
+	if err != nil {
+		return false, newParseError(ErrInvalidLabel, "invalid %s value '%s': must be true or false", apptypes.LabelSkipReachability, label)
+	}
+	return skip, nil
+}
+
+// defaultWaitReadyTimeout is used when wait-ready=true is set but WAIT_READY_TIMEOUT is not
+const defaultWaitReadyTimeout = 30 * time.Second
+
+// waitReadyFor resolves the wait-ready label against the global WAIT_READY_TIMEOUT
+// The label accepts a duration ("45s"), "true" (use the global timeout) or "false" (never wait)
+func (c *Client) waitReadyFor(label string) (time.Duration, error) {
+	switch label {
+	case "":
+		return c.waitReadyTimeout, nil
+	case "false":
+		return 0, nil
+	case "true":
+		if c.waitReadyTimeout > 0 {
+			return c.waitReadyTimeout, nil
+		}
+		return defaultWaitReadyTimeout, nil
+	}
+
+	timeout, err := time.ParseDuration(label)
+	if err != nil || timeout < 0 {
+		return 0, newParseError(ErrInvalidLabel, "invalid %s value '%s': must be true, false, or a duration like 30s", apptypes.LabelWaitReady, label)
+	}
+	return timeout, nil
+}
+
+// waitForReachable retries the TCP reachability check until it succeeds or the timeout expires
+func (c *Client) waitForReachable(ctx context.Context, ip string, port string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		err := c.checkReachability(ip, port)
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,879 @@
+package docker
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/client"
+	"github.com/docker/go-connections/nat"
+
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+func TestValidatePort(t *testing.T) {
+	tests := []struct {
+		value   string
+		wantErr bool
+	}{
+		{"80", false},
+		{"1", false},
+		{"65535", false},
+		{"0", true},
+		{"65536", true},
+		{"-1", true},
+		{"8o80", true},
+		{"", true},
+		{"80/tcp", true},
+	}
+
+	for _, tt := range tests {
+		err := validatePort("target", tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("validatePort(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+		}
+	}
+
+	if err := validatePort("target", "8o80"); err == nil || err.Error() != "invalid target port '8o80': must be an integer 1-65535" {
+		t.Errorf("unexpected error message: %v", err)
+	}
+}
+
+func TestGetContainerIP(t *testing.T) {
+	inspect := container.InspectResponse{NetworkSettings: &container.NetworkSettings{
+		Networks: map[string]*network.EndpointSettings{
+			"proj_frontend": {IPAddress: "172.20.0.2"},
+			"proj_backend":  {IPAddress: "172.21.0.2"},
+			"monitoring":    {IPAddress: "172.22.0.2"},
+			"bridge":        {IPAddress: "172.17.0.2"},
+			"empty":         {},
+		},
+	}}
+	noBridge := container.InspectResponse{NetworkSettings: &container.NetworkSettings{
+		Networks: map[string]*network.EndpointSettings{
+			"proj_frontend": {IPAddress: "172.20.0.2"},
+			"proj_backend":  {IPAddress: "172.21.0.2"},
+			"monitoring":    {IPAddress: "172.22.0.2"},
+		},
+	}}
+
+	tests := []struct {
+		name        string
+		inspect     container.InspectResponse
+		specified   string
+		priority    []string
+		wantNetwork string
+	}{
+		{"explicit network", inspect, "monitoring", nil, "monitoring"},
+		{"explicit network by compose suffix", inspect, "backend", nil, "proj_backend"},
+		{"bridge preferred by default", inspect, "", nil, "bridge"},
+		{"priority beats bridge", inspect, "", []string{"_backend"}, "proj_backend"},
+		{"priority order is respected", inspect, "", []string{"missing", "monitoring", "_frontend"}, "monitoring"},
+		{"fallback is sorted by name", noBridge, "", nil, "monitoring"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{networkPriority: tt.priority}
+			// Run repeatedly: map iteration order must not affect the result
+			for i := 0; i < 20; i++ {
+				_, networkName, err := c.getContainerIP(tt.inspect, tt.specified, "web")
+				if err != nil {
+					t.Fatalf("getContainerIP() error: %v", err)
+				}
+				if networkName != tt.wantNetwork {
+					t.Fatalf("getContainerIP() network = %q, want %q", networkName, tt.wantNetwork)
+				}
+			}
+		})
+	}
+}
+
+func TestContainerIPWithRetry(t *testing.T) {
+	withIP := func(ip string) container.InspectResponse {
+		return container.InspectResponse{
+			ContainerJSONBase: &container.ContainerJSONBase{ID: "0123456789abcdef"},
+			NetworkSettings: &container.NetworkSettings{
+				Networks: map[string]*network.EndpointSettings{"bridge": {IPAddress: ip}},
+			},
+		}
+	}
+
+	// The daemon only hands out the IP on the second re-inspect
+	inspects := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inspects++
+		ip := ""
+		if inspects >= 2 {
+			ip = "172.17.0.2"
+		}
+		_ = json.NewEncoder(w).Encode(withIP(ip))
+	}))
+	defer server.Close()
+
+	cli, err := client.NewClientWithOpts(client.WithHost("tcp://"+strings.TrimPrefix(server.URL, "http://")), client.WithVersion("1.47"))
+	if err != nil {
+		t.Fatalf("failed to create Docker client: %v", err)
+	}
+	defer func() { _ = cli.Close() }()
+
+	tests := []struct {
+		name    string
+		retries int
+		wantIP  string
+	}{
+		{"no retries", 0, ""},
+		{"too few retries", 1, ""},
+		{"IP shows up", 3, "172.17.0.2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inspects = 0
+			c := &Client{cli: cli, ipRetries: tt.retries, ipRetryDelay: time.Millisecond}
+			ip, _, _, err := c.containerIPWithRetry(context.Background(), withIP(""), "", "web")
+			if tt.wantIP == "" {
+				if !errors.Is(err, ErrNoContainerIP) {
+					t.Errorf("expected ErrNoContainerIP, got ip %q, err %v", ip, err)
+				}
+				return
+			}
+			if err != nil || ip != tt.wantIP {
+				t.Errorf("expected %s, got ip %q, err %v", tt.wantIP, ip, err)
+			}
+			if inspects != 2 {
+				t.Errorf("expected to stop re-inspecting once the IP showed up, inspected %d times", inspects)
+			}
+		})
+	}
+}
+
+func TestParseInspectSharedNetwork(t *testing.T) {
+	owners := map[string]container.InspectResponse{
+		"vpn": {
+			ContainerJSONBase: &container.ContainerJSONBase{
+				ID:         "fedcba9876543210",
+				Name:       "/vpn",
+				State:      &container.State{Running: true},
+				HostConfig: &container.HostConfig{NetworkMode: "backend"},
+			},
+			NetworkSettings: &container.NetworkSettings{
+				Networks: map[string]*network.EndpointSettings{"backend": {IPAddress: "172.20.0.5"}},
+			},
+		},
+		"stopped": {
+			ContainerJSONBase: &container.ContainerJSONBase{
+				ID:    "0011223344556677",
+				Name:  "/stopped",
+				State: &container.State{Running: false},
+			},
+		},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// /v1.47/containers/<ref>/json
+		parts := strings.Split(r.URL.Path, "/")
+		owner, ok := owners[parts[len(parts)-2]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"No such container"}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(owner)
+	}))
+	defer server.Close()
+
+	cli, err := client.NewClientWithOpts(client.WithHost("tcp://"+strings.TrimPrefix(server.URL, "http://")), client.WithVersion("1.47"))
+	if err != nil {
+		t.Fatalf("failed to create Docker client: %v", err)
+	}
+	defer func() { _ = cli.Close() }()
+
+	tests := []struct {
+		name    string
+		owner   string
+		useDNS  bool
+		wantIP  string
+		wantErr error
+	}{
+		{"owner's IP", "vpn", false, "172.20.0.5", nil},
+		{"owner's DNS name", "vpn", true, "vpn", nil},
+		{"owner missing", "gone", false, "", ErrNoContainerIP},
+		{"owner stopped", "stopped", false, "", ErrNoContainerIP},
+	}
+
+	c := &Client{cli: cli, runtime: RuntimeDocker}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			labels := map[string]string{
+				apptypes.LabelEnable:  "true",
+				apptypes.LabelService: "app",
+				apptypes.LabelTarget:  "8080",
+			}
+			if tt.useDNS {
+				labels[apptypes.LabelUseDNS] = "true"
+			}
+			inspect := container.InspectResponse{
+				ContainerJSONBase: &container.ContainerJSONBase{
+					ID:         "0123456789abcdef",
+					Name:       "/app",
+					HostConfig: &container.HostConfig{NetworkMode: container.NetworkMode("container:" + tt.owner)},
+				},
+				NetworkSettings: &container.NetworkSettings{},
+			}
+
+			svc, err := c.parseInspect(context.Background(), labels, inspect)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseInspect() error = %v", err)
+			}
+			if svc.IPAddress != tt.wantIP || svc.TargetPort != "8080" || svc.ContainerName != "app" {
+				t.Errorf("expected app -> %s:8080, got %s -> %s:%s", tt.wantIP, svc.ContainerName, svc.IPAddress, svc.TargetPort)
+			}
+		})
+	}
+}
+
+func TestValidateUnixSocket(t *testing.T) {
+	tests := []struct {
+		name    string
+		labels  map[string]string
+		socket  string
+		wantErr bool
+	}{
+		{"valid", map[string]string{}, "/run/app/app.sock", false},
+		{"explicit http", map[string]string{apptypes.LabelTargetProtocol: "http"}, "/run/app.sock", false},
+		{"relative path", map[string]string{}, "app.sock", true},
+		{"with target port", map[string]string{apptypes.LabelTarget: "80"}, "/run/app.sock", true},
+		{"with direct", map[string]string{apptypes.LabelDirect: "true"}, "/run/app.sock", true},
+		{"with network", map[string]string{apptypes.LabelNetwork: "backend"}, "/run/app.sock", true},
+		{"with https protocol", map[string]string{apptypes.LabelTargetProtocol: "https"}, "/run/app.sock", true},
+		{"with funnel", map[string]string{apptypes.LabelFunnelEnable: "true"}, "/run/app.sock", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateUnixSocket(tt.labels, tt.socket)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateUnixSocket() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMergeTags(t *testing.T) {
+	got := mergeTags([]string{"tag:container", "tag:web"}, []string{"tag:web", "tag:prod", "tag:container", "tag:team"})
+	want := []string{"tag:container", "tag:web", "tag:prod", "tag:team"}
+	if len(got) != len(want) {
+		t.Fatalf("mergeTags() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("mergeTags() = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestParseInspectDestinationChange(t *testing.T) {
+	labels := map[string]string{
+		apptypes.LabelEnable:  "true",
+		apptypes.LabelService: "web",
+		apptypes.LabelTarget:  "8080",
+	}
+	inspectOn := func(ip string, bindings nat.PortMap) container.InspectResponse {
+		return container.InspectResponse{
+			ContainerJSONBase: &container.ContainerJSONBase{
+				ID:         "0123456789abcdef",
+				Name:       "/web",
+				HostConfig: &container.HostConfig{NetworkMode: "proj_default", PortBindings: bindings},
+			},
+			NetworkSettings: &container.NetworkSettings{
+				Networks: map[string]*network.EndpointSettings{"proj_default": {IPAddress: ip}},
+			},
+		}
+	}
+
+	c := &Client{publishedHost: "localhost", runtime: RuntimeDocker}
+	ctx := context.Background()
+
+	before, err := c.parseInspect(ctx, labels, inspectOn("127.0.0.2", nil))
+	if err != nil {
+		t.Fatalf("parseInspect() error = %v", err)
+	}
+	after, err := c.parseInspect(ctx, labels, inspectOn("127.0.0.3", nil))
+	if err != nil {
+		t.Fatalf("parseInspect() error = %v", err)
+	}
+	if before.IPAddress != "127.0.0.2" || after.IPAddress != "127.0.0.3" || after.TargetPort != "8080" {
+		t.Errorf("direct mode destination = %s:%s -> %s:%s, want 127.0.0.2:8080 -> 127.0.0.3:8080",
+			before.IPAddress, before.TargetPort, after.IPAddress, after.TargetPort)
+	}
+
+	// Re-published on a new host port with docker update / recreate
+	published := map[string]string{apptypes.LabelDirect: "false"}
+	for k, v := range labels {
+		published[k] = v
+	}
+	moved, err := c.parseInspect(ctx, published, inspectOn("127.0.0.3", nat.PortMap{"8080/tcp": {{HostPort: "18081"}}}))
+	if err != nil {
+		t.Fatalf("parseInspect() error = %v", err)
+	}
+	if moved.IPAddress != "localhost" || moved.TargetPort != "18081" {
+		t.Errorf("published destination = %s:%s, want localhost:18081", moved.IPAddress, moved.TargetPort)
+	}
+}
+
+func TestParseInspectHostPort(t *testing.T) {
+	bindings := nat.PortMap{"8080/tcp": {
+		{HostIP: "0.0.0.0", HostPort: "18080"},
+		{HostIP: "::", HostPort: "18080"},
+		{HostIP: "127.0.0.1", HostPort: "28080"},
+	}}
+	inspect := container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{
+			ID:         "0123456789abcdef",
+			Name:       "/web",
+			HostConfig: &container.HostConfig{NetworkMode: "bridge", PortBindings: bindings},
+		},
+		NetworkSettings: &container.NetworkSettings{},
+	}
+
+	tests := []struct {
+		name     string
+		hostPort string
+		want     string
+		wantErr  bool
+	}{
+		{"first binding by default", "", "18080", false},
+		{"pinned", "28080", "28080", false},
+		{"not published", "38080", "", true},
+		{"invalid", "http", "", true},
+	}
+
+	c := &Client{publishedHost: "localhost", runtime: RuntimeDocker}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			labels := map[string]string{
+				apptypes.LabelEnable:  "true",
+				apptypes.LabelService: "web",
+				apptypes.LabelTarget:  "8080",
+				apptypes.LabelDirect:  "false",
+			}
+			if tt.hostPort != "" {
+				labels[apptypes.LabelHostPort] = tt.hostPort
+			}
+			svc, err := c.parseInspect(context.Background(), labels, inspect)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseInspect() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && svc.TargetPort != tt.want {
+				t.Errorf("host port = %s, want %s", svc.TargetPort, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInspectDestPort(t *testing.T) {
+	tests := []struct {
+		name     string
+		network  string
+		destPort string
+		want     string
+		wantErr  bool
+	}{
+		{"defaults to target", "host", "", "80", false},
+		{"overrides target", "host", "8080", "8080", false},
+		{"out of range", "host", "70000", "", true},
+		{"not numeric", "host", "http", "", true},
+		{"published ports", "bridge", "8080", "", true},
+	}
+
+	c := &Client{runtime: RuntimeDocker}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inspect := container.InspectResponse{
+				ContainerJSONBase: &container.ContainerJSONBase{
+					ID:         "0123456789abcdef",
+					Name:       "/web",
+					HostConfig: &container.HostConfig{NetworkMode: container.NetworkMode(tt.network)},
+				},
+				NetworkSettings: &container.NetworkSettings{},
+			}
+			labels := map[string]string{
+				apptypes.LabelEnable:  "true",
+				apptypes.LabelService: "web",
+				apptypes.LabelTarget:  "80",
+			}
+			if tt.network != "host" {
+				labels[apptypes.LabelDirect] = "false"
+			}
+			if tt.destPort != "" {
+				labels[apptypes.LabelDestPort] = tt.destPort
+			}
+			svc, err := c.parseInspect(context.Background(), labels, inspect)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseInspect() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && svc.TargetPort != tt.want {
+				t.Errorf("destination port = %s, want %s", svc.TargetPort, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInspectHTTPSRedirect(t *testing.T) {
+	inspect := container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{
+			ID:         "0123456789abcdef",
+			Name:       "/web",
+			HostConfig: &container.HostConfig{NetworkMode: "host"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		labels  map[string]string
+		want    bool
+		wantErr bool
+	}{
+		{"https service", map[string]string{apptypes.LabelPort: "443", apptypes.LabelHTTPSRedirect: "true"}, true, false},
+		{"disabled", map[string]string{apptypes.LabelPort: "443", apptypes.LabelHTTPSRedirect: "false"}, false, false},
+		{"http service", map[string]string{apptypes.LabelPort: "80", apptypes.LabelHTTPSRedirect: "true"}, false, true},
+		{"https on port 80", map[string]string{apptypes.LabelPort: "80", apptypes.LabelServiceProtocol: "https", apptypes.LabelHTTPSRedirect: "true"}, false, true},
+		{"not a boolean", map[string]string{apptypes.LabelPort: "443", apptypes.LabelHTTPSRedirect: "yes please"}, false, true},
+	}
+
+	c := &Client{publishedHost: "localhost", runtime: RuntimeDocker}
+	for _, tt := range tests {
+		labels := map[string]string{
+			apptypes.LabelEnable:  "true",
+			apptypes.LabelService: "web",
+			apptypes.LabelTarget:  "8080",
+		}
+		for k, v := range tt.labels {
+			labels[k] = v
+		}
+
+		svc, err := c.parseInspect(context.Background(), labels, inspect)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: parseInspect() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err == nil && svc.HTTPSRedirect != tt.want {
+			t.Errorf("%s: HTTPSRedirect = %v, want %v", tt.name, svc.HTTPSRedirect, tt.want)
+		}
+	}
+}
+
+func TestParseInspectFunnelTags(t *testing.T) {
+	inspect := container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{
+			ID:         "0123456789abcdef",
+			Name:       "/web",
+			HostConfig: &container.HostConfig{NetworkMode: "host"},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   []string
+	}{
+		{"funnel with tags", map[string]string{
+			apptypes.LabelFunnelEnable: "true",
+			apptypes.LabelFunnelPort:   "8080",
+			apptypes.LabelFunnelTags:   "tag:public, tag:web",
+		}, []string{"tag:public", "tag:web"}},
+		{"funnel without tags", map[string]string{
+			apptypes.LabelFunnelEnable: "true",
+			apptypes.LabelFunnelPort:   "8080",
+		}, nil},
+		{"tags without funnel", map[string]string{
+			apptypes.LabelFunnelTags: "tag:public",
+		}, nil},
+	}
+
+	c := &Client{publishedHost: "localhost", runtime: RuntimeDocker, defaultTags: []string{"tag:container"}}
+	for _, tt := range tests {
+		labels := map[string]string{
+			apptypes.LabelEnable:  "true",
+			apptypes.LabelService: "web",
+			apptypes.LabelTarget:  "8080",
+		}
+		for k, v := range tt.labels {
+			labels[k] = v
+		}
+
+		svc, err := c.parseInspect(context.Background(), labels, inspect)
+		if err != nil {
+			t.Errorf("%s: parseInspect() unexpected error: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(svc.FunnelTags, tt.want) {
+			t.Errorf("%s: FunnelTags = %v, want %v", tt.name, svc.FunnelTags, tt.want)
+		}
+		if !reflect.DeepEqual(svc.Tags, []string{"tag:container"}) {
+			t.Errorf("%s: service tags changed to %v", tt.name, svc.Tags)
+		}
+	}
+}
+
+func TestParseInspectFunnelOnly(t *testing.T) {
+	inspect := container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{
+			ID:         "0123456789abcdef",
+			Name:       "/web",
+			HostConfig: &container.HostConfig{NetworkMode: "host"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		labels  map[string]string
+		want    bool
+		wantErr bool
+	}{
+		{"funnel only", map[string]string{
+			apptypes.LabelFunnelEnable: "true",
+			apptypes.LabelFunnelPort:   "8080",
+			apptypes.LabelFunnelOnly:   "true",
+		}, true, false},
+		{"funnel and serve", map[string]string{
+			apptypes.LabelFunnelEnable: "true",
+			apptypes.LabelFunnelPort:   "8080",
+		}, false, false},
+		{"funnel not enabled", map[string]string{
+			apptypes.LabelFunnelOnly: "true",
+		}, false, true},
+		{"not a boolean", map[string]string{
+			apptypes.LabelFunnelEnable: "true",
+			apptypes.LabelFunnelPort:   "8080",
+			apptypes.LabelFunnelOnly:   "public",
+		}, false, true},
+	}
+
+	c := &Client{publishedHost: "localhost", runtime: RuntimeDocker}
+	for _, tt := range tests {
+		labels := map[string]string{
+			apptypes.LabelEnable:  "true",
+			apptypes.LabelService: "web",
+			apptypes.LabelTarget:  "8080",
+		}
+		for k, v := range tt.labels {
+			labels[k] = v
+		}
+
+		svc, err := c.parseInspect(context.Background(), labels, inspect)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: parseInspect() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err == nil && svc.FunnelOnly != tt.want {
+			t.Errorf("%s: FunnelOnly = %v, want %v", tt.name, svc.FunnelOnly, tt.want)
+		}
+	}
+}
+
+func TestParseInspectMaintenancePage(t *testing.T) {
+	inspect := container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{
+			ID:         "0123456789abcdef",
+			Name:       "/web",
+			HostConfig: &container.HostConfig{NetworkMode: "host"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		labels  map[string]string
+		wantErr bool
+	}{
+		{"https service", map[string]string{apptypes.LabelPort: "443", apptypes.LabelMaintenancePage: "/srv/maintenance.html"}, false},
+		{"http service", map[string]string{apptypes.LabelMaintenancePage: "/srv/maintenance.html"}, false},
+		{"tcp service", map[string]string{apptypes.LabelTargetProtocol: "tcp", apptypes.LabelMaintenancePage: "/srv/maintenance.html"}, true},
+		{"relative path", map[string]string{apptypes.LabelMaintenancePage: "maintenance.html"}, true},
+	}
+
+	c := &Client{publishedHost: "localhost", runtime: RuntimeDocker}
+	for _, tt := range tests {
+		labels := map[string]string{
+			apptypes.LabelEnable:  "true",
+			apptypes.LabelService: "web",
+			apptypes.LabelTarget:  "8080",
+		}
+		for k, v := range tt.labels {
+			labels[k] = v
+		}
+
+		svc, err := c.parseInspect(context.Background(), labels, inspect)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: parseInspect() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err == nil && svc.MaintenancePage != tt.labels[apptypes.LabelMaintenancePage] {
+			t.Errorf("%s: MaintenancePage = %q", tt.name, svc.MaintenancePage)
+		}
+	}
+}
+
+func TestParseInspectServePath(t *testing.T) {
+	dir := t.TempDir()
+	inspect := container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{
+			ID:         "0123456789abcdef",
+			Name:       "/docs",
+			HostConfig: &container.HostConfig{NetworkMode: "bridge"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		labels  map[string]string
+		wantErr bool
+	}{
+		{"directory", map[string]string{apptypes.LabelServePath: dir}, false},
+		{"https service", map[string]string{apptypes.LabelServePath: dir, apptypes.LabelPort: "443"}, false},
+		{"missing path", map[string]string{apptypes.LabelServePath: dir + "/missing"}, true},
+		{"relative path", map[string]string{apptypes.LabelServePath: "site"}, true},
+		{"with target port", map[string]string{apptypes.LabelServePath: dir, apptypes.LabelTarget: "80"}, true},
+		{"with direct", map[string]string{apptypes.LabelServePath: dir, apptypes.LabelDirect: "true"}, true},
+		{"with unix socket", map[string]string{apptypes.LabelServePath: dir, apptypes.LabelUnixSocket: "/run/app.sock"}, true},
+		{"with funnel", map[string]string{apptypes.LabelServePath: dir, apptypes.LabelFunnelEnable: "true"}, true},
+		{"tcp service", map[string]string{apptypes.LabelServePath: dir, apptypes.LabelServiceProtocol: "tcp"}, true},
+	}
+
+	c := &Client{publishedHost: "localhost", runtime: RuntimeDocker}
+	for _, tt := range tests {
+		labels := map[string]string{
+			apptypes.LabelEnable:  "true",
+			apptypes.LabelService: "docs",
+		}
+		for k, v := range tt.labels {
+			labels[k] = v
+		}
+
+		svc, err := c.parseInspect(context.Background(), labels, inspect)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: parseInspect() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err == nil && (svc.ServePath != dir || svc.IPAddress != "" || svc.TargetPort != "") {
+			t.Errorf("%s: expected only ServePath %q, got path %q ip %q port %q", tt.name, dir, svc.ServePath, svc.IPAddress, svc.TargetPort)
+		}
+	}
+}
+
+func TestParseInspectTargetCA(t *testing.T) {
+	dir := t.TempDir()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{SerialNumber: big.NewInt(1), IsCA: true, NotAfter: time.Now().Add(time.Hour)}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caFile := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	notPEM := filepath.Join(dir, "ca.txt")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	inspect := container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{
+			ID:         "0123456789abcdef",
+			Name:       "/vault",
+			HostConfig: &container.HostConfig{NetworkMode: "host"},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		labels       map[string]string
+		wantProtocol string
+		wantErr      bool
+	}{
+		{"implies https", map[string]string{apptypes.LabelTargetCA: caFile}, "https", false},
+		{"explicit https+insecure", map[string]string{apptypes.LabelTargetCA: caFile, apptypes.LabelTargetProtocol: "https+insecure"}, "https+insecure", false},
+		{"plain http backend", map[string]string{apptypes.LabelTargetCA: caFile, apptypes.LabelTargetProtocol: "http"}, "", true},
+		{"missing file", map[string]string{apptypes.LabelTargetCA: filepath.Join(dir, "missing.pem")}, "", true},
+		{"not a certificate", map[string]string{apptypes.LabelTargetCA: notPEM}, "", true},
+		{"relative path", map[string]string{apptypes.LabelTargetCA: "ca.pem"}, "", true},
+	}
+
+	c := &Client{publishedHost: "localhost", runtime: RuntimeDocker}
+	for _, tt := range tests {
+		labels := map[string]string{
+			apptypes.LabelEnable:  "true",
+			apptypes.LabelService: "vault",
+			apptypes.LabelTarget:  "8200",
+		}
+		for k, v := range tt.labels {
+			labels[k] = v
+		}
+
+		svc, err := c.parseInspect(context.Background(), labels, inspect)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: parseInspect() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err == nil && (svc.Protocol != tt.wantProtocol || svc.TargetCA != caFile) {
+			t.Errorf("%s: got protocol %q target CA %q, want %q %q", tt.name, svc.Protocol, svc.TargetCA, tt.wantProtocol, caFile)
+		}
+	}
+}
+
+func TestParseInspectStrictLabels(t *testing.T) {
+	inspect := container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{
+			ID:         "0123456789abcdef",
+			Name:       "/web",
+			HostConfig: &container.HostConfig{NetworkMode: "host"},
+		},
+	}
+	explicit := map[string]string{
+		apptypes.LabelEnable:          "true",
+		apptypes.LabelService:         "web",
+		apptypes.LabelTarget:          "8080",
+		apptypes.LabelPort:            "443",
+		apptypes.LabelServiceProtocol: "https",
+		apptypes.LabelTargetProtocol:  "http",
+	}
+
+	tests := []struct {
+		name    string
+		without string
+		extra   map[string]string
+		wantErr bool
+	}{
+		{name: "fully explicit"},
+		{name: "missing service port", without: apptypes.LabelPort, wantErr: true},
+		{name: "missing service protocol", without: apptypes.LabelServiceProtocol, wantErr: true},
+		{name: "missing target protocol", without: apptypes.LabelTargetProtocol, wantErr: true},
+		{name: "funnel without public port", extra: map[string]string{
+			apptypes.LabelFunnelEnable:   "true",
+			apptypes.LabelFunnelPort:     "8080",
+			apptypes.LabelFunnelProtocol: "https",
+		}, wantErr: true},
+	}
+
+	c := &Client{publishedHost: "localhost", runtime: RuntimeDocker, strictLabels: true, defaultTargetProtocol: "http"}
+	for _, tt := range tests {
+		labels := map[string]string{}
+		for k, v := range explicit {
+			if k != tt.without {
+				labels[k] = v
+			}
+		}
+		for k, v := range tt.extra {
+			labels[k] = v
+		}
+
+		_, err := c.parseInspect(context.Background(), labels, inspect)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: parseInspect() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+		if tt.without != "" && err != nil && !strings.Contains(err.Error(), tt.without) {
+			t.Errorf("%s: error %q does not name the missing label %s", tt.name, err, tt.without)
+		}
+	}
+}
+
+func TestInStage(t *testing.T) {
+	tests := []struct {
+		selector string
+		stage    string
+		want     bool
+	}{
+		{"", "", true},
+		{"", "canary", true},
+		{"prod", "prod", true},
+		{"prod", "canary", false},
+		{"prod", "", false},
+	}
+
+	for _, tt := range tests {
+		c := &Client{stageSelector: tt.selector}
+		labels := map[string]string{}
+		if tt.stage != "" {
+			labels[apptypes.LabelStage] = tt.stage
+		}
+		if got := c.inStage(labels); got != tt.want {
+			t.Errorf("inStage(selector %q, stage %q) = %v, want %v", tt.selector, tt.stage, got, tt.want)
+		}
+	}
+}
+
+func TestSkipReachabilityFor(t *testing.T) {
+	tests := []struct {
+		global  bool
+		label   string
+		want    bool
+		wantErr bool
+	}{
+		{false, "", false, false},
+		{true, "", true, false},
+		{false, "true", true, false},
+		{true, "false", false, false},
+		{false, "maybe", false, true},
+	}
+
+	for _, tt := range tests {
+		c := &Client{skipReachability: tt.global}
+		got, err := c.skipReachabilityFor(tt.label)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("skipReachabilityFor(%q) error = %v, wantErr %v", tt.label, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("skipReachabilityFor(%q) with global %v = %v, want %v", tt.label, tt.global, got, tt.want)
+		}
+	}
+}
+
+func TestPausedError(t *testing.T) {
+	tests := []struct {
+		label string
+		want  error
+	}{
+		{"", ErrPaused},
+		{"true", ErrPaused},
+		{"false", nil},
+		{"sometimes", ErrInvalidLabel},
+	}
+
+	for _, tt := range tests {
+		labels := map[string]string{}
+		if tt.label != "" {
+			labels[apptypes.LabelKeepOnPause] = tt.label
+		}
+		err := pausedError(labels)
+		if (tt.want == nil && err != nil) || (tt.want != nil && !errors.Is(err, tt.want)) {
+			t.Errorf("pausedError(%q) = %v, want %v", tt.label, err, tt.want)
+		}
+	}
+}
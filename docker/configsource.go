@@ -0,0 +1,100 @@
+package docker
+
+import (
+	"strings"
+
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+// Where container service configuration is read from (CONFIG_SOURCE)
+const (
+	ConfigSourceLabels = "labels" // Container labels only (default)
+	ConfigSourceEnv    = "env"    // Container environment variables only
+	ConfigSourceBoth   = "both"   // Labels, falling back to environment variables
+)
+
+// envConfigLabels are the labels that can also be supplied as container environment variables
+// Indexed funnel labels are label-only
+var envConfigLabels = []string{
+	apptypes.LabelEnable,
+	apptypes.LabelService,
+	apptypes.LabelPort,
+	apptypes.LabelServiceProtocol,
+	apptypes.LabelTarget,
+	apptypes.LabelTargetProtocol,
+	apptypes.LabelTags,
+	apptypes.LabelTagsMode,
+	apptypes.LabelComment,
+	apptypes.LabelFunnelEnable,
+	apptypes.LabelFunnelPort,
+	apptypes.LabelFunnelFunnelPort,
+	apptypes.LabelFunnelProtocol,
+	apptypes.LabelFunnelTags,
+	apptypes.LabelFunnelTarget,
+	apptypes.LabelDirect,
+	apptypes.LabelNetwork,
+	apptypes.LabelWaitReady,
+	apptypes.LabelUseDNS,
+	apptypes.LabelTLSSNI,
+	apptypes.LabelGroup,
+	apptypes.LabelUnixSocket,
+	apptypes.LabelServePath,
+	apptypes.LabelHTTPSRedirect,
+	apptypes.LabelTailnet,
+	apptypes.LabelMaintenancePage,
+	apptypes.LabelAllowedTags,
+	apptypes.LabelHostPort,
+	apptypes.LabelDestPort,
+	apptypes.LabelFunnelOnly,
+	apptypes.LabelOnError,
+	apptypes.LabelTargetCA,
+	apptypes.LabelSkipReachability,
+	apptypes.LabelKeepOnPause,
+	apptypes.LabelBasicAuthUser,
+	apptypes.LabelBasicAuthHash,
+	apptypes.LabelBasicAuthSecret,
+	apptypes.LabelTCPTimeout,
+	apptypes.LabelIdleTimeout,
+	apptypes.LabelStage,
+}
+
+// envKey returns the environment variable name for a label
+// (e.g., docktail.service.service-port → DOCKTAIL_SERVICE_SERVICE_PORT)
+func envKey(label string) string {
+	return strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(label))
+}
+
+// labelsFromEnv extracts DockTail configuration from a container's Config.Env, keyed by label name
+func labelsFromEnv(env []string) map[string]string {
+	vars := make(map[string]string, len(env))
+	for _, entry := range env {
+		if key, value, ok := strings.Cut(entry, "="); ok {
+			vars[key] = value
+		}
+	}
+
+	labels := make(map[string]string)
+	for _, label := range envConfigLabels {
+		if value, ok := vars[envKey(label)]; ok {
+			labels[label] = value
+		}
+	}
+	return labels
+}
+
+// mergeConfig combines container labels and environment variables according to the config source
+// In "both" mode labels take precedence over environment variables
+func mergeConfig(source string, labels map[string]string, env []string) map[string]string {
+	switch source {
+	case ConfigSourceEnv:
+		return labelsFromEnv(env)
+	case ConfigSourceBoth:
+		merged := labelsFromEnv(env)
+		for key, value := range labels {
+			merged[key] = value
+		}
+		return merged
+	default:
+		return labels
+	}
+}
@@ -0,0 +1,72 @@
+package docker
+
+import (
+	"testing"
+
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+func TestEnvKey(t *testing.T) {
+	tests := []struct {
+		label string
+		want  string
+	}{
+		{apptypes.LabelEnable, "DOCKTAIL_SERVICE_ENABLE"},
+		{apptypes.LabelPort, "DOCKTAIL_SERVICE_SERVICE_PORT"},
+		{apptypes.LabelTarget, "DOCKTAIL_SERVICE_PORT"},
+		{apptypes.LabelTags, "DOCKTAIL_TAGS"},
+		{apptypes.LabelFunnelFunnelPort, "DOCKTAIL_FUNNEL_FUNNEL_PORT"},
+		{apptypes.LabelFunnelTags, "DOCKTAIL_FUNNEL_TAGS"},
+		{apptypes.LabelFunnelTarget, "DOCKTAIL_FUNNEL_TARGET_PROTOCOL"},
+	}
+
+	for _, tt := range tests {
+		if got := envKey(tt.label); got != tt.want {
+			t.Errorf("envKey(%q) = %q, want %q", tt.label, got, tt.want)
+		}
+	}
+}
+
+func TestMergeConfig(t *testing.T) {
+	labels := map[string]string{
+		apptypes.LabelEnable:  "true",
+		apptypes.LabelService: "from-label",
+	}
+	env := []string{
+		"PATH=/usr/bin",
+		"DOCKTAIL_SERVICE_ENABLE=true",
+		"DOCKTAIL_SERVICE_NAME=from-env",
+		"DOCKTAIL_SERVICE_PORT=8080",
+		"DOCKTAIL_TAGS=tag:a,tag:b",
+		"MALFORMED",
+	}
+
+	tests := []struct {
+		source  string
+		name    string
+		target  string
+		hasTags bool
+	}{
+		{ConfigSourceLabels, "from-label", "", false},
+		{ConfigSourceEnv, "from-env", "8080", true},
+		{ConfigSourceBoth, "from-label", "8080", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			merged := mergeConfig(tt.source, labels, env)
+			if merged[apptypes.LabelEnable] != "true" {
+				t.Errorf("expected enable=true, got %q", merged[apptypes.LabelEnable])
+			}
+			if merged[apptypes.LabelService] != tt.name {
+				t.Errorf("service name = %q, want %q", merged[apptypes.LabelService], tt.name)
+			}
+			if merged[apptypes.LabelTarget] != tt.target {
+				t.Errorf("target = %q, want %q", merged[apptypes.LabelTarget], tt.target)
+			}
+			if _, ok := merged[apptypes.LabelTags]; ok != tt.hasTags {
+				t.Errorf("tags present = %v, want %v", ok, tt.hasTags)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package docker
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/docker/docker/client"
+)
+
+// defaultContextName is the built-in Docker CLI context, which just means DOCKER_HOST or the default socket
+const defaultContextName = "default"
+
+// dockerContext is the Docker endpoint of a Docker CLI context (docker context ls)
+type dockerContext struct {
+	Name string
+	Host string
+	// TLSDir holds ca.pem, cert.pem and key.pem for the endpoint (empty = no TLS material)
+	TLSDir string
+}
+
+// contextMeta is the part of a context's meta.json DockTail reads
+type contextMeta struct {
+	Name      string `json:"Name"`
+	Endpoints map[string]struct {
+		Host string `json:"Host"`
+	} `json:"Endpoints"`
+}
+
+// dockerConfigDir returns the Docker CLI config directory: DOCKER_CONFIG or ~/.docker
+func dockerConfigDir() string {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".docker")
+}
+
+// resolveContext loads a Docker CLI context from the config directory
+// An empty name selects the currentContext from config.json; nil is returned when no
+// context applies (no CLI config, no current context, or the built-in default context)
+func resolveContext(configDir, name string) (*dockerContext, error) {
+	if configDir == "" {
+		return nil, nil
+	}
+
+	if name == "" {
+		data, err := os.ReadFile(filepath.Join(configDir, "config.json"))
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Docker CLI config: %w", err)
+		}
+		var cliConfig struct {
+			CurrentContext string `json:"currentContext"`
+		}
+		if err := json.Unmarshal(data, &cliConfig); err != nil {
+			return nil, fmt.Errorf("failed to parse Docker CLI config %s: %w", filepath.Join(configDir, "config.json"), err)
+		}
+		name = cliConfig.CurrentContext
+	}
+	if name == "" || name == defaultContextName {
+		return nil, nil
+	}
+
+	// Contexts are stored under the SHA-256 of their name, like the Docker CLI context store
+	sum := sha256.Sum256([]byte(name))
+	id := hex.EncodeToString(sum[:])
+
+	data, err := os.ReadFile(filepath.Join(configDir, "contexts", "meta", id, "meta.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("docker context %q not found in %s", name, configDir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read docker context %q: %w", name, err)
+	}
+	var meta contextMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("failed to parse docker context %q: %w", name, err)
+	}
+	host := meta.Endpoints["docker"].Host
+	if host == "" {
+		return nil, fmt.Errorf("docker context %q has no docker endpoint", name)
+	}
+
+	ctx := &dockerContext{Name: name, Host: host}
+	tlsDir := filepath.Join(configDir, "contexts", "tls", id, "docker")
+	ctx.TLSDir = tlsDir
+	for _, file := range []string{"ca.pem", "cert.pem", "key.pem"} {
+		if _, err := os.Stat(filepath.Join(tlsDir, file)); err != nil {
+			ctx.TLSDir = ""
+			break
+		}
+	}
+	return ctx, nil
+}
+
+// clientOpts returns the Docker client options that connect to the context's endpoint
+func (d *dockerContext) clientOpts() []client.Opt {
+	opts := []client.Opt{client.WithHost(d.Host)}
+	if d.TLSDir != "" {
+		opts = append(opts, client.WithTLSClientConfig(
+			filepath.Join(d.TLSDir, "ca.pem"),
+			filepath.Join(d.TLSDir, "cert.pem"),
+			filepath.Join(d.TLSDir, "key.pem"),
+		))
+	}
+	return opts
+}
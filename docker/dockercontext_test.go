@@ -0,0 +1,62 @@
+package docker
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveContext(t *testing.T) {
+	dir := t.TempDir()
+	writeContext := func(name, host string) {
+		sum := sha256.Sum256([]byte(name))
+		metaDir := filepath.Join(dir, "contexts", "meta", hex.EncodeToString(sum[:]))
+		if err := os.MkdirAll(metaDir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		meta := `{"Name":"` + name + `","Metadata":{},"Endpoints":{"docker":{"Host":"` + host + `","SkipTLSVerify":false}}}`
+		if err := os.WriteFile(filepath.Join(metaDir, "meta.json"), []byte(meta), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeContext("colima", "unix:///Users/me/.colima/default/docker.sock")
+	writeContext("remote", "ssh://me@build")
+
+	// No CLI config yet: no context applies
+	if got, err := resolveContext(dir, ""); err != nil || got != nil {
+		t.Fatalf("resolveContext() without config = %+v, %v, want nil", got, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(`{"auths":{},"currentContext":"colima"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		context  string
+		wantHost string
+		wantErr  bool
+	}{
+		{name: "current context", wantHost: "unix:///Users/me/.colima/default/docker.sock"},
+		{name: "override", context: "remote", wantHost: "ssh://me@build"},
+		{name: "default context", context: "default"},
+		{name: "unknown context", context: "missing", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := resolveContext(dir, tt.context)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: resolveContext() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		host := ""
+		if got != nil {
+			host = got.Host
+		}
+		if host != tt.wantHost {
+			t.Errorf("%s: resolveContext() host = %q, want %q", tt.name, host, tt.wantHost)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package docker
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinels for why a container could not be parsed into a service
+// Parse errors keep their human-readable message and match one of these with errors.Is
+var (
+	// ErrMissingLabel means a required label (or its env equivalent) is not set
+	ErrMissingLabel = errors.New("missing required label")
+	// ErrInvalidLabel means a label value is malformed, e.g. not a port, boolean or path
+	ErrInvalidLabel = errors.New("invalid label value")
+	// ErrInvalidProtocol means a backend, service or funnel protocol is not supported
+	ErrInvalidProtocol = errors.New("invalid protocol")
+	// ErrConflictingLabels means labels are individually valid but can't be combined
+	ErrConflictingLabels = errors.New("conflicting labels")
+	// ErrPortNotPublished means direct mode is off and the needed port has no host binding
+	ErrPortNotPublished = errors.New("port not published")
+	// ErrNoContainerIP means direct mode found no IP on the container's selected network
+	ErrNoContainerIP = errors.New("no container IP")
+	// ErrBackendUnreachable means the backend didn't accept connections within the wait-ready timeout
+	ErrBackendUnreachable = errors.New("backend unreachable")
+	// ErrRestarting is reported for a container caught mid-restart, whose service should be left as is
+	ErrRestarting = errors.New("container is restarting")
+	// ErrPaused is reported for a paused container whose service should be left as is (docktail.service.keep-on-pause)
+	ErrPaused = errors.New("container is paused")
+)
+
+// parseError is a parse failure with its own message that also matches a sentinel
+type parseError struct {
+	kind error
+	err  error
+}
+
+// newParseError formats a parse error like fmt.Errorf (including %w) and tags it with kind
+func newParseError(kind error, format string, args ...any) error {
+	return &parseError{kind: kind, err: fmt.Errorf(format, args...)}
+}
+
+func (e *parseError) Error() string {
+	return e.err.Error()
+}
+
+func (e *parseError) Unwrap() []error {
+	return []error{e.kind, e.err}
+}
+
+// errorCategories names each sentinel for status reporting, e.g. /status/errors
+var errorCategories = []struct {
+	err  error
+	name string
+}{
+	{ErrMissingLabel, "missing_label"},
+	{ErrInvalidLabel, "invalid_label"},
+	{ErrInvalidProtocol, "invalid_protocol"},
+	{ErrConflictingLabels, "conflicting_labels"},
+	{ErrPortNotPublished, "port_not_published"},
+	{ErrNoContainerIP, "no_container_ip"},
+	{ErrBackendUnreachable, "backend_unreachable"},
+	{ErrRestarting, "restarting"},
+	{ErrPaused, "paused"},
+}
+
+// ErrorCategory returns the category of a parse error, or "invalid_config" if it matches no sentinel
+func ErrorCategory(err error) string {
+	for _, category := range errorCategories {
+		if errors.Is(err, category.err) {
+			return category.name
+		}
+	}
+	return "invalid_config"
+}
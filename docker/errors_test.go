@@ -0,0 +1,81 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+func TestParseInspectErrorKinds(t *testing.T) {
+	inspect := container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{
+			ID:         "0123456789abcdef",
+			Name:       "/web",
+			HostConfig: &container.HostConfig{NetworkMode: "bridge"},
+		},
+		NetworkSettings: &container.NetworkSettings{
+			Networks: map[string]*network.EndpointSettings{"bridge": {}},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		labels  map[string]string
+		want    error
+		wantMsg string
+	}{
+		{"missing target", map[string]string{apptypes.LabelService: "web"},
+			ErrMissingLabel, "missing required label: docktail.service.port"},
+		{"bad protocol", map[string]string{apptypes.LabelService: "web", apptypes.LabelTarget: "80", apptypes.LabelTargetProtocol: "gopher"},
+			ErrInvalidProtocol, "invalid protocol: gopher (must be http, https, https+insecure, tcp, or tls-terminated-tcp)"},
+		{"bad port", map[string]string{apptypes.LabelService: "web", apptypes.LabelTarget: "http"},
+			ErrInvalidLabel, "invalid target port 'http': must be an integer 1-65535"},
+		{"redirect on http", map[string]string{apptypes.LabelService: "web", apptypes.LabelTarget: "80", apptypes.LabelPort: "80", apptypes.LabelHTTPSRedirect: "true"},
+			ErrConflictingLabels, "docktail.service.https-redirect requires service-protocol https (got http)"},
+		{"not published", map[string]string{apptypes.LabelService: "web", apptypes.LabelTarget: "80", apptypes.LabelDirect: "false"},
+			ErrPortNotPublished, ""},
+		{"no IP yet", map[string]string{apptypes.LabelService: "web", apptypes.LabelTarget: "80"},
+			ErrNoContainerIP, "container 'web' has no IP address on any network"},
+	}
+
+	c := &Client{publishedHost: "localhost", runtime: RuntimeDocker}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			labels := map[string]string{apptypes.LabelEnable: "true"}
+			for k, v := range tt.labels {
+				labels[k] = v
+			}
+			_, err := c.parseInspect(context.Background(), labels, inspect)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("expected an error matching %v, got %v", tt.want, err)
+			}
+			if tt.wantMsg != "" && err.Error() != tt.wantMsg {
+				t.Errorf("message changed: got %q, want %q", err.Error(), tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestErrorCategory(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{newParseError(ErrMissingLabel, "missing required label: %s", apptypes.LabelTarget), "missing_label"},
+		{fmt.Errorf("container 'app' shares the network of 'vpn': %w", newParseError(ErrNoContainerIP, "no IP")), "no_container_ip"},
+		{ErrRestarting, "restarting"},
+		{errors.New("failed to inspect container: boom"), "invalid_config"},
+	}
+
+	for _, tt := range tests {
+		if got := ErrorCategory(tt.err); got != tt.want {
+			t.Errorf("ErrorCategory(%q) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
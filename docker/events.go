@@ -0,0 +1,19 @@
+package docker
+
+import (
+	"github.com/docker/docker/api/types/filters"
+)
+
+// DefaultEvents are the container events that trigger a reconciliation when DOCKER_EVENTS is unset
+// Besides start/stop, pause/unpause, update (e.g. docker update) and rename change whether
+// or how a container is served without restarting it
+var DefaultEvents = []string{"start", "stop", "die", "restart", "pause", "unpause", "update", "rename"}
+
+// eventFilters builds the event stream filter for the given container actions
+func eventFilters(actions []string) filters.Args {
+	args := filters.NewArgs(filters.Arg("type", "container"))
+	for _, action := range actions {
+		args.Add("event", action)
+	}
+	return args
+}
@@ -0,0 +1,22 @@
+package docker
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestEventFilters(t *testing.T) {
+	args := eventFilters([]string{"start", "rename", "health_status"})
+
+	if got := args.Get("type"); !reflect.DeepEqual(got, []string{"container"}) {
+		t.Errorf("type filter = %v, want [container]", got)
+	}
+
+	got := args.Get("event")
+	sort.Strings(got)
+	want := []string{"health_status", "rename", "start"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("event filter = %v, want %v", got, want)
+	}
+}
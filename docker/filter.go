@@ -0,0 +1,57 @@
+package docker
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// nameFilter decides which containers DockTail manages based on their names
+type nameFilter struct {
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+}
+
+// newNameFilter compiles the INCLUDE_CONTAINERS and EXCLUDE_CONTAINERS patterns
+func newNameFilter(include, exclude []string) (*nameFilter, error) {
+	f := &nameFilter{}
+	var err error
+	if f.include, err = compilePatterns("INCLUDE_CONTAINERS", include); err != nil {
+		return nil, err
+	}
+	if f.exclude, err = compilePatterns("EXCLUDE_CONTAINERS", exclude); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func compilePatterns(name string, patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s pattern '%s': %w", name, pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// allowed reports whether a container should be considered, and the reason if it isn't
+// Exclusions win over the allow-list
+func (f *nameFilter) allowed(containerName string) (bool, string) {
+	for _, re := range f.exclude {
+		if re.MatchString(containerName) {
+			return false, fmt.Sprintf("matches EXCLUDE_CONTAINERS pattern '%s'", re)
+		}
+	}
+
+	if len(f.include) == 0 {
+		return true, ""
+	}
+	for _, re := range f.include {
+		if re.MatchString(containerName) {
+			return true, ""
+		}
+	}
+	return false, "does not match any INCLUDE_CONTAINERS pattern"
+}
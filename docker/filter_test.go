@@ -0,0 +1,39 @@
+package docker
+
+import "testing"
+
+func TestNameFilter(t *testing.T) {
+	tests := []struct {
+		name      string
+		include   []string
+		exclude   []string
+		container string
+		want      bool
+	}{
+		{"no patterns", nil, nil, "web", true},
+		{"excluded prefix", nil, []string{"^build-"}, "build-1234", false},
+		{"not excluded", nil, []string{"^build-"}, "web", true},
+		{"included", []string{"^prod-"}, nil, "prod-web", true},
+		{"not included", []string{"^prod-"}, nil, "dev-web", false},
+		{"exclude wins over include", []string{"^prod-"}, []string{"-canary$"}, "prod-web-canary", false},
+		{"any include pattern matches", []string{"^prod-", "^shared-"}, nil, "shared-db", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := newNameFilter(tt.include, tt.exclude)
+			if err != nil {
+				t.Fatalf("newNameFilter() error: %v", err)
+			}
+			if got, _ := f.allowed(tt.container); got != tt.want {
+				t.Errorf("allowed(%q) = %v, want %v", tt.container, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNameFilterInvalidPattern(t *testing.T) {
+	if _, err := newNameFilter(nil, []string{"("}); err == nil {
+		t.Error("expected error for invalid regex")
+	}
+}
@@ -0,0 +1,193 @@
+package docker
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/go-connections/nat"
+
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+// indexedFunnelLabel matches indexed funnel labels like docktail.funnel.1.port
+var indexedFunnelLabel = regexp.MustCompile(`^` + regexp.QuoteMeta(apptypes.LabelFunnelPrefix) + `(\d+)\.`)
+
+// funnelPublicPorts are the only public ports Tailscale Funnel can listen on
+var funnelPublicPorts = map[string]bool{
+	"443":   true,
+	"8443":  true,
+	"10000": true,
+}
+
+// funnelTargetProtocols are the backend protocols an https funnel can proxy to
+var funnelTargetProtocols = map[string]bool{
+	"http":           true,
+	"https":          true,
+	"https+insecure": true,
+}
+
+// funnelTargetProtocol resolves the protocol https funnels proxy to
+// An empty value follows the service's backend protocol, falling back to http for tcp backends
+func funnelTargetProtocol(value, backendProtocol string) (string, error) {
+	if value == "" {
+		if funnelTargetProtocols[backendProtocol] {
+			return backendProtocol, nil
+		}
+		return "http", nil
+	}
+	if !funnelTargetProtocols[value] {
+		return "", newParseError(ErrInvalidProtocol, "invalid %s: %s (must be http, https, or https+insecure)", apptypes.LabelFunnelTarget, value)
+	}
+	return value, nil
+}
+
+// parseFunnels collects all funnel entries for a container
+// The un-indexed docktail.funnel.* labels form the first entry, followed by
+// docktail.funnel.<n>.* entries in index order
+func (c *Client) parseFunnels(labels map[string]string, inspect container.InspectResponse, isHostNetwork, isDirectMode bool, containerName string) ([]apptypes.FunnelConfig, error) {
+	prefixes := []string{}
+	if labels[apptypes.LabelFunnelPort] != "" {
+		prefixes = append(prefixes, apptypes.LabelFunnelPrefix)
+	}
+
+	indices := make(map[int]bool)
+	for key := range labels {
+		if m := indexedFunnelLabel.FindStringSubmatch(key); m != nil {
+			i, _ := strconv.Atoi(m[1])
+			indices[i] = true
+		}
+	}
+	sorted := make([]int, 0, len(indices))
+	for i := range indices {
+		sorted = append(sorted, i)
+	}
+	sort.Ints(sorted)
+	for _, i := range sorted {
+		prefixes = append(prefixes, fmt.Sprintf("%s%d.", apptypes.LabelFunnelPrefix, i))
+	}
+
+	if len(prefixes) == 0 {
+		return nil, newParseError(ErrMissingLabel, "funnel enabled but missing required label: %s (container port)", apptypes.LabelFunnelPort)
+	}
+
+	funnels := make([]apptypes.FunnelConfig, 0, len(prefixes))
+	publicPorts := make(map[string]string) // public port -> label prefix that claimed it
+	for _, prefix := range prefixes {
+		funnel, err := c.parseFunnel(labels, prefix, inspect, isHostNetwork, isDirectMode, containerName)
+		if err != nil {
+			return nil, err
+		}
+		if other, exists := publicPorts[funnel.FunnelPort]; exists {
+			return nil, newParseError(ErrConflictingLabels, "funnel-port %s is used by both %s* and %s* labels (only ONE funnel per port)", funnel.FunnelPort, other, prefix)
+		}
+		publicPorts[funnel.FunnelPort] = prefix
+		funnels = append(funnels, *funnel)
+	}
+
+	return funnels, nil
+}
+
+// parseFunnel parses a single funnel entry from the labels under the given prefix
+func (c *Client) parseFunnel(labels map[string]string, prefix string, inspect container.InspectResponse, isHostNetwork, isDirectMode bool, containerName string) (*apptypes.FunnelConfig, error) {
+	label := func(name string) string {
+		return prefix + strings.TrimPrefix(name, apptypes.LabelFunnelPrefix)
+	}
+
+	// Get funnel-specific container port (like service.port but for funnel)
+	funnelPort := labels[label(apptypes.LabelFunnelPort)]
+	if funnelPort == "" {
+		return nil, newParseError(ErrMissingLabel, "funnel enabled but missing required label: %s (container port)", label(apptypes.LabelFunnelPort))
+	}
+	if err := validatePort("funnel", funnelPort); err != nil {
+		return nil, err
+	}
+
+	if c.strictLabels {
+		if err := requireLabels(labels, label(apptypes.LabelFunnelProtocol), label(apptypes.LabelFunnelFunnelPort)); err != nil {
+			return nil, err
+		}
+	}
+
+	// Get funnel protocol
+	funnelProtocol := labels[label(apptypes.LabelFunnelProtocol)]
+	if funnelProtocol == "" {
+		funnelProtocol = "https" // Default to HTTPS
+		log.Debug().
+			Str("container", containerName).
+			Str("label_prefix", prefix).
+			Msg("Funnel protocol not specified, defaulting to HTTPS")
+	}
+
+	// Validate funnel protocol
+	validFunnelProtocols := map[string]bool{
+		"https":              true,
+		"tcp":                true,
+		"tls-terminated-tcp": true,
+	}
+	if !validFunnelProtocols[funnelProtocol] {
+		return nil, newParseError(ErrInvalidProtocol, "invalid funnel protocol: %s (must be https, tcp, or tls-terminated-tcp)", funnelProtocol)
+	}
+
+	// Get public-facing funnel port (funnel-port)
+	funnelFunnelPort := labels[label(apptypes.LabelFunnelFunnelPort)]
+	if funnelFunnelPort == "" {
+		funnelFunnelPort = "443" // Default to 443
+		log.Debug().
+			Str("container", containerName).
+			Str("label_prefix", prefix).
+			Msg("Funnel public port not specified, defaulting to 443")
+	} else if err := validatePort("funnel-funnel", funnelFunnelPort); err != nil {
+		return nil, err
+	}
+
+	// Tailscale only opens 443, 8443 and 10000 to the internet, whether the
+	// funnel terminates TLS (https) or passes raw TCP through (tcp)
+	if !funnelPublicPorts[funnelFunnelPort] {
+		return nil, newParseError(ErrInvalidLabel, "invalid funnel-port: %s for %s funnel (must be 443, 8443, or 10000)", funnelFunnelPort, strings.ToUpper(funnelProtocol))
+	}
+
+	// Find the published host port for the funnel container port
+	var funnelTargetPort string
+	if isHostNetwork {
+		// For host networking, the container port IS the host port
+		funnelTargetPort = funnelPort
+	} else if isDirectMode {
+		// Direct mode: use container port directly (funnel will use same destIP as service)
+		funnelTargetPort = funnelPort
+	} else {
+		funnelPortKey := nat.Port(fmt.Sprintf("%s/tcp", funnelPort))
+		if inspect.HostConfig != nil && inspect.HostConfig.PortBindings != nil {
+			if bindings, ok := inspect.HostConfig.PortBindings[funnelPortKey]; ok && len(bindings) > 0 {
+				funnelTargetPort = bindings[0].HostPort
+			}
+		}
+		if funnelTargetPort == "" && inspect.NetworkSettings != nil && inspect.NetworkSettings.Ports != nil {
+			if bindings, ok := inspect.NetworkSettings.Ports[funnelPortKey]; ok && len(bindings) > 0 {
+				funnelTargetPort = bindings[0].HostPort
+			}
+		}
+
+		if funnelTargetPort == "" {
+			return nil, newParseError(ErrPortNotPublished, "funnel container port %s is NOT published to host (direct mode disabled). Add it to ports in docker-compose, or remove 'docktail.service.direct=false'", funnelPort)
+		}
+	}
+
+	log.Info().
+		Str("container", containerName).
+		Str("funnel_container_port", funnelPort).
+		Str("funnel_host_port", funnelTargetPort).
+		Str("funnel_public_port", funnelFunnelPort).
+		Str("funnel_protocol", funnelProtocol).
+		Msg("Funnel enabled for public internet access")
+
+	return &apptypes.FunnelConfig{
+		Port:       funnelPort,
+		TargetPort: funnelTargetPort,
+		FunnelPort: funnelFunnelPort,
+		Protocol:   funnelProtocol,
+	}, nil
+}
@@ -0,0 +1,112 @@
+package docker
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/go-connections/nat"
+
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+func TestParseFunnel(t *testing.T) {
+	published := container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{HostConfig: &container.HostConfig{
+			PortBindings: nat.PortMap{"25565/tcp": {{HostPort: "35565"}}},
+		}},
+	}
+
+	tests := []struct {
+		name    string
+		labels  map[string]string
+		direct  bool
+		want    apptypes.FunnelConfig
+		wantErr bool
+	}{
+		{
+			name: "tcp funnel through published port",
+			labels: map[string]string{
+				"docktail.funnel.port":        "25565",
+				"docktail.funnel.funnel-port": "10000",
+				"docktail.funnel.protocol":    "tcp",
+			},
+			want: apptypes.FunnelConfig{Port: "25565", TargetPort: "35565", FunnelPort: "10000", Protocol: "tcp"},
+		},
+		{
+			name: "tcp funnel in direct mode keeps container port",
+			labels: map[string]string{
+				"docktail.funnel.port":     "25565",
+				"docktail.funnel.protocol": "tcp",
+			},
+			direct: true,
+			want:   apptypes.FunnelConfig{Port: "25565", TargetPort: "25565", FunnelPort: "443", Protocol: "tcp"},
+		},
+		{
+			name: "tcp funnel on a port funnel cannot open",
+			labels: map[string]string{
+				"docktail.funnel.port":        "25565",
+				"docktail.funnel.funnel-port": "25565",
+				"docktail.funnel.protocol":    "tcp",
+			},
+			direct:  true,
+			wantErr: true,
+		},
+		{
+			name: "tcp funnel with unpublished port",
+			labels: map[string]string{
+				"docktail.funnel.port":     "7777",
+				"docktail.funnel.protocol": "tcp",
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown protocol",
+			labels: map[string]string{
+				"docktail.funnel.port":     "80",
+				"docktail.funnel.protocol": "udp",
+			},
+			direct:  true,
+			wantErr: true,
+		},
+	}
+
+	c := &Client{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.parseFunnel(tt.labels, apptypes.LabelFunnelPrefix, published, false, tt.direct, "test")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFunnel() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && *got != tt.want {
+				t.Errorf("parseFunnel() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFunnelTargetProtocol(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		backend string
+		want    string
+		wantErr bool
+	}{
+		{name: "follows http backend", backend: "http", want: "http"},
+		{name: "follows https backend", backend: "https+insecure", want: "https+insecure"},
+		{name: "tcp backend falls back to http", backend: "tcp", want: "http"},
+		{name: "explicit overrides backend", value: "http", backend: "https", want: "http"},
+		{name: "tcp is not an https funnel target", value: "tcp", backend: "http", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := funnelTargetProtocol(tt.value, tt.backend)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: funnelTargetProtocol() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: funnelTargetProtocol() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/client"
+)
+
+// Destinations for published ports (PUBLISHED_VIA)
+const (
+	PublishedViaHost    = "host"    // PUBLISHED_HOST, localhost by default
+	PublishedViaGateway = "gateway" // The Docker bridge gateway, i.e. the host as seen from a container
+)
+
+// resolvePublishedGateway finds the gateway IP that reaches host-published ports from inside a container
+// The serve proxy runs in tailscaled, which shares DockTail's network namespace when it isn't on the
+// host, so DockTail's own container (or the container whose network it joined) is inspected first,
+// then the default bridge network. Falls back to localhost if neither yields a gateway
+func resolvePublishedGateway(ctx context.Context, cli *client.Client, configured string) string {
+	if configured != "" {
+		return configured
+	}
+
+	if gateway, err := ownGateway(ctx, cli); err != nil {
+		log.Debug().Err(err).Msg("Could not read the gateway from DockTail's own container")
+	} else if gateway != "" {
+		log.Info().Str("gateway", gateway).Msg("Proxying published ports via DockTail's network gateway")
+		return gateway
+	}
+
+	bridge, err := cli.NetworkInspect(ctx, "bridge", network.InspectOptions{})
+	if err == nil {
+		for _, ipam := range bridge.IPAM.Config {
+			if ipam.Gateway != "" {
+				log.Info().Str("gateway", ipam.Gateway).Msg("Proxying published ports via the Docker bridge gateway")
+				return ipam.Gateway
+			}
+		}
+	}
+
+	log.Warn().
+		Err(err).
+		Msg("PUBLISHED_VIA=gateway: could not resolve the Docker gateway IP, falling back to localhost (set PUBLISHED_GATEWAY to configure it)")
+	return "localhost"
+}
+
+// ownGateway returns the gateway of the container DockTail runs in, which Docker names after its hostname
+// A container started with network_mode container:<id> (e.g. a tailscale sidecar) shares that container's networks
+func ownGateway(ctx context.Context, cli *client.Client) (string, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "", fmt.Errorf("failed to read hostname: %w", err)
+	}
+
+	inspect, err := cli.ContainerInspect(ctx, hostname)
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect own container %s: %w", hostname, err)
+	}
+	if inspect.HostConfig != nil {
+		if joined, ok := strings.CutPrefix(string(inspect.HostConfig.NetworkMode), "container:"); ok {
+			inspect, err = cli.ContainerInspect(ctx, joined)
+			if err != nil {
+				return "", fmt.Errorf("failed to inspect network container %s: %w", joined, err)
+			}
+		}
+	}
+	if inspect.NetworkSettings == nil {
+		return "", nil
+	}
+	return gatewayFromNetworks(inspect.NetworkSettings.Networks), nil
+}
+
+// gatewayFromNetworks picks a gateway from a container's networks, preferring the default bridge,
+// then the first network by name. Host-networked containers have no gateway
+func gatewayFromNetworks(networks map[string]*network.EndpointSettings) string {
+	if ep := networks["bridge"]; ep != nil && ep.Gateway != "" {
+		return ep.Gateway
+	}
+
+	names := make([]string, 0, len(networks))
+	for name := range networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if ep := networks[name]; ep != nil && ep.Gateway != "" {
+			return ep.Gateway
+		}
+	}
+	return ""
+}
@@ -0,0 +1,38 @@
+package docker
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types/network"
+)
+
+func TestGatewayFromNetworks(t *testing.T) {
+	tests := []struct {
+		name     string
+		networks map[string]*network.EndpointSettings
+		want     string
+	}{
+		{"no networks", nil, ""},
+		{"host network", map[string]*network.EndpointSettings{"host": {}}, ""},
+		{"bridge preferred", map[string]*network.EndpointSettings{
+			"app_default": {Gateway: "172.18.0.1"},
+			"bridge":      {Gateway: "172.17.0.1"},
+		}, "172.17.0.1"},
+		{"first network by name", map[string]*network.EndpointSettings{
+			"zeta_default": {Gateway: "172.19.0.1"},
+			"app_default":  {Gateway: "172.18.0.1"},
+		}, "172.18.0.1"},
+		{"skips networks without gateway", map[string]*network.EndpointSettings{
+			"app_internal": {},
+			"web_default":  {Gateway: "172.20.0.1"},
+		}, "172.20.0.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := gatewayFromNetworks(tt.networks); got != tt.want {
+				t.Errorf("gatewayFromNetworks() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package docker
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// Container runtimes (CONTAINER_RUNTIME)
+const (
+	RuntimeDocker = "docker"
+	RuntimePodman = "podman"
+)
+
+// rootfulPodmanSocket is where the Podman API socket lives when podman.socket runs as root
+const rootfulPodmanSocket = "/run/podman/podman.sock"
+
+// podmanHost returns the Podman API socket to use when DOCKER_HOST is unset
+// CONTAINER_HOST (Podman's own variable) wins, then the rootless socket, then the rootful one
+func podmanHost() string {
+	if host := os.Getenv("CONTAINER_HOST"); host != "" {
+		return host
+	}
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		rootless := filepath.Join(runtimeDir, "podman", "podman.sock")
+		if _, err := os.Stat(rootless); err == nil {
+			return "unix://" + rootless
+		}
+	}
+	return "unix://" + rootfulPodmanSocket
+}
+
+// hasRoutableNetwork reports whether a container has at least one network with an IP address
+// Rootless Podman containers using slirp4netns or pasta have none: their IP lives in a
+// user-mode network stack that is unreachable from the host, so only published ports work
+func hasRoutableNetwork(inspect container.InspectResponse) bool {
+	if inspect.NetworkSettings == nil {
+		return false
+	}
+	for _, network := range inspect.NetworkSettings.Networks {
+		if network != nil && network.IPAddress != "" {
+			return true
+		}
+	}
+	return false
+}
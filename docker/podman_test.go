@@ -0,0 +1,61 @@
+package docker
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+)
+
+func TestHasRoutableNetwork(t *testing.T) {
+	tests := []struct {
+		name    string
+		inspect container.InspectResponse
+		want    bool
+	}{
+		{"no network settings", container.InspectResponse{}, false},
+		{"slirp4netns with no networks", container.InspectResponse{NetworkSettings: &container.NetworkSettings{}}, false},
+		{"network without IP", container.InspectResponse{NetworkSettings: &container.NetworkSettings{
+			Networks: map[string]*network.EndpointSettings{"podman": {}},
+		}}, false},
+		{"network with IP", container.InspectResponse{NetworkSettings: &container.NetworkSettings{
+			Networks: map[string]*network.EndpointSettings{"podman": {IPAddress: "10.88.0.2"}},
+		}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hasRoutableNetwork(tt.inspect); got != tt.want {
+				t.Errorf("hasRoutableNetwork() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPodmanHost(t *testing.T) {
+	runtimeDir := t.TempDir()
+	t.Setenv("CONTAINER_HOST", "")
+	t.Setenv("XDG_RUNTIME_DIR", runtimeDir)
+
+	if got := podmanHost(); got != "unix://"+rootfulPodmanSocket {
+		t.Errorf("without rootless socket: got %q, want rootful socket", got)
+	}
+
+	rootless := filepath.Join(runtimeDir, "podman", "podman.sock")
+	if err := os.MkdirAll(filepath.Dir(rootless), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(rootless, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got := podmanHost(); got != "unix://"+rootless {
+		t.Errorf("with rootless socket: got %q, want %q", got, "unix://"+rootless)
+	}
+
+	t.Setenv("CONTAINER_HOST", "unix:///custom/podman.sock")
+	if got := podmanHost(); got != "unix:///custom/podman.sock" {
+		t.Errorf("with CONTAINER_HOST: got %q", got)
+	}
+}
@@ -0,0 +1,50 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/docker/client"
+)
+
+// IsConnectionError reports whether err means the Docker daemon couldn't be reached at all,
+// as opposed to the daemon answering with an error
+func IsConnectionError(err error) bool {
+	return client.IsErrConnectionFailed(err)
+}
+
+// api returns the current Docker API client, which Reconnect may replace
+func (c *Client) api() *client.Client {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cli
+}
+
+// Reconnect replaces the Docker API client with a new one built from the same options,
+// for when the daemon socket was replaced (e.g. a daemon reinstall) and the old client keeps failing
+// The old client is only closed once the new one has reached the daemon
+func (c *Client) Reconnect(ctx context.Context) error {
+	log.Warn().
+		Str("docker_host", c.dockerHost).
+		Msg("Reconnecting to Docker daemon")
+
+	cli, err := client.NewClientWithOpts(c.opts...)
+	if err != nil {
+		return fmt.Errorf("failed to create Docker client: %w", err)
+	}
+	if err := negotiateAPIVersion(ctx, cli, true); err != nil {
+		_ = cli.Close()
+		return fmt.Errorf("failed to reconnect to Docker daemon at %s: %w", c.dockerHost, err)
+	}
+
+	c.mu.Lock()
+	old := c.cli
+	c.cli = cli
+	c.mu.Unlock()
+	_ = old.Close()
+
+	log.Info().
+		Str("docker_host", c.dockerHost).
+		Msg("Reconnected to Docker daemon")
+	return nil
+}
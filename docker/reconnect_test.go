@@ -0,0 +1,59 @@
+package docker
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/client"
+)
+
+func TestReconnect(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "docker.sock")
+	opts := []client.Opt{client.WithHost("unix://" + socket), client.WithAPIVersionNegotiation()}
+	cli, err := client.NewClientWithOpts(opts...)
+	if err != nil {
+		t.Fatalf("failed to create Docker client: %v", err)
+	}
+	c := &Client{cli: cli, opts: opts, dockerHost: "unix://" + socket, defaultTags: []string{"tag:container"}}
+	defer func() { _ = c.Close() }()
+
+	// No daemon behind the socket yet
+	err = c.Ping(context.Background())
+	if !IsConnectionError(err) {
+		t.Fatalf("expected a connection error, got %v", err)
+	}
+	if err := c.Reconnect(context.Background()); err == nil {
+		t.Fatal("expected Reconnect to fail while the daemon is down")
+	}
+	if c.api() != cli {
+		t.Error("a failed Reconnect should keep the old client")
+	}
+
+	// The daemon comes back on a new socket at the same path
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("failed to listen on %s: %v", socket, err)
+	}
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("API-Version", "1.47")
+		_, _ = w.Write([]byte("OK"))
+	})}
+	go func() { _ = server.Serve(listener) }()
+	defer func() { _ = server.Close() }()
+
+	if err := c.Reconnect(context.Background()); err != nil {
+		t.Fatalf("Reconnect() error = %v", err)
+	}
+	if c.api() == cli {
+		t.Error("expected Reconnect to replace the client")
+	}
+	if err := c.Ping(context.Background()); err != nil {
+		t.Errorf("Ping() after Reconnect error = %v", err)
+	}
+	if len(c.defaultTags) != 1 || c.defaultTags[0] != "tag:container" {
+		t.Errorf("expected default tags to survive Reconnect, got %v", c.defaultTags)
+	}
+}
@@ -0,0 +1,28 @@
+package docker
+
+import (
+	"time"
+
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+// parseTCPTimeouts reads the docktail.service.tcp-timeout and idle-timeout labels (0 = not set)
+// They only apply to raw TCP streams, so any other service protocol is an error
+func parseTCPTimeouts(labels map[string]string, serviceProtocol string) (time.Duration, time.Duration, error) {
+	var timeouts [2]time.Duration
+	for i, label := range []string{apptypes.LabelTCPTimeout, apptypes.LabelIdleTimeout} {
+		value := labels[label]
+		if value == "" {
+			continue
+		}
+		if serviceProtocol != "tcp" && serviceProtocol != "tls-terminated-tcp" {
+			return 0, 0, newParseError(ErrConflictingLabels, "%s is only valid with service-protocol tcp or tls-terminated-tcp (got %s)", label, serviceProtocol)
+		}
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout <= 0 {
+			return 0, 0, newParseError(ErrInvalidLabel, "invalid %s value '%s': must be a positive duration (e.g. 30m)", label, value)
+		}
+		timeouts[i] = timeout
+	}
+	return timeouts[0], timeouts[1], nil
+}
@@ -0,0 +1,46 @@
+package docker
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+func TestParseTCPTimeouts(t *testing.T) {
+	tests := []struct {
+		name        string
+		labels      map[string]string
+		protocol    string
+		wantTimeout time.Duration
+		wantIdle    time.Duration
+		wantErr     error
+	}{
+		{"no labels", map[string]string{}, "https", 0, 0, nil},
+		{"tcp", map[string]string{apptypes.LabelTCPTimeout: "1h", apptypes.LabelIdleTimeout: "30m"}, "tcp", time.Hour, 30 * time.Minute, nil},
+		{"tls-terminated-tcp", map[string]string{apptypes.LabelIdleTimeout: "90s"}, "tls-terminated-tcp", 0, 90 * time.Second, nil},
+		{"http service", map[string]string{apptypes.LabelTCPTimeout: "1h"}, "http", 0, 0, ErrConflictingLabels},
+		{"not a duration", map[string]string{apptypes.LabelIdleTimeout: "forever"}, "tcp", 0, 0, ErrInvalidLabel},
+		{"missing unit", map[string]string{apptypes.LabelTCPTimeout: "30"}, "tcp", 0, 0, ErrInvalidLabel},
+		{"zero", map[string]string{apptypes.LabelTCPTimeout: "0s"}, "tcp", 0, 0, ErrInvalidLabel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timeout, idle, err := parseTCPTimeouts(tt.labels, tt.protocol)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("parseTCPTimeouts() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseTCPTimeouts() error = %v", err)
+			}
+			if timeout != tt.wantTimeout || idle != tt.wantIdle {
+				t.Errorf("parseTCPTimeouts() = %v, %v, want %v, %v", timeout, idle, tt.wantTimeout, tt.wantIdle)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package docker
+
+import (
+	"strings"
+	"text/template"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// labelTemplateData is everything a label template can see: {{ .Name }}, {{ .ID }} and {{ .Env.FOO }}
+// Only these fields are exposed, so a template can't reach the rest of the inspect response
+type labelTemplateData struct {
+	Name string            // Container name without the leading slash
+	ID   string            // Short (12 character) container ID
+	Env  map[string]string // Container environment variables
+}
+
+// templateLabelPrefix limits template rendering to DockTail's own labels; other tools' labels
+// (e.g. Homepage's {{HOMEPAGE_VAR_X}}) use their own {{ }} syntax and are left alone
+const templateLabelPrefix = "docktail."
+
+// renderLabels returns a copy of labels with every docktail.* value containing {{ rendered as a Go
+// template against the container, so replicas can share one label block (e.g. service={{ .Name }})
+// Rendered output is not rendered again, and a reference to an unset env var is an error
+func renderLabels(labels map[string]string, inspect container.InspectResponse) (map[string]string, error) {
+	var data *labelTemplateData
+	rendered := labels
+	for key, value := range labels {
+		if !strings.HasPrefix(key, templateLabelPrefix) || !strings.Contains(value, "{{") {
+			continue
+		}
+		if data == nil {
+			data = newLabelTemplateData(inspect)
+			rendered = make(map[string]string, len(labels))
+			for k, v := range labels {
+				rendered[k] = v
+			}
+		}
+
+		tmpl, err := template.New(key).Option("missingkey=error").Parse(value)
+		if err != nil {
+			return nil, newParseError(ErrInvalidLabel, "invalid template in %s: %w", key, err)
+		}
+		var out strings.Builder
+		if err := tmpl.Execute(&out, data); err != nil {
+			return nil, newParseError(ErrInvalidLabel, "failed to render template in %s: %w", key, err)
+		}
+		rendered[key] = strings.TrimSpace(out.String())
+	}
+	return rendered, nil
+}
+
+// newLabelTemplateData collects the whitelisted template fields of a container
+func newLabelTemplateData(inspect container.InspectResponse) *labelTemplateData {
+	data := &labelTemplateData{Env: make(map[string]string)}
+	if inspect.ContainerJSONBase != nil {
+		data.Name = strings.TrimPrefix(inspect.Name, "/")
+		data.ID = inspect.ID
+		if len(data.ID) > 12 {
+			data.ID = data.ID[:12]
+		}
+	}
+	if inspect.Config != nil {
+		for _, entry := range inspect.Config.Env {
+			if key, value, ok := strings.Cut(entry, "="); ok {
+				data.Env[key] = value
+			}
+		}
+	}
+	return data
+}
@@ -0,0 +1,78 @@
+package docker
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+func TestRenderLabels(t *testing.T) {
+	inspect := container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{ID: "0123456789abcdef", Name: "/app-2"},
+		Config:            &container.Config{Env: []string{"APP_NAME=billing", "EMPTY="}},
+	}
+
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"plain value", "web", "web", false},
+		{"name", "{{ .Name }}", "app-2", false},
+		{"id", "svc-{{ .ID }}", "svc-0123456789ab", false},
+		{"env", "{{ .Env.APP_NAME }}-api", "billing-api", false},
+		{"empty env", "x{{ .Env.EMPTY }}", "x", false},
+		{"unset env", "{{ .Env.MISSING }}", "", true},
+		{"field outside the whitelist", "{{ .State.Pid }}", "", true},
+		{"syntax error", "{{ .Name ", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			labels := map[string]string{"docktail.service.name": tt.value}
+			rendered, err := renderLabels(labels, inspect)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("renderLabels() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, ErrInvalidLabel) {
+					t.Errorf("expected ErrInvalidLabel, got %v", err)
+				}
+				return
+			}
+			if got := rendered["docktail.service.name"]; got != tt.want {
+				t.Errorf("renderLabels() = %q, want %q", got, tt.want)
+			}
+			if labels["docktail.service.name"] != tt.value {
+				t.Errorf("renderLabels() modified the container's labels")
+			}
+		})
+	}
+}
+
+func TestRenderLabelsSkipsForeignLabels(t *testing.T) {
+	inspect := container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{ID: "0123456789abcdef", Name: "/app-2"},
+		Config:            &container.Config{},
+	}
+	labels := map[string]string{
+		"docktail.service.name":  "{{ .Name }}",
+		"homepage.widget.key":    "{{HOMEPAGE_VAR_X}}",
+		"traefik.http.routers.x": "{{ not a template",
+	}
+
+	rendered, err := renderLabels(labels, inspect)
+	if err != nil {
+		t.Fatalf("renderLabels() error = %v, foreign labels must not be rendered", err)
+	}
+	if got := rendered["docktail.service.name"]; got != "app-2" {
+		t.Errorf("renderLabels() = %q, want %q", got, "app-2")
+	}
+	for _, key := range []string{"homepage.widget.key", "traefik.http.routers.x"} {
+		if rendered[key] != labels[key] {
+			t.Errorf("renderLabels() changed %s to %q", key, rendered[key])
+		}
+	}
+}
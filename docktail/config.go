@@ -0,0 +1,304 @@
+package docktail
+
+import (
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/marvinvr/docktail/docker"
+	"github.com/marvinvr/docktail/reconciler"
+	"github.com/marvinvr/docktail/tailscale"
+)
+
+// Config holds all DockTail settings
+// Each field mirrors the environment variable of the same name read by the docktail binary
+type Config struct {
+	ReconcileInterval      time.Duration     // RECONCILE_INTERVAL
+	ReconcileJitter        time.Duration     // RECONCILE_JITTER
+	HealthAddr             string            // HEALTH_ADDR (empty = no health/status server)
+	PprofAddr              string            // PPROF_ADDR (empty = profiling disabled)
+	KeepServicesOnShutdown bool              // KEEP_SERVICES_ON_SHUTDOWN
+	RunOnce                bool              // RUN_ONCE or --once
+	ShutdownTimeout        time.Duration     // SHUTDOWN_TIMEOUT
+	WaitReadyTimeout       time.Duration     // WAIT_READY_TIMEOUT
+	IPRetryCount           int               // IP_RETRY_COUNT
+	IPRetryDelay           time.Duration     // IP_RETRY_DELAY
+	StageSelector          string            // STAGE_SELECTOR
+	HealthCheckInterval    time.Duration     // HEALTH_CHECK_INTERVAL
+	RemoveUnhealthy        bool              // REMOVE_UNHEALTHY
+	MaxServices            int               // MAX_SERVICES (0 = unlimited)
+	ServiceStabilizeDelay  time.Duration     // SERVICE_STABILIZE_DELAY (0 = disabled)
+	RemovalGrace           time.Duration     // REMOVAL_GRACE (0 = disabled)
+	MDNSAdvertise          bool              // MDNS_ADVERTISE
+	DefaultTargetProtocol  string            // DEFAULT_TARGET_PROTOCOL
+	DefaultHTTPSInsecure   bool              // DEFAULT_HTTPS_INSECURE
+	WebhookURL             string            // WEBHOOK_URL
+	InventoryFile          string            // INVENTORY_FILE
+	ControlToken           string            // CONTROL_TOKEN
+	ConfigSource           string            // CONFIG_SOURCE (labels, env, or both)
+	IncludeContainers      []string          // INCLUDE_CONTAINERS (regex patterns)
+	ExcludeContainers      []string          // EXCLUDE_CONTAINERS (regex patterns)
+	PublishedHost          string            // PUBLISHED_HOST
+	PublishedVia           string            // PUBLISHED_VIA (host or gateway)
+	PublishedGateway       string            // PUBLISHED_GATEWAY (empty = resolve from Docker)
+	ContainerRuntime       string            // CONTAINER_RUNTIME (docker or podman)
+	DockerSocket           string            // DOCKER_SOCKET (used when DOCKER_HOST is unset)
+	DockerContext          string            // DOCKER_CONTEXT (Docker CLI context, used when DOCKER_HOST is unset)
+	NetworkPriority        []string          // NETWORK_PRIORITY (network name suffixes)
+	DockerEvents           []string          // DOCKER_EVENTS (container events that trigger a reconciliation)
+	EventScopes            map[string]string // EVENT_SCOPES (event action -> full or targeted reconciliation)
+	StrictLabels           bool              // STRICT_LABELS
+	FailOnParseError       bool              // FAIL_ON_PARSE_ERROR
+	RequireTags            bool              // REQUIRE_TAGS
+	SkipReachability       bool              // SKIP_REACHABILITY
+	AutoServiceName        bool              // AUTO_SERVICE_NAME
+
+	TailscaleSocket            string   // TAILSCALE_SOCKET
+	TailscaleAPIKey            string   // TAILSCALE_API_KEY
+	TailscaleOAuthClientID     string   // TAILSCALE_OAUTH_CLIENT_ID
+	TailscaleOAuthClientSecret string   // TAILSCALE_OAUTH_CLIENT_SECRET
+	TailscaleTailnet           string   // TAILSCALE_TAILNET
+	NodeNameOverride           string   // NODE_NAME_OVERRIDE
+	TailscaleRetryMax          int      // TAILSCALE_RETRY_MAX
+	TraceTailscale             bool     // TRACE_TAILSCALE
+	TailscaleAPIRate           float64  // TS_API_RATE (requests per second, 0 = unlimited)
+	ACLSync                    bool     // ACL_SYNC
+	AdoptOrphans               bool     // ADOPT_ORPHANS
+	AllowServiceOverwrite      bool     // ALLOW_SERVICE_OVERWRITE
+	DefaultTags                []string // DEFAULT_SERVICE_TAGS
+	TagsMerge                  bool     // TAGS_MERGE
+
+	DefaultTailnet string          // DEFAULT_TAILNET (name of the tailnet configured by the TAILSCALE_* settings)
+	Tailnets       []TailnetConfig // TAILNETS (additional tailnets, each configured by TAILNET_<NAME>_* settings)
+}
+
+// TailnetConfig is an additional tailnet containers can select with docktail.service.tailnet
+// Each tailnet needs its own tailscaled, reached through its own socket
+type TailnetConfig struct {
+	Name              string
+	Socket            string // TAILNET_<NAME>_SOCKET
+	APIKey            string // TAILNET_<NAME>_API_KEY
+	OAuthClientID     string // TAILNET_<NAME>_OAUTH_CLIENT_ID
+	OAuthClientSecret string // TAILNET_<NAME>_OAUTH_CLIENT_SECRET
+	Tailnet           string // TAILNET_<NAME>_TAILNET
+}
+
+// TailnetEnvPrefix returns the prefix of the settings configuring the named tailnet, e.g. TAILNET_STAGING_
+func TailnetEnvPrefix(name string) string {
+	return "TAILNET_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+}
+
+// maxTailscaleRetries is the largest TAILSCALE_RETRY_MAX; retries block the reconcile loop,
+// and at the capped backoff 10 attempts already wait about two minutes for one CLI call
+const maxTailscaleRetries = 10
+
+// DefaultConfig returns a Config with the same defaults as the docktail binary
+func DefaultConfig() Config {
+	return Config{
+		ReconcileInterval:   60 * time.Second,
+		HealthCheckInterval: 30 * time.Second,
+		ShutdownTimeout:     30 * time.Second,
+		ConfigSource:        docker.ConfigSourceLabels,
+		PublishedHost:       "localhost",
+		PublishedVia:        docker.PublishedViaHost,
+		ContainerRuntime:    docker.RuntimeDocker,
+		DockerSocket:        docker.DefaultSocket,
+		IPRetryCount:        3,
+		IPRetryDelay:        200 * time.Millisecond,
+		DockerEvents:        slices.Clone(docker.DefaultEvents),
+		EventScopes:         maps.Clone(reconciler.DefaultEventScopes),
+		TailscaleSocket:     "/var/run/tailscale/tailscaled.sock",
+		TailscaleTailnet:    "-",
+		TailscaleRetryMax:   3,
+		TailscaleAPIRate:    tailscale.DefaultAPIRate,
+		AdoptOrphans:        true,
+		DefaultTags:         []string{"tag:container"},
+		DefaultTailnet:      reconciler.DefaultTailnetName,
+	}
+}
+
+// Validate checks the Tailscale settings up front so misconfiguration fails at startup
+// instead of mid-loop. Every problem found is reported in a single error.
+func (c Config) Validate() error {
+	problems := validateTailscale("TAILSCALE_", c.TailscaleSocket, c.TailscaleOAuthClientID, c.TailscaleOAuthClientSecret, c.apiSyncMethod(), c.TailscaleTailnet)
+
+	seen := map[string]bool{c.DefaultTailnet: true}
+	for _, tn := range c.Tailnets {
+		if seen[tn.Name] {
+			problems = append(problems, fmt.Sprintf("TAILNETS lists tailnet %q more than once (DEFAULT_TAILNET is %q)", tn.Name, c.DefaultTailnet))
+			continue
+		}
+		seen[tn.Name] = true
+		problems = append(problems, validateTailscale(TailnetEnvPrefix(tn.Name), tn.Socket, tn.OAuthClientID, tn.OAuthClientSecret, tn.apiSyncMethod(), tn.Tailnet)...)
+	}
+
+	if c.TailscaleRetryMax < 1 || c.TailscaleRetryMax > maxTailscaleRetries {
+		problems = append(problems, fmt.Sprintf("TAILSCALE_RETRY_MAX must be between 1 and %d (got %d)", maxTailscaleRetries, c.TailscaleRetryMax))
+	}
+
+	for action, scope := range c.EventScopes {
+		if action == "" || (scope != reconciler.EventScopeFull && scope != reconciler.EventScopeTargeted) {
+			problems = append(problems, fmt.Sprintf("invalid EVENT_SCOPES entry %q: must be <event>:full or <event>:targeted", action+":"+scope))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%d configuration problem(s):\n  - %s", len(problems), strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+// redacted is shown in place of a secret that is set; unset secrets stay empty
+const redacted = "***"
+
+// redact hides a secret value while still showing whether it was set
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return redacted
+}
+
+// redactURL keeps only the scheme and host of a URL, since webhook paths and queries often embed tokens
+func redactURL(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return redacted
+	}
+	return u.Scheme + "://" + u.Host + "/" + redacted
+}
+
+// Effective returns the configuration keyed by environment variable name, for the /config endpoint
+// API keys and OAuth client secrets are replaced with "***" when set, as is the webhook URL's path
+func (c Config) Effective() map[string]any {
+	settings := map[string]any{
+		"RECONCILE_INTERVAL":            c.ReconcileInterval.String(),
+		"RECONCILE_JITTER":              c.ReconcileJitter.String(),
+		"HEALTH_ADDR":                   c.HealthAddr,
+		"PPROF_ADDR":                    c.PprofAddr,
+		"KEEP_SERVICES_ON_SHUTDOWN":     c.KeepServicesOnShutdown,
+		"RUN_ONCE":                      c.RunOnce,
+		"SHUTDOWN_TIMEOUT":              c.ShutdownTimeout.String(),
+		"WAIT_READY_TIMEOUT":            c.WaitReadyTimeout.String(),
+		"IP_RETRY_COUNT":                c.IPRetryCount,
+		"IP_RETRY_DELAY":                c.IPRetryDelay.String(),
+		"STAGE_SELECTOR":                c.StageSelector,
+		"HEALTH_CHECK_INTERVAL":         c.HealthCheckInterval.String(),
+		"REMOVE_UNHEALTHY":              c.RemoveUnhealthy,
+		"MAX_SERVICES":                  c.MaxServices,
+		"SERVICE_STABILIZE_DELAY":       c.ServiceStabilizeDelay.String(),
+		"REMOVAL_GRACE":                 c.RemovalGrace.String(),
+		"MDNS_ADVERTISE":                c.MDNSAdvertise,
+		"DEFAULT_TARGET_PROTOCOL":       c.DefaultTargetProtocol,
+		"DEFAULT_HTTPS_INSECURE":        c.DefaultHTTPSInsecure,
+		"WEBHOOK_URL":                   redactURL(c.WebhookURL),
+		"INVENTORY_FILE":                c.InventoryFile,
+		"CONTROL_TOKEN":                 redact(c.ControlToken),
+		"CONFIG_SOURCE":                 c.ConfigSource,
+		"INCLUDE_CONTAINERS":            nonNil(c.IncludeContainers),
+		"EXCLUDE_CONTAINERS":            nonNil(c.ExcludeContainers),
+		"PUBLISHED_HOST":                c.PublishedHost,
+		"PUBLISHED_VIA":                 c.PublishedVia,
+		"PUBLISHED_GATEWAY":             c.PublishedGateway,
+		"CONTAINER_RUNTIME":             c.ContainerRuntime,
+		"DOCKER_SOCKET":                 c.DockerSocket,
+		"DOCKER_CONTEXT":                c.DockerContext,
+		"NETWORK_PRIORITY":              nonNil(c.NetworkPriority),
+		"DOCKER_EVENTS":                 nonNil(c.DockerEvents),
+		"EVENT_SCOPES":                  c.EventScopes,
+		"STRICT_LABELS":                 c.StrictLabels,
+		"FAIL_ON_PARSE_ERROR":           c.FailOnParseError,
+		"REQUIRE_TAGS":                  c.RequireTags,
+		"SKIP_REACHABILITY":             c.SkipReachability,
+		"AUTO_SERVICE_NAME":             c.AutoServiceName,
+		"TAILSCALE_SOCKET":              c.TailscaleSocket,
+		"TAILSCALE_API_KEY":             redact(c.TailscaleAPIKey),
+		"TAILSCALE_OAUTH_CLIENT_ID":     c.TailscaleOAuthClientID,
+		"TAILSCALE_OAUTH_CLIENT_SECRET": redact(c.TailscaleOAuthClientSecret),
+		"TAILSCALE_TAILNET":             c.TailscaleTailnet,
+		"NODE_NAME_OVERRIDE":            c.NodeNameOverride,
+		"TAILSCALE_RETRY_MAX":           c.TailscaleRetryMax,
+		"TRACE_TAILSCALE":               c.TraceTailscale,
+		"TS_API_RATE":                   c.TailscaleAPIRate,
+		"ACL_SYNC":                      c.ACLSync,
+		"ADOPT_ORPHANS":                 c.AdoptOrphans,
+		"ALLOW_SERVICE_OVERWRITE":       c.AllowServiceOverwrite,
+		"DEFAULT_SERVICE_TAGS":          nonNil(c.DefaultTags),
+		"TAGS_MERGE":                    c.TagsMerge,
+		"DEFAULT_TAILNET":               c.DefaultTailnet,
+	}
+
+	names := make([]string, 0, len(c.Tailnets))
+	for _, tn := range c.Tailnets {
+		names = append(names, tn.Name)
+		prefix := TailnetEnvPrefix(tn.Name)
+		settings[prefix+"SOCKET"] = tn.Socket
+		settings[prefix+"API_KEY"] = redact(tn.APIKey)
+		settings[prefix+"OAUTH_CLIENT_ID"] = tn.OAuthClientID
+		settings[prefix+"OAUTH_CLIENT_SECRET"] = redact(tn.OAuthClientSecret)
+		settings[prefix+"TAILNET"] = tn.Tailnet
+	}
+	settings["TAILNETS"] = names
+
+	return settings
+}
+
+// nonNil returns an empty list instead of nil so unset lists encode as [] rather than null
+func nonNil(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
+
+// apiSyncMethod returns which Control Plane credentials are in use: oauth, api_key or disabled
+func (c Config) apiSyncMethod() string {
+	if c.TailscaleOAuthClientID != "" && c.TailscaleOAuthClientSecret != "" {
+		return "oauth"
+	} else if c.TailscaleAPIKey != "" {
+		return "api_key"
+	}
+	return "disabled"
+}
+
+// apiSyncMethod returns which Control Plane credentials the tailnet uses: oauth, api_key or disabled
+func (t TailnetConfig) apiSyncMethod() string {
+	if t.OAuthClientID != "" && t.OAuthClientSecret != "" {
+		return "oauth"
+	} else if t.APIKey != "" {
+		return "api_key"
+	}
+	return "disabled"
+}
+
+// validateTailscale checks the settings of one tailnet, whose setting names start with prefix
+func validateTailscale(prefix, socket, oauthClientID, oauthClientSecret, apiSyncMethod, tailnet string) []string {
+	var problems []string
+
+	if socket == "" {
+		problems = append(problems, fmt.Sprintf("%sSOCKET must be set", prefix))
+	} else if info, err := os.Stat(socket); err != nil {
+		problems = append(problems, fmt.Sprintf("%sSOCKET %s is not accessible: %v (is tailscaled running and the socket mounted?)", prefix, socket, err))
+	} else if info.Mode()&os.ModeSocket == 0 {
+		problems = append(problems, fmt.Sprintf("%sSOCKET %s is not a unix socket", prefix, socket))
+	}
+
+	if (oauthClientID == "") != (oauthClientSecret == "") {
+		problems = append(problems, fmt.Sprintf("%sOAUTH_CLIENT_ID and %sOAUTH_CLIENT_SECRET must both be set or both be empty", prefix, prefix))
+	}
+
+	// OAuth clients are scoped to a single tailnet, so "-" is unambiguous there;
+	// an API key belongs to a user who may be a member of several tailnets
+	if apiSyncMethod == "api_key" && (tailnet == "-" || tailnet == "") {
+		problems = append(problems, fmt.Sprintf("%sTAILNET must be set to your tailnet name when using %sAPI_KEY", prefix, prefix))
+	}
+
+	return problems
+}
@@ -0,0 +1,105 @@
+package docktail
+
+import (
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigValidate(t *testing.T) {
+	dir := t.TempDir()
+	socketPath := filepath.Join(dir, "tailscaled.sock")
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatalf("failed to create test socket: %v", err)
+	}
+	defer func() { _ = listener.Close() }()
+
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr string
+	}{
+		{"defaults", func(c *Config) {}, ""},
+		{"missing socket", func(c *Config) { c.TailscaleSocket = filepath.Join(dir, "missing.sock") }, "is not accessible"},
+		{"not a socket", func(c *Config) { c.TailscaleSocket = dir }, "is not a unix socket"},
+		{"oauth id only", func(c *Config) { c.TailscaleOAuthClientID = "id" }, "must both be set"},
+		{"api key without tailnet", func(c *Config) { c.TailscaleAPIKey = "key" }, "TAILSCALE_TAILNET"},
+		{"api key with tailnet", func(c *Config) { c.TailscaleAPIKey = "key"; c.TailscaleTailnet = "example.com" }, ""},
+		{"oauth with default tailnet", func(c *Config) {
+			c.TailscaleOAuthClientID = "id"
+			c.TailscaleOAuthClientSecret = "secret"
+		}, ""},
+		{"extra tailnet", func(c *Config) {
+			c.Tailnets = []TailnetConfig{{Name: "staging", Socket: socketPath, Tailnet: "-"}}
+		}, ""},
+		{"extra tailnet without socket", func(c *Config) {
+			c.Tailnets = []TailnetConfig{{Name: "staging", Tailnet: "-"}}
+		}, "TAILNET_STAGING_SOCKET must be set"},
+		{"extra tailnet api key without tailnet", func(c *Config) {
+			c.Tailnets = []TailnetConfig{{Name: "prod-eu", Socket: socketPath, APIKey: "key", Tailnet: "-"}}
+		}, "TAILNET_PROD_EU_TAILNET"},
+		{"targeted start events", func(c *Config) { c.EventScopes["start"] = "targeted" }, ""},
+		{"unknown event scope", func(c *Config) { c.EventScopes["start"] = "partial" }, "invalid EVENT_SCOPES entry"},
+		{"no tailscale attempts", func(c *Config) { c.TailscaleRetryMax = 0 }, "TAILSCALE_RETRY_MAX"},
+		{"too many tailscale retries", func(c *Config) { c.TailscaleRetryMax = 40 }, "TAILSCALE_RETRY_MAX"},
+		{"extra tailnet named like the default", func(c *Config) {
+			c.Tailnets = []TailnetConfig{{Name: "default", Socket: socketPath, Tailnet: "-"}}
+		}, "more than once"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.TailscaleSocket = socketPath
+			tt.modify(&cfg)
+
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigEffectiveRedactsSecrets(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.TailscaleOAuthClientID = "k123"
+	cfg.TailscaleOAuthClientSecret = "tskey-client-secret"
+	cfg.WebhookURL = "https://hooks.example.com/services/T000/B000/token?key=secret"
+	cfg.Tailnets = []TailnetConfig{{Name: "staging", Socket: "/run/ts.sock", APIKey: "tskey-api-secret", Tailnet: "example.com"}}
+
+	settings := cfg.Effective()
+
+	tests := []struct {
+		key  string
+		want any
+	}{
+		{"TAILSCALE_OAUTH_CLIENT_ID", "k123"},
+		{"TAILSCALE_OAUTH_CLIENT_SECRET", "***"},
+		{"TAILSCALE_API_KEY", ""},
+		{"WEBHOOK_URL", "https://hooks.example.com/***"},
+		{"TAILNET_STAGING_API_KEY", "***"},
+		{"TAILNET_STAGING_OAUTH_CLIENT_SECRET", ""},
+		{"TAILNET_STAGING_TAILNET", "example.com"},
+		{"RECONCILE_INTERVAL", "1m0s"},
+	}
+	for _, tt := range tests {
+		if got := settings[tt.key]; got != tt.want {
+			t.Errorf("Effective()[%s] = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+
+	for key, value := range settings {
+		if s, ok := value.(string); ok && strings.Contains(s, "secret") {
+			t.Errorf("Effective()[%s] leaks a secret: %q", key, s)
+		}
+	}
+}
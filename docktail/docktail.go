@@ -0,0 +1,325 @@
+// Package docktail wires the Docker, Tailscale and reconciler packages together
+// so DockTail can be embedded in another binary as well as run standalone
+package docktail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/marvinvr/docktail/docker"
+	"github.com/marvinvr/docktail/reconciler"
+	"github.com/marvinvr/docktail/server"
+	"github.com/marvinvr/docktail/tailscale"
+)
+
+// Run starts DockTail and blocks until the context is cancelled, or until a single pass completes when cfg.RunOnce is set
+// On return, managed services are cleaned up unless cfg.KeepServicesOnShutdown is set
+func Run(ctx context.Context, cfg Config) error {
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	// Create one Tailscale client per tailnet, the default one first
+	// Their "-" tailnets are resolved to names first, so the configuration below shows them
+	// A tailscale too old for Tailscale Services fails here rather than on every serve command
+	tailnets := newTailscaleClients(cfg)
+	tailscaleClient := tailnets[0].client
+	cfg.Tailnets = slices.Clone(cfg.Tailnets)
+	for i, tn := range tailnets {
+		resolveCtx, resolveCancel := context.WithTimeout(ctx, 15*time.Second)
+		if err := tn.client.CheckVersion(resolveCtx); err != nil {
+			resolveCancel()
+			return fmt.Errorf("tailnet %s: %w", tn.name, err)
+		}
+		tn.client.ResolveTailnet(resolveCtx)
+		tn.client.ResolveNodeName(resolveCtx)
+		resolveCancel()
+		if i == 0 {
+			cfg.TailscaleTailnet = tn.client.Tailnet()
+		} else {
+			cfg.Tailnets[i-1].Tailnet = tn.client.Tailnet()
+		}
+	}
+
+	log.Info().
+		Dur("reconcile_interval", cfg.ReconcileInterval).
+		Dur("reconcile_jitter", cfg.ReconcileJitter).
+		Str("tailscale_socket", cfg.TailscaleSocket).
+		Str("health_addr", cfg.HealthAddr).
+		Str("pprof_addr", cfg.PprofAddr).
+		Bool("keep_services_on_shutdown", cfg.KeepServicesOnShutdown).
+		Bool("run_once", cfg.RunOnce).
+		Dur("shutdown_timeout", cfg.ShutdownTimeout).
+		Dur("wait_ready_timeout", cfg.WaitReadyTimeout).
+		Int("ip_retry_count", cfg.IPRetryCount).
+		Dur("ip_retry_delay", cfg.IPRetryDelay).
+		Str("stage_selector", cfg.StageSelector).
+		Dur("health_check_interval", cfg.HealthCheckInterval).
+		Bool("remove_unhealthy", cfg.RemoveUnhealthy).
+		Int("max_services", cfg.MaxServices).
+		Dur("service_stabilize_delay", cfg.ServiceStabilizeDelay).
+		Dur("removal_grace", cfg.RemovalGrace).
+		Bool("mdns_advertise", cfg.MDNSAdvertise).
+		Bool("webhook_enabled", cfg.WebhookURL != "").
+		Str("inventory_file", cfg.InventoryFile).
+		Bool("control_token_set", cfg.ControlToken != "").
+		Str("api_sync_method", cfg.apiSyncMethod()).
+		Str("tailnet", cfg.TailscaleTailnet).
+		Str("node", tailscaleClient.NodeName()).
+		Str("tailscale_version", tailscaleClient.Version()).
+		Str("default_tailnet", cfg.DefaultTailnet).
+		Strs("default_tags", cfg.DefaultTags).
+		Bool("tags_merge", cfg.TagsMerge).
+		Str("default_target_protocol", cfg.DefaultTargetProtocol).
+		Bool("default_https_insecure", cfg.DefaultHTTPSInsecure).
+		Int("tailscale_retry_max", cfg.TailscaleRetryMax).
+		Bool("trace_tailscale", cfg.TraceTailscale).
+		Float64("ts_api_rate", cfg.TailscaleAPIRate).
+		Bool("acl_sync", cfg.ACLSync).
+		Bool("adopt_orphans", cfg.AdoptOrphans).
+		Bool("allow_service_overwrite", cfg.AllowServiceOverwrite).
+		Str("config_source", cfg.ConfigSource).
+		Strs("include_containers", cfg.IncludeContainers).
+		Strs("exclude_containers", cfg.ExcludeContainers).
+		Str("published_host", cfg.PublishedHost).
+		Str("published_via", cfg.PublishedVia).
+		Str("published_gateway", cfg.PublishedGateway).
+		Str("container_runtime", cfg.ContainerRuntime).
+		Str("docker_socket", cfg.DockerSocket).
+		Str("docker_context", cfg.DockerContext).
+		Strs("network_priority", cfg.NetworkPriority).
+		Strs("docker_events", cfg.DockerEvents).
+		Interface("event_scopes", cfg.EventScopes).
+		Bool("strict_labels", cfg.StrictLabels).
+		Bool("fail_on_parse_error", cfg.FailOnParseError).
+		Bool("require_tags", cfg.RequireTags).
+		Bool("skip_reachability", cfg.SkipReachability).
+		Bool("auto_service_name", cfg.AutoServiceName).
+		Msg("Configuration loaded")
+
+	// Create Docker client
+	dockerClient, err := newDockerClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create Docker client: %w", err)
+	}
+	defer func() { _ = dockerClient.Close() }()
+
+	log.Info().Msg("Docker client initialized")
+
+	log.Info().Int("tailnets", len(tailnets)).Msg("Tailscale client initialized")
+
+	// Surface untagged nodes and undefined tags before any container is served
+	for _, tn := range tailnets {
+		checkCtx, checkCancel := context.WithTimeout(ctx, 15*time.Second)
+		tn.client.SelfCheck(checkCtx, cfg.DefaultTags)
+		checkCancel()
+	}
+
+	extraTailnets := make(map[string]*tailscale.Client, len(tailnets)-1)
+	for _, tn := range tailnets[1:] {
+		extraTailnets[tn.name] = tn.client
+	}
+
+	// Create reconciler
+	rec := reconciler.NewReconciler(dockerClient, tailscaleClient, reconciler.Config{
+		Interval:            cfg.ReconcileInterval,
+		Jitter:              cfg.ReconcileJitter,
+		HealthCheckInterval: cfg.HealthCheckInterval,
+		RemoveUnhealthy:     cfg.RemoveUnhealthy,
+		WebhookURL:          cfg.WebhookURL,
+		InventoryFile:       cfg.InventoryFile,
+		MaxServices:         cfg.MaxServices,
+		StabilizeDelay:      cfg.ServiceStabilizeDelay,
+		RemovalGrace:        cfg.RemovalGrace,
+		MDNSAdvertise:       cfg.MDNSAdvertise,
+		FailOnParseError:    cfg.FailOnParseError,
+		RequireTags:         cfg.RequireTags,
+		EventScopes:         cfg.EventScopes,
+		DefaultTailnet:      cfg.DefaultTailnet,
+		Tailnets:            extraTailnets,
+	})
+
+	var runErr error
+	if cfg.RunOnce {
+		// One-shot mode (CI/cron): no servers, just a single pass
+		log.Info().Msg("Running a single reconciliation (RUN_ONCE)")
+		if err := rec.RunOnce(ctx); err != nil {
+			runErr = fmt.Errorf("reconciliation failed: %w", err)
+		}
+	} else {
+		// The servers stop with runCtx, also when the reconciler stops on its own (FAIL_ON_PARSE_ERROR)
+		runCtx, cancelRun := context.WithCancel(ctx)
+		defer cancelRun()
+
+		// Start health/status server
+		if cfg.HealthAddr != "" {
+			srv := server.New(cfg.HealthAddr, rec, cfg.Effective(), cfg.ControlToken)
+			go func() {
+				if err := srv.Run(runCtx); err != nil {
+					log.Error().Err(err).Msg("Health server failed")
+				}
+			}()
+		}
+
+		// Start profiling server (opt-in, for debugging leaks in long-running processes)
+		if cfg.PprofAddr != "" {
+			go func() {
+				if err := server.RunPprof(runCtx, cfg.PprofAddr); err != nil {
+					log.Error().Err(err).Msg("pprof server failed")
+				}
+			}()
+		}
+
+		// SIGUSR1/SIGUSR2 pause and resume reconciliation for maintenance
+		watchPauseSignals(runCtx, rec)
+
+		// Run reconciler
+		log.Info().Msg("Starting reconciliation loop")
+		if err := rec.Run(runCtx); err != nil && !errors.Is(err, context.Canceled) {
+			runErr = fmt.Errorf("reconciler failed: %w", err)
+		}
+		cancelRun()
+	}
+
+	cleaners := make([]namedCleaner, 0, len(tailnets))
+	for _, tn := range tailnets {
+		cleaners = append(cleaners, namedCleaner{name: tn.name, cleaner: tn.client})
+	}
+	return shutdown(cfg, rec, cleaners, runErr)
+}
+
+// reconcilerWaiter waits for a stopped reconciler to drain (*reconciler.Reconciler)
+type reconcilerWaiter interface {
+	Wait(ctx context.Context) error
+}
+
+// serviceCleaner removes every service DockTail manages on one tailnet (*tailscale.Client)
+type serviceCleaner interface {
+	CleanupAllServices(ctx context.Context) error
+}
+
+// namedCleaner is the serviceCleaner of one configured tailnet
+type namedCleaner struct {
+	name    string
+	cleaner serviceCleaner
+}
+
+// shutdown runs once the reconciler has stopped, however it stopped: managed services are cleaned
+// up unless cfg.KeepServicesOnShutdown is set, and runErr (the reason it stopped, if any) is returned
+func shutdown(cfg Config, rec reconcilerWaiter, tailnets []namedCleaner, runErr error) error {
+	if cfg.KeepServicesOnShutdown {
+		// Leave services advertised so they survive a DockTail restart/upgrade;
+		// the next run reconciles them back to the desired state
+		log.Info().Msg("Reconciler stopped, keeping Tailscale services in place (KEEP_SERVICES_ON_SHUTDOWN=true)")
+		return runErr
+	}
+
+	// Graceful shutdown: clean up all Tailscale services
+	log.Info().Msg("Reconciler stopped, cleaning up Tailscale services")
+
+	// Use a new context with timeout for cleanup (don't use cancelled context)
+	cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cleanupCancel()
+
+	// A reconciliation or health probe still in flight could re-create what cleanup removes
+	if !cfg.RunOnce {
+		if err := rec.Wait(cleanupCtx); err != nil {
+			log.Warn().Err(err).Msg("Reconciler did not stop in time, cleaning up anyway")
+		}
+	}
+
+	for _, tn := range tailnets {
+		if err := tn.cleaner.CleanupAllServices(cleanupCtx); err != nil {
+			log.Error().Err(err).Str("tailnet", tn.name).Msg("Failed to clean up all services during shutdown")
+		} else {
+			log.Info().Str("tailnet", tn.name).Msg("Successfully cleaned up all services")
+		}
+	}
+
+	return runErr
+}
+
+// namedTailnet is the Tailscale client of one configured tailnet
+type namedTailnet struct {
+	name   string
+	client *tailscale.Client
+}
+
+// newTailscaleClients creates the default tailnet's client from the TAILSCALE_* settings,
+// followed by one client per additional tailnet in TAILNETS order
+func newTailscaleClients(cfg Config) []namedTailnet {
+	tailnets := []namedTailnet{{
+		name: cfg.DefaultTailnet,
+		client: tailscale.NewClient(tailscale.ClientConfig{
+			SocketPath:            cfg.TailscaleSocket,
+			Tailnet:               cfg.TailscaleTailnet,
+			APIKey:                cfg.TailscaleAPIKey,
+			OAuthClientID:         cfg.TailscaleOAuthClientID,
+			OAuthClientSecret:     cfg.TailscaleOAuthClientSecret,
+			RetryMax:              cfg.TailscaleRetryMax,
+			TraceCLI:              cfg.TraceTailscale,
+			APIRate:               cfg.TailscaleAPIRate,
+			ACLSync:               cfg.ACLSync,
+			AdoptOrphans:          cfg.AdoptOrphans,
+			AllowServiceOverwrite: cfg.AllowServiceOverwrite,
+			NodeName:              cfg.NodeNameOverride,
+		}),
+	}}
+	for _, tn := range cfg.Tailnets {
+		log.Info().
+			Str("tailnet", tn.Name).
+			Str("socket", tn.Socket).
+			Str("api_sync_method", tn.apiSyncMethod()).
+			Msg("Configuring additional tailnet")
+		tailnets = append(tailnets, namedTailnet{
+			name: tn.Name,
+			client: tailscale.NewClient(tailscale.ClientConfig{
+				SocketPath:            tn.Socket,
+				Tailnet:               tn.Tailnet,
+				APIKey:                tn.APIKey,
+				OAuthClientID:         tn.OAuthClientID,
+				OAuthClientSecret:     tn.OAuthClientSecret,
+				RetryMax:              cfg.TailscaleRetryMax,
+				TraceCLI:              cfg.TraceTailscale,
+				APIRate:               cfg.TailscaleAPIRate,
+				ACLSync:               cfg.ACLSync,
+				AdoptOrphans:          cfg.AdoptOrphans,
+				AllowServiceOverwrite: cfg.AllowServiceOverwrite,
+			}),
+		})
+	}
+	return tailnets
+}
+
+// newDockerClient creates the Docker client from the DockTail configuration
+func newDockerClient(cfg Config) (*docker.Client, error) {
+	return docker.NewClient(docker.ClientConfig{
+		DefaultTags:           cfg.DefaultTags,
+		WaitReadyTimeout:      cfg.WaitReadyTimeout,
+		DefaultTargetProtocol: cfg.DefaultTargetProtocol,
+		DefaultHTTPSInsecure:  cfg.DefaultHTTPSInsecure,
+		ConfigSource:          cfg.ConfigSource,
+		IncludeContainers:     cfg.IncludeContainers,
+		ExcludeContainers:     cfg.ExcludeContainers,
+		PublishedHost:         cfg.PublishedHost,
+		PublishedVia:          cfg.PublishedVia,
+		PublishedGateway:      cfg.PublishedGateway,
+		Runtime:               cfg.ContainerRuntime,
+		NetworkPriority:       cfg.NetworkPriority,
+		TagsMerge:             cfg.TagsMerge,
+		Events:                cfg.DockerEvents,
+		StrictLabels:          cfg.StrictLabels,
+		AutoServiceName:       cfg.AutoServiceName,
+		Socket:                cfg.DockerSocket,
+		Context:               cfg.DockerContext,
+		IPRetries:             cfg.IPRetryCount,
+		IPRetryDelay:          cfg.IPRetryDelay,
+		StageSelector:         cfg.StageSelector,
+		SkipReachability:      cfg.SkipReachability,
+	})
+}
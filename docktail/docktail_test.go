@@ -0,0 +1,62 @@
+package docktail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/marvinvr/docktail/reconciler"
+)
+
+type fakeWaiter struct{ waited bool }
+
+func (f *fakeWaiter) Wait(ctx context.Context) error {
+	f.waited = true
+	return nil
+}
+
+type fakeCleaner struct{ cleaned bool }
+
+func (f *fakeCleaner) CleanupAllServices(ctx context.Context) error {
+	f.cleaned = true
+	return nil
+}
+
+func TestShutdownAfterParseError(t *testing.T) {
+	tests := []struct {
+		name        string
+		keep        bool
+		wantCleaned bool
+	}{
+		{name: "cleans up services", keep: false, wantCleaned: true},
+		{name: "keeps services", keep: true, wantCleaned: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{
+				FailOnParseError:       true,
+				KeepServicesOnShutdown: tt.keep,
+				ShutdownTimeout:        time.Second,
+			}
+			waiter := &fakeWaiter{}
+			cleaners := []namedCleaner{{name: "a", cleaner: &fakeCleaner{}}, {name: "b", cleaner: &fakeCleaner{}}}
+			runErr := fmt.Errorf("reconciler failed: %w", reconciler.ErrInvalidContainers)
+
+			err := shutdown(cfg, waiter, cleaners, runErr)
+			if !errors.Is(err, reconciler.ErrInvalidContainers) {
+				t.Errorf("shutdown() error = %v, want %v", err, reconciler.ErrInvalidContainers)
+			}
+			if waiter.waited != tt.wantCleaned {
+				t.Errorf("reconciler waited = %v, want %v", waiter.waited, tt.wantCleaned)
+			}
+			for _, c := range cleaners {
+				if got := c.cleaner.(*fakeCleaner).cleaned; got != tt.wantCleaned {
+					t.Errorf("tailnet %q cleaned = %v, want %v", c.name, got, tt.wantCleaned)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package docktail
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/marvinvr/docktail/tailscale"
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+// Explanation is the fully resolved plan for a single container
+type Explanation struct {
+	Service     string                     `json:"service"`     // Tailscale service name, e.g. svc:web
+	Destination string                     `json:"destination"` // What tailscale serve will proxy to
+	Container   *apptypes.ContainerService `json:"container"`   // Every resolved (and defaulted) setting
+}
+
+// Explain resolves the service one container (by name or ID) would get, without touching Tailscale
+// It only needs Docker access, so the Tailscale settings in cfg are not validated
+func Explain(ctx context.Context, cfg Config, container string) (*Explanation, error) {
+	dockerClient, err := newDockerClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Docker client: %w", err)
+	}
+	defer func() { _ = dockerClient.Close() }()
+
+	svc, err := dockerClient.ExplainContainer(ctx, container)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Explanation{
+		Service:     "svc:" + svc.ServiceName,
+		Destination: tailscale.BuildDestination(svc),
+		Container:   svc,
+	}, nil
+}
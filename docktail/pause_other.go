@@ -0,0 +1,12 @@
+//go:build !unix
+
+package docktail
+
+import (
+	"context"
+
+	"github.com/marvinvr/docktail/reconciler"
+)
+
+// watchPauseSignals is a no-op where SIGUSR1/SIGUSR2 do not exist
+func watchPauseSignals(ctx context.Context, rec *reconciler.Reconciler) {}
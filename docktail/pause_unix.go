@@ -0,0 +1,39 @@
+//go:build unix
+
+package docktail
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/marvinvr/docktail/reconciler"
+)
+
+// watchPauseSignals lets operators freeze reconciliation (e.g. during a
+// tailscaled upgrade) without stopping DockTail and triggering cleanup
+// SIGUSR1 toggles the paused state, SIGUSR2 always resumes
+func watchPauseSignals(ctx context.Context, rec *reconciler.Reconciler) {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGUSR1, syscall.SIGUSR2)
+
+	go func() {
+		defer signal.Stop(sigChan)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case sig := <-sigChan:
+				log.Info().Str("signal", sig.String()).Msg("Received pause/resume signal")
+				if sig == syscall.SIGUSR2 {
+					rec.Resume()
+				} else {
+					rec.TogglePause()
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// dedupWriter sits between zerolog and the output and drops warnings identical to one already
+// logged within the window (LOG_DEDUP_WINDOW), such as the same container's label warning every loop
+// Once a window ends, a line with a "suppressed" count is written in place of the dropped repeats
+type dedupWriter struct {
+	out io.Writer
+	now func() time.Time
+
+	mu     sync.Mutex
+	window time.Duration
+	seen   map[string]*dedupEntry
+}
+
+// dedupEntry is a warning logged in the current window and how many repeats were dropped since
+type dedupEntry struct {
+	fields     map[string]any
+	since      time.Time
+	suppressed int
+}
+
+func newDedupWriter(out io.Writer) *dedupWriter {
+	return &dedupWriter{out: out, now: time.Now, seen: make(map[string]*dedupEntry)}
+}
+
+// start enables deduplication and writes the suppressed counts every window (0 = pass everything through)
+func (w *dedupWriter) start(window time.Duration) {
+	if window <= 0 {
+		return
+	}
+	w.mu.Lock()
+	w.window = window
+	w.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(window)
+		defer ticker.Stop()
+		for range ticker.C {
+			w.flush()
+		}
+	}()
+}
+
+// Write receives one JSON log line per call from zerolog
+func (w *dedupWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.window <= 0 {
+		return w.out.Write(p)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(p, &fields); err != nil || fields[zerolog.LevelFieldName] != zerolog.LevelWarnValue {
+		return w.out.Write(p)
+	}
+
+	key := dedupKey(fields)
+	now := w.now()
+	entry, ok := w.seen[key]
+	if ok && now.Sub(entry.since) < w.window {
+		entry.suppressed++
+		return len(p), nil
+	}
+
+	w.seen[key] = &dedupEntry{fields: fields, since: now}
+	if !ok || entry.suppressed == 0 {
+		return w.out.Write(p)
+	}
+	// The previous window's repeats are reported on the line that starts the next one
+	if _, err := w.writeFields(fields, entry.suppressed); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// flush writes the suppressed count of every ended window and forgets those warnings
+func (w *dedupWriter) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := w.now()
+	for key, entry := range w.seen {
+		if now.Sub(entry.since) < w.window {
+			continue
+		}
+		if entry.suppressed > 0 {
+			fields := make(map[string]any, len(entry.fields))
+			for k, v := range entry.fields {
+				fields[k] = v
+			}
+			fields[zerolog.TimestampFieldName] = formatTime(now)
+			_, _ = w.writeFields(fields, entry.suppressed)
+		}
+		delete(w.seen, key)
+	}
+}
+
+// writeFields writes a log line with the number of identical lines dropped before it
+// Caller must hold mu
+func (w *dedupWriter) writeFields(fields map[string]any, suppressed int) (int, error) {
+	fields["suppressed"] = suppressed
+	line, err := json.Marshal(fields)
+	if err != nil {
+		return 0, err
+	}
+	return w.out.Write(append(line, '\n'))
+}
+
+// dedupKey identifies identical log lines: every field except the timestamp
+func dedupKey(fields map[string]any) string {
+	rest := make(map[string]any, len(fields))
+	for k, v := range fields {
+		if k != zerolog.TimestampFieldName {
+			rest[k] = v
+		}
+	}
+	key, _ := json.Marshal(rest)
+	return string(key)
+}
+
+// formatTime formats a timestamp the way zerolog writes it
+func formatTime(t time.Time) any {
+	if zerolog.TimeFieldFormat == zerolog.TimeFormatUnix {
+		return t.Unix()
+	}
+	return t.Format(zerolog.TimeFieldFormat)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func TestDedupWriter(t *testing.T) {
+	var out bytes.Buffer
+	w := newDedupWriter(&out)
+	now := time.Unix(1700000000, 0)
+	w.now = func() time.Time { return now }
+	w.window = time.Minute
+	logger := zerolog.New(w)
+
+	tagWarning := func(container string) {
+		logger.Warn().Str("container", container).Str("tag", "web").Msg("Tag should start with 'tag:' prefix per Tailscale convention")
+	}
+
+	tagWarning("web")
+	tagWarning("web")
+	tagWarning("web")
+	tagWarning("api")
+	logger.Info().Msg("Reconciliation summary")
+	logger.Info().Msg("Reconciliation summary")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected web's repeats to be dropped and info lines kept, got %d lines:\n%s", len(lines), out.String())
+	}
+
+	// The first warning after the window reports the repeats it replaced
+	out.Reset()
+	now = now.Add(time.Minute)
+	tagWarning("web")
+	var fields map[string]any
+	if err := json.Unmarshal(out.Bytes(), &fields); err != nil {
+		t.Fatalf("invalid log line %q: %v", out.String(), err)
+	}
+	if fields["suppressed"] != float64(2) || fields["container"] != "web" {
+		t.Errorf("expected web's line with suppressed=2, got %v", fields)
+	}
+
+	// Repeats that never recur are reported by the periodic flush
+	out.Reset()
+	tagWarning("web")
+	now = now.Add(time.Minute)
+	w.flush()
+	if err := json.Unmarshal(out.Bytes(), &fields); err != nil {
+		t.Fatalf("invalid summary line %q: %v", out.String(), err)
+	}
+	if fields["suppressed"] != float64(1) {
+		t.Errorf("expected a summary with suppressed=1, got %v", fields)
+	}
+	if len(w.seen) != 0 {
+		t.Errorf("expected ended windows to be forgotten, %d left", len(w.seen))
+	}
+}
@@ -2,84 +2,64 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
-	"strings"
-
 	"github.com/marvinvr/docktail/docker"
+	"github.com/marvinvr/docktail/docktail"
 	"github.com/marvinvr/docktail/reconciler"
 	"github.com/marvinvr/docktail/tailscale"
 )
 
 func main() {
-	// Setup logging
-	setupLogging()
-
-	log.Info().Msg("Starting DockTail")
+	once := flag.Bool("once", false, "reconcile once and exit (same as RUN_ONCE=true)")
+	explain := flag.String("explain", "", "print the resolved service of this container (name or ID) as JSON and exit, without touching Tailscale")
+	flag.Parse()
 
-	// Get configuration from environment
-	reconcileInterval := getEnvDuration("RECONCILE_INTERVAL", 60*time.Second)
-	tailscaleSocket := getEnv("TAILSCALE_SOCKET", "/var/run/tailscale/tailscaled.sock")
-
-	// Control Plane Configuration
-	tailscaleAPIKey := getEnv("TAILSCALE_API_KEY", "")
-	tailscaleOAuthClientID := getEnv("TAILSCALE_OAUTH_CLIENT_ID", "")
-	tailscaleOAuthClientSecret := getEnv("TAILSCALE_OAUTH_CLIENT_SECRET", "")
-	tailscaleTailnet := getEnv("TAILSCALE_TAILNET", "-")
-	defaultTagsStr := getEnv("DEFAULT_SERVICE_TAGS", "tag:container")
-
-	// Parse default tags
-	var defaultTags []string
-	for _, tag := range strings.Split(defaultTagsStr, ",") {
-		if trimmed := strings.TrimSpace(tag); trimmed != "" {
-			defaultTags = append(defaultTags, trimmed)
-		}
+	// Load the optional config file first so it can also set LOG_LEVEL/LOG_FORMAT
+	var configFileErr error
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		fileSettings, configFileErr = loadConfigFile(path)
 	}
 
-	// Determine API sync method for logging
-	apiSyncMethod := "disabled"
-	if tailscaleOAuthClientID != "" && tailscaleOAuthClientSecret != "" {
-		apiSyncMethod = "oauth"
-	} else if tailscaleAPIKey != "" {
-		apiSyncMethod = "api_key"
+	// Setup logging
+	// With --explain, stdout carries the JSON result, so logs go to stderr
+	logOutput := os.Stdout
+	if *explain != "" {
+		logOutput = os.Stderr
 	}
+	setupLogging(logOutput)
 
-	log.Info().
-		Dur("reconcile_interval", reconcileInterval).
-		Str("tailscale_socket", tailscaleSocket).
-		Str("api_sync_method", apiSyncMethod).
-		Str("tailnet", tailscaleTailnet).
-		Strs("default_tags", defaultTags).
-		Msg("Configuration loaded")
-
-	// Create Docker client
-	dockerClient, err := docker.NewClient(defaultTags)
-	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to create Docker client")
+	if configFileErr != nil {
+		log.Fatal().Err(configFileErr).Msg("Invalid config file")
 	}
-	defer func() { _ = dockerClient.Close() }()
 
-	log.Info().Msg("Docker client initialized")
-
-	// Create Tailscale client
-	tailscaleClient := tailscale.NewClient(tailscale.ClientConfig{
-		SocketPath:        tailscaleSocket,
-		Tailnet:           tailscaleTailnet,
-		APIKey:            tailscaleAPIKey,
-		OAuthClientID:     tailscaleOAuthClientID,
-		OAuthClientSecret: tailscaleOAuthClientSecret,
-	})
+	log.Info().Msg("Starting DockTail")
 
-	log.Info().Msg("Tailscale client initialized")
+	// Get configuration from environment
+	cfg := configFromEnv()
+	if *once {
+		cfg.RunOnce = true
+	}
+	if unknown := unknownSettings(); len(unknown) > 0 {
+		log.Warn().Strs("keys", unknown).Msg("Ignoring unknown settings in CONFIG_FILE")
+	}
 
-	// Create reconciler
-	rec := reconciler.NewReconciler(dockerClient, tailscaleClient, reconcileInterval)
+	if *explain != "" {
+		explainContainer(cfg, *explain)
+		return
+	}
 
 	// Setup signal handling
 	ctx, cancel := context.WithCancel(context.Background())
@@ -94,63 +74,213 @@ func main() {
 		cancel()
 	}()
 
-	// Run reconciler
-	log.Info().Msg("Starting reconciliation loop")
-	if err := rec.Run(ctx); err != nil && err != context.Canceled {
-		log.Fatal().Err(err).Msg("Reconciler failed")
+	if err := docktail.Run(ctx, cfg); err != nil {
+		log.Fatal().Err(err).Msg("DockTail failed")
+	}
+
+	log.Info().Msg("DockTail stopped gracefully")
+}
+
+// configFromEnv builds the DockTail configuration from environment variables,
+// falling back to CONFIG_FILE values
+func configFromEnv() docktail.Config {
+	defaults := docktail.DefaultConfig()
+
+	cfg := docktail.Config{
+		ReconcileInterval:      getEnvDuration("RECONCILE_INTERVAL", defaults.ReconcileInterval),
+		ReconcileJitter:        getEnvDuration("RECONCILE_JITTER", defaults.ReconcileJitter),
+		HealthAddr:             getEnv("HEALTH_ADDR", defaults.HealthAddr),
+		PprofAddr:              getEnv("PPROF_ADDR", defaults.PprofAddr),
+		KeepServicesOnShutdown: getEnvBool("KEEP_SERVICES_ON_SHUTDOWN", defaults.KeepServicesOnShutdown),
+		RunOnce:                getEnvBool("RUN_ONCE", defaults.RunOnce),
+		ShutdownTimeout:        getEnvDuration("SHUTDOWN_TIMEOUT", defaults.ShutdownTimeout),
+		WaitReadyTimeout:       getEnvDuration("WAIT_READY_TIMEOUT", defaults.WaitReadyTimeout),
+		IPRetryCount:           getEnvInt("IP_RETRY_COUNT", defaults.IPRetryCount),
+		IPRetryDelay:           getEnvDuration("IP_RETRY_DELAY", defaults.IPRetryDelay),
+		StageSelector:          getEnv("STAGE_SELECTOR", defaults.StageSelector),
+		HealthCheckInterval:    getEnvDuration("HEALTH_CHECK_INTERVAL", defaults.HealthCheckInterval),
+		RemoveUnhealthy:        getEnvBool("REMOVE_UNHEALTHY", defaults.RemoveUnhealthy),
+		MaxServices:            getEnvInt("MAX_SERVICES", defaults.MaxServices),
+		ServiceStabilizeDelay:  getEnvDuration("SERVICE_STABILIZE_DELAY", defaults.ServiceStabilizeDelay),
+		RemovalGrace:           getEnvDuration("REMOVAL_GRACE", defaults.RemovalGrace),
+		MDNSAdvertise:          getEnvBool("MDNS_ADVERTISE", defaults.MDNSAdvertise),
+		DefaultTargetProtocol:  getEnv("DEFAULT_TARGET_PROTOCOL", defaults.DefaultTargetProtocol),
+		DefaultHTTPSInsecure:   getEnvBool("DEFAULT_HTTPS_INSECURE", defaults.DefaultHTTPSInsecure),
+		WebhookURL:             getEnv("WEBHOOK_URL", defaults.WebhookURL),
+		InventoryFile:          getEnv("INVENTORY_FILE", defaults.InventoryFile),
+		ControlToken:           getEnv("CONTROL_TOKEN", defaults.ControlToken),
+		ConfigSource:           getEnv("CONFIG_SOURCE", defaults.ConfigSource),
+		PublishedHost:          getEnv("PUBLISHED_HOST", defaults.PublishedHost),
+		PublishedVia:           getEnv("PUBLISHED_VIA", defaults.PublishedVia),
+		PublishedGateway:       getEnv("PUBLISHED_GATEWAY", defaults.PublishedGateway),
+		ContainerRuntime:       getEnv("CONTAINER_RUNTIME", defaults.ContainerRuntime),
+		DockerSocket:           getEnv("DOCKER_SOCKET", defaults.DockerSocket),
+		DockerContext:          getEnv("DOCKER_CONTEXT", defaults.DockerContext),
+		StrictLabels:           getEnvBool("STRICT_LABELS", defaults.StrictLabels),
+		FailOnParseError:       getEnvBool("FAIL_ON_PARSE_ERROR", defaults.FailOnParseError),
+		RequireTags:            getEnvBool("REQUIRE_TAGS", defaults.RequireTags),
+		SkipReachability:       getEnvBool("SKIP_REACHABILITY", defaults.SkipReachability),
+		AutoServiceName:        getEnvBool("AUTO_SERVICE_NAME", defaults.AutoServiceName),
+
+		// Control Plane Configuration
+		TailscaleSocket:            getEnv("TAILSCALE_SOCKET", defaults.TailscaleSocket),
+		TailscaleAPIKey:            getEnv("TAILSCALE_API_KEY", defaults.TailscaleAPIKey),
+		TailscaleOAuthClientID:     getEnv("TAILSCALE_OAUTH_CLIENT_ID", defaults.TailscaleOAuthClientID),
+		TailscaleOAuthClientSecret: getEnv("TAILSCALE_OAUTH_CLIENT_SECRET", defaults.TailscaleOAuthClientSecret),
+		TailscaleTailnet:           getEnv("TAILSCALE_TAILNET", defaults.TailscaleTailnet),
+		NodeNameOverride:           getEnv("NODE_NAME_OVERRIDE", defaults.NodeNameOverride),
+		TailscaleRetryMax:          getEnvInt("TAILSCALE_RETRY_MAX", defaults.TailscaleRetryMax),
+		TraceTailscale:             getEnvBool("TRACE_TAILSCALE", defaults.TraceTailscale),
+		TailscaleAPIRate:           getEnvFloat("TS_API_RATE", defaults.TailscaleAPIRate),
+		ACLSync:                    getEnvBool("ACL_SYNC", defaults.ACLSync),
+		AdoptOrphans:               getEnvBool("ADOPT_ORPHANS", defaults.AdoptOrphans),
+		AllowServiceOverwrite:      getEnvBool("ALLOW_SERVICE_OVERWRITE", defaults.AllowServiceOverwrite),
+		DefaultTags:                defaults.DefaultTags,
+		TagsMerge:                  getEnvBool("TAGS_MERGE", defaults.TagsMerge),
+		DefaultTailnet:             getEnv("DEFAULT_TAILNET", defaults.DefaultTailnet),
 	}
 
-	// Graceful shutdown: clean up all Tailscale services
-	log.Info().Msg("Reconciler stopped, cleaning up Tailscale services")
+	// Parse default tags
+	if defaultTagsStr := lookupSetting("DEFAULT_SERVICE_TAGS"); defaultTagsStr != "" {
+		cfg.DefaultTags = splitList(defaultTagsStr)
+	}
 
-	// Use a new context with timeout for cleanup (don't use cancelled context)
-	cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cleanupCancel()
+	cfg.IncludeContainers = splitList(lookupSetting("INCLUDE_CONTAINERS"))
+	cfg.ExcludeContainers = splitList(lookupSetting("EXCLUDE_CONTAINERS"))
+	cfg.NetworkPriority = splitList(lookupSetting("NETWORK_PRIORITY"))
+	if events := splitList(lookupSetting("DOCKER_EVENTS")); len(events) > 0 {
+		cfg.DockerEvents = events
+	}
+	// EVENT_SCOPES entries (action:scope) override the default scope of their action only
+	cfg.EventScopes = defaults.EventScopes
+	for _, entry := range splitList(lookupSetting("EVENT_SCOPES")) {
+		action, scope, _ := strings.Cut(entry, ":")
+		cfg.EventScopes[strings.TrimSpace(action)] = strings.TrimSpace(scope)
+	}
 
-	if err := tailscaleClient.CleanupAllServices(cleanupCtx); err != nil {
-		log.Error().Err(err).Msg("Failed to clean up all services during shutdown")
-	} else {
-		log.Info().Msg("Successfully cleaned up all services")
+	// Additional tailnets, each configured by its own TAILNET_<NAME>_* settings
+	for _, name := range splitList(lookupSetting("TAILNETS")) {
+		prefix := docktail.TailnetEnvPrefix(name)
+		cfg.Tailnets = append(cfg.Tailnets, docktail.TailnetConfig{
+			Name:              name,
+			Socket:            getEnv(prefix+"SOCKET", ""),
+			APIKey:            getEnv(prefix+"API_KEY", ""),
+			OAuthClientID:     getEnv(prefix+"OAUTH_CLIENT_ID", ""),
+			OAuthClientSecret: getEnv(prefix+"OAUTH_CLIENT_SECRET", ""),
+			Tailnet:           getEnv(prefix+"TAILNET", defaults.TailscaleTailnet),
+		})
 	}
 
-	log.Info().Msg("DockTail stopped gracefully")
+	return cfg
 }
 
-func setupLogging() {
-	// Configure zerolog
-	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
-	log.Logger = log.Output(zerolog.ConsoleWriter{
-		Out:        os.Stdout,
-		TimeFormat: time.RFC3339,
-	})
+// splitList splits a comma-separated list, trimming spaces and dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if trimmed := strings.TrimSpace(item); trimmed != "" {
+			items = append(items, trimmed)
+		}
+	}
+	return items
+}
 
-	// Set log level from environment
-	logLevel := getEnv("LOG_LEVEL", "info")
-	switch logLevel {
+// explainContainer prints the resolved plan for one container and exits non-zero if it can't be served
+func explainContainer(cfg docktail.Config, container string) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	explanation, err := docktail.Explain(ctx, cfg, container)
+	if err != nil {
+		log.Fatal().Err(err).Str("container", container).Msg("Container would not be served")
+	}
+
+	out, err := json.MarshalIndent(explanation, "", "  ")
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to encode explanation")
+	}
+	fmt.Println(string(out))
+}
+
+// logSubsystems are the packages with their own logger, keyed by LOG_LEVEL_<name>
+// Each one logs through the global logger until its SetLogger is called, so embedders of
+// docktail.Run get their format and output; setupLogging hands each one a copy at its own level
+var logSubsystems = []struct {
+	name      string
+	setLogger func(zerolog.Logger)
+}{
+	{"DOCKER", docker.SetLogger},
+	{"TAILSCALE", tailscale.SetLogger},
+	{"RECONCILER", reconciler.SetLogger},
+}
+
+// parseLogLevel converts a LOG_LEVEL value (debug, info, warn, error); anything else means info
+func parseLogLevel(value string) zerolog.Level {
+	switch value {
 	case "debug":
-		zerolog.SetGlobalLevel(zerolog.DebugLevel)
-	case "info":
-		zerolog.SetGlobalLevel(zerolog.InfoLevel)
+		return zerolog.DebugLevel
 	case "warn":
-		zerolog.SetGlobalLevel(zerolog.WarnLevel)
+		return zerolog.WarnLevel
 	case "error":
-		zerolog.SetGlobalLevel(zerolog.ErrorLevel)
+		return zerolog.ErrorLevel
 	default:
-		zerolog.SetGlobalLevel(zerolog.InfoLevel)
+		return zerolog.InfoLevel
 	}
+}
 
-	log.Debug().Str("level", logLevel).Msg("Log level set")
+func setupLogging(out io.Writer) {
+	// Configure zerolog
+	// console: human-readable colored output (default)
+	// json: raw JSON lines with RFC3339 timestamps for log aggregation (Loki, ELK, ...)
+	// Both formats go through the dedup writer, which sees zerolog's JSON before console formatting
+	logFormat := getEnv("LOG_FORMAT", "console")
+	dedup := newDedupWriter(out)
+	switch logFormat {
+	case "json":
+		zerolog.TimeFieldFormat = time.RFC3339
+		log.Logger = zerolog.New(dedup).With().Timestamp().Logger()
+	default:
+		zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
+		dedup.out = zerolog.ConsoleWriter{
+			Out:        out,
+			TimeFormat: time.RFC3339,
+		}
+		log.Logger = log.Output(dedup)
+	}
+
+	// Set log levels from environment; LOG_LEVEL_<SUBSYSTEM> overrides LOG_LEVEL for one package
+	logLevel := getEnv("LOG_LEVEL", "info")
+	level := parseLogLevel(logLevel)
+	base := log.Logger
+	minLevel := level
+	for _, sub := range logSubsystems {
+		subLevel := parseLogLevel(getEnv("LOG_LEVEL_"+sub.name, logLevel))
+		sub.setLogger(base.Level(subLevel))
+		minLevel = min(minLevel, subLevel)
+	}
+	// The global level filters every logger, so it must be as verbose as the most verbose subsystem
+	zerolog.SetGlobalLevel(minLevel)
+	log.Logger = base.Level(level)
+
+	if logFormat != "console" && logFormat != "json" {
+		log.Warn().Str("format", logFormat).Msg("Unknown LOG_FORMAT, using console")
+	}
+
+	// Repeated warnings, e.g. the same container's label problem every loop, are logged once per window
+	dedup.start(getEnvDuration("LOG_DEDUP_WINDOW", 5*time.Minute))
+
+	log.Debug().Str("level", logLevel).Str("format", logFormat).Msg("Log level set")
 }
 
 func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
+	if value := lookupSetting(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
 
 func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
-	if value := os.Getenv(key); value != "" {
+	if value := lookupSetting(key); value != "" {
 		if duration, err := time.ParseDuration(value); err == nil {
 			return duration
 		}
@@ -162,3 +292,45 @@ func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := lookupSetting(key); value != "" {
+		if i, err := strconv.Atoi(value); err == nil {
+			return i
+		}
+		log.Warn().
+			Str("key", key).
+			Str("value", value).
+			Int("default", defaultValue).
+			Msg("Failed to parse integer, using default")
+	}
+	return defaultValue
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := lookupSetting(key); value != "" {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+		log.Warn().
+			Str("key", key).
+			Str("value", value).
+			Float64("default", defaultValue).
+			Msg("Failed to parse number, using default")
+	}
+	return defaultValue
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := lookupSetting(key); value != "" {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+		log.Warn().
+			Str("key", key).
+			Str("value", value).
+			Bool("default", defaultValue).
+			Msg("Failed to parse boolean, using default")
+	}
+	return defaultValue
+}
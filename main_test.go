@@ -0,0 +1,44 @@
+package main
+
+import (
+	"io"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+func TestSetupLoggingSubsystemLevels(t *testing.T) {
+	defer func(logger zerolog.Logger, level zerolog.Level) {
+		log.Logger = logger
+		zerolog.SetGlobalLevel(level)
+	}(log.Logger, zerolog.GlobalLevel())
+
+	tests := []struct {
+		name       string
+		global     string
+		tailscale  string
+		wantGlobal zerolog.Level
+		wantRoot   zerolog.Level
+	}{
+		{"global only", "warn", "", zerolog.WarnLevel, zerolog.WarnLevel},
+		{"more verbose subsystem", "info", "debug", zerolog.DebugLevel, zerolog.InfoLevel},
+		{"quieter subsystem", "debug", "error", zerolog.DebugLevel, zerolog.DebugLevel},
+		{"unknown level", "loud", "", zerolog.InfoLevel, zerolog.InfoLevel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("LOG_LEVEL", tt.global)
+			t.Setenv("LOG_LEVEL_TAILSCALE", tt.tailscale)
+			setupLogging(io.Discard)
+
+			if got := zerolog.GlobalLevel(); got != tt.wantGlobal {
+				t.Errorf("global level = %s, want %s", got, tt.wantGlobal)
+			}
+			if got := log.Logger.GetLevel(); got != tt.wantRoot {
+				t.Errorf("root logger level = %s, want %s", got, tt.wantRoot)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHistogramVec(t *testing.T) {
+	h := &HistogramVec{
+		name:    "test_seconds",
+		help:    "Test histogram",
+		label:   "service",
+		buckets: []float64{1, 5},
+		series:  make(map[string]*histogram),
+	}
+	h.Observe("web", 0.5)
+	h.Observe("web", 3)
+	h.Observe("web", 10)
+	h.Observe(`a"b`, 1)
+
+	var out strings.Builder
+	h.write(&out)
+
+	want := `# HELP test_seconds Test histogram
+# TYPE test_seconds histogram
+test_seconds_bucket{service="a\"b",le="1"} 1
+test_seconds_bucket{service="a\"b",le="5"} 1
+test_seconds_bucket{service="a\"b",le="+Inf"} 1
+test_seconds_sum{service="a\"b"} 1
+test_seconds_count{service="a\"b"} 1
+test_seconds_bucket{service="web",le="1"} 1
+test_seconds_bucket{service="web",le="5"} 2
+test_seconds_bucket{service="web",le="+Inf"} 3
+test_seconds_sum{service="web"} 13.5
+test_seconds_count{service="web"} 3
+`
+	if out.String() != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestHandlerServesRegistered(t *testing.T) {
+	h := NewHistogramVec("handler_test_seconds", "Registered histogram", "service", []float64{1})
+	h.Observe("web", 2)
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if !strings.Contains(rec.Body.String(), `handler_test_seconds_count{service="web"} 1`) {
+		t.Errorf("registered histogram missing from output:\n%s", rec.Body.String())
+	}
+}
+
+func TestGauge(t *testing.T) {
+	g := &Gauge{name: "test_age_seconds", help: "Test gauge"}
+	g.Set(90.5)
+
+	var out strings.Builder
+	g.write(&out)
+
+	want := `# HELP test_age_seconds Test gauge
+# TYPE test_age_seconds gauge
+test_age_seconds 90.5
+`
+	if out.String() != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
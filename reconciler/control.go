@@ -0,0 +1,82 @@
+package reconciler
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrPaused is returned by ReconcileNow while reconciliation is paused
+var ErrPaused = errors.New("reconciliation is paused")
+
+// ErrStopped is returned by ReconcileNow once the reconciler is shutting down
+var ErrStopped = errors.New("reconciler is stopped")
+
+// ReconcileSummary describes the outcome of one reconciliation, as logged in "Reconciliation summary"
+type ReconcileSummary struct {
+	Enabled  int
+	Created  int
+	Updated  int
+	Removed  int
+	Errored  int
+	Duration time.Duration
+}
+
+// reconcileOutcome is handed to ReconcileNow callers once their reconciliation finishes
+type reconcileOutcome struct {
+	summary ReconcileSummary
+	err     error
+}
+
+// ReconcileNow asks the loop for an immediate reconciliation and waits for its outcome
+// A reconciliation already in progress may have missed the caller's changes, so the caller waits
+// for the next one; concurrent callers are coalesced into that same run
+func (r *Reconciler) ReconcileNow(ctx context.Context) (ReconcileSummary, error) {
+	done := make(chan reconcileOutcome, 1)
+	r.waitersMu.Lock()
+	r.waiters = append(r.waiters, done)
+	r.waitersMu.Unlock()
+
+	r.Trigger()
+
+	select {
+	case <-ctx.Done():
+		return ReconcileSummary{}, ctx.Err()
+	case outcome := <-done:
+		return outcome.summary, outcome.err
+	case <-r.done:
+		return ReconcileSummary{}, ErrStopped
+	}
+}
+
+// Done returns a channel that is closed once Run has returned and its health checker has exited,
+// i.e. once no reconciliation can run anymore
+func (r *Reconciler) Done() <-chan struct{} {
+	return r.done
+}
+
+// Wait blocks until the reconciler has stopped (see Done) or ctx is done
+func (r *Reconciler) Wait(ctx context.Context) error {
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// takeWaiters returns the ReconcileNow callers waiting for the reconciliation about to start
+func (r *Reconciler) takeWaiters() []chan reconcileOutcome {
+	r.waitersMu.Lock()
+	defer r.waitersMu.Unlock()
+	waiters := r.waiters
+	r.waiters = nil
+	return waiters
+}
+
+// notifyWaiters hands the outcome of a reconciliation to the callers that were waiting for it
+func notifyWaiters(waiters []chan reconcileOutcome, outcome reconcileOutcome) {
+	for _, done := range waiters {
+		done <- outcome
+	}
+}
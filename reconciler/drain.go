@@ -0,0 +1,79 @@
+package reconciler
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/marvinvr/docktail/docker"
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+// drainRemoved keeps the applied services of containers that stopped less than grace ago, so
+// in-flight requests can complete before the serve config goes (REMOVAL_GRACE)
+// removals tracks when each service's container was first seen gone and is updated in place;
+// a removal is cancelled when the container, or a replacement serving the same service, is back
+// Returns the services to keep and how long until the next of them expires (0 if none)
+func drainRemoved(containers []*apptypes.ContainerService, parseErrors []docker.ParseError, applied map[string]*apptypes.ContainerService, removals map[string]time.Time, grace time.Duration, now time.Time) ([]*apptypes.ContainerService, time.Duration) {
+	present := make(map[string]bool, len(containers)+len(parseErrors))
+	served := make(map[string]bool, len(containers))
+	for _, svc := range containers {
+		present[svc.ContainerID] = true
+		served[fmt.Sprintf("svc:%s:%s", svc.ServiceName, svc.Port)] = true
+	}
+	for _, pe := range parseErrors {
+		present[pe.ContainerID] = true
+	}
+
+	for key := range removals {
+		if svc, ok := applied[key]; !ok || present[svc.ContainerID] || served[key] {
+			if ok {
+				log.Info().
+					Str("container", svc.ContainerName).
+					Str("service", svc.ServiceName).
+					Msg("Container is back within REMOVAL_GRACE, cancelling service removal")
+			}
+			delete(removals, key)
+		}
+	}
+	if grace <= 0 {
+		return nil, 0
+	}
+
+	keys := make([]string, 0, len(applied))
+	for key := range applied {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var kept []*apptypes.ContainerService
+	var nextExpiry time.Duration
+	for _, key := range keys {
+		svc := applied[key]
+		if present[svc.ContainerID] || served[key] {
+			continue
+		}
+
+		stoppedAt, ok := removals[key]
+		if !ok {
+			stoppedAt = now
+			removals[key] = now
+			log.Info().
+				Str("container", svc.ContainerName).
+				Str("service", svc.ServiceName).
+				Dur("grace", grace).
+				Msg("Container stopped, keeping its service for REMOVAL_GRACE")
+		}
+		remaining := stoppedAt.Add(grace).Sub(now)
+		if remaining <= 0 {
+			delete(removals, key)
+			continue
+		}
+
+		kept = append(kept, svc)
+		if nextExpiry == 0 || remaining < nextExpiry {
+			nextExpiry = remaining
+		}
+	}
+	return kept, nextExpiry
+}
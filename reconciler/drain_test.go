@@ -0,0 +1,50 @@
+package reconciler
+
+import (
+	"testing"
+	"time"
+
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+func TestDrainRemoved(t *testing.T) {
+	web := &apptypes.ContainerService{ContainerID: "web", ContainerName: "web", ServiceName: "web", Port: "443"}
+	api := &apptypes.ContainerService{ContainerID: "api", ContainerName: "api", ServiceName: "api", Port: "80"}
+	applied := map[string]*apptypes.ContainerService{"svc:web:443": web, "svc:api:80": api}
+	removals := make(map[string]time.Time)
+	grace := 10 * time.Second
+	start := time.Now()
+
+	// api stopped: its service is kept for the grace period
+	kept, next := drainRemoved([]*apptypes.ContainerService{web}, nil, applied, removals, grace, start)
+	if len(kept) != 1 || kept[0] != api || next != grace {
+		t.Fatalf("expected api kept for %s, got %v (next %s)", grace, kept, next)
+	}
+
+	// Still within the window, the original stop time counts
+	kept, next = drainRemoved([]*apptypes.ContainerService{web}, nil, applied, removals, grace, start.Add(4*time.Second))
+	if len(kept) != 1 || next != 6*time.Second {
+		t.Errorf("expected api kept for 6s more, got %v (next %s)", kept, next)
+	}
+
+	// The window ends
+	kept, _ = drainRemoved([]*apptypes.ContainerService{web}, nil, applied, removals, grace, start.Add(grace))
+	if len(kept) != 0 || len(removals) != 0 {
+		t.Errorf("expected api released after the grace period, got %v, removals %v", kept, removals)
+	}
+
+	// A replacement container serving the same service cancels the removal
+	removals = make(map[string]time.Time)
+	_, _ = drainRemoved([]*apptypes.ContainerService{web}, nil, applied, removals, grace, start)
+	replacement := &apptypes.ContainerService{ContainerID: "api2", ContainerName: "api", ServiceName: "api", Port: "80"}
+	kept, _ = drainRemoved([]*apptypes.ContainerService{web, replacement}, nil, applied, removals, grace, start.Add(time.Second))
+	if len(kept) != 0 || len(removals) != 0 {
+		t.Errorf("expected the replacement to cancel the removal, got %v, removals %v", kept, removals)
+	}
+
+	// Disabled
+	kept, next = drainRemoved(nil, nil, applied, make(map[string]time.Time), 0, start)
+	if len(kept) != 0 || next != 0 {
+		t.Errorf("expected nothing kept without a grace period, got %v", kept)
+	}
+}
@@ -0,0 +1,149 @@
+package reconciler
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+
+	"github.com/marvinvr/docktail/docker"
+	"github.com/marvinvr/docktail/tailscale"
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+// Event scopes say how much of the container set a Docker event reconciles
+const (
+	// EventScopeFull lists every enabled container and reconciles them all
+	EventScopeFull = "full"
+	// EventScopeTargeted re-inspects only the event's container and updates just its service
+	EventScopeTargeted = "targeted"
+)
+
+// DefaultEventScopes are the event scopes used when EVENT_SCOPES is unset
+// update and rename don't restart the container, so re-inspecting it is enough; every
+// other event lists all containers. A Docker event stream reconnect always resyncs fully
+var DefaultEventScopes = map[string]string{
+	string(events.ActionUpdate): EventScopeTargeted,
+	string(events.ActionRename): EventScopeTargeted,
+}
+
+// handleEvent reacts to a Docker container event
+// Events with a targeted scope re-inspect only that container and reconcile immediately if its
+// service now differs from the last known one; every other event triggers a full reconciliation
+func (r *Reconciler) handleEvent(ctx context.Context, event events.Message) {
+	if r.eventScopes[string(event.Action)] != EventScopeTargeted {
+		r.reconcileAndReport(ctx, "Event-triggered")
+		return
+	}
+
+	current, parseErr, err := r.dockerClient.InspectEnabledContainer(ctx, event.Actor.ID)
+	if err != nil {
+		log.Warn().
+			Err(err).
+			Str("container", event.Actor.ID[:12]).
+			Msg("Failed to re-inspect container, running full reconciliation")
+		r.reconcileAndReport(ctx, "Event-triggered")
+		return
+	}
+
+	previous, previousErr := r.listedFor(event.Actor.ID)
+	if parseErr == nil && previousErr == nil && !serviceChanged(previous, current) {
+		log.Debug().
+			Str("action", string(event.Action)).
+			Str("container", event.Actor.ID[:12]).
+			Msg("Container change does not affect its service, nothing to re-apply")
+		return
+	}
+
+	logEvent := log.Info().
+		Str("action", string(event.Action)).
+		Str("container", event.Actor.ID[:12])
+	if previous != nil {
+		logEvent = logEvent.Str("old_destination", tailscale.BuildDestination(previous))
+	}
+	if current != nil {
+		logEvent = logEvent.Str("new_destination", tailscale.BuildDestination(current))
+	}
+	logEvent.Msg("Container service changed, re-applying serve config")
+
+	r.runAndReport(ctx, "Targeted", func(ctx context.Context) error {
+		return r.reconcileContainer(ctx, event.Actor.ID, current, parseErr)
+	})
+}
+
+// reconcileContainer reconciles after one container changed, without listing every container
+// The container's re-inspected service (or parse error) replaces its entry in the last listing
+// Derived service names may need disambiguating against every container, and nothing has been
+// listed before the first full reconciliation, so both fall back to a full reconciliation
+func (r *Reconciler) reconcileContainer(ctx context.Context, containerID string, current *apptypes.ContainerService, parseErr *docker.ParseError) error {
+	if (r.listed == nil && r.listedErrors == nil) || (current != nil && current.AutoName) {
+		return r.Reconcile(ctx)
+	}
+
+	start := time.Now()
+	log.Info().
+		Str("container", containerID[:12]).
+		Msg("Starting targeted reconciliation")
+	r.lastSummary = ReconcileSummary{}
+
+	r.listed, r.listedErrors = replaceListed(r.listed, r.listedErrors, containerID, current, parseErr)
+	return r.reconcileContainers(ctx, start, r.listed, r.listedErrors)
+}
+
+// replaceListed returns the listing with one container's entry replaced by its re-inspected
+// service or parse error; a nil service and error drop the container
+func replaceListed(containers []*apptypes.ContainerService, parseErrors []docker.ParseError, containerID string, current *apptypes.ContainerService, parseErr *docker.ParseError) ([]*apptypes.ContainerService, []docker.ParseError) {
+	keptContainers := make([]*apptypes.ContainerService, 0, len(containers)+1)
+	for _, svc := range containers {
+		if !sameContainer(containerID, svc.ContainerID) {
+			keptContainers = append(keptContainers, svc)
+		}
+	}
+	keptErrors := make([]docker.ParseError, 0, len(parseErrors)+1)
+	for _, pe := range parseErrors {
+		if !sameContainer(containerID, pe.ContainerID) {
+			keptErrors = append(keptErrors, pe)
+		}
+	}
+
+	if current != nil {
+		keptContainers = append(keptContainers, current)
+	}
+	if parseErr != nil {
+		keptErrors = append(keptErrors, *parseErr)
+	}
+	return keptContainers, keptErrors
+}
+
+// listedFor returns a container's service or parse error from the last listing, if any
+func (r *Reconciler) listedFor(containerID string) (*apptypes.ContainerService, *docker.ParseError) {
+	for _, svc := range r.listed {
+		if sameContainer(containerID, svc.ContainerID) {
+			return svc, nil
+		}
+	}
+	for i := range r.listedErrors {
+		if sameContainer(containerID, r.listedErrors[i].ContainerID) {
+			return nil, &r.listedErrors[i]
+		}
+	}
+	return nil, nil
+}
+
+// sameContainer reports whether a full container ID from an event matches a short stored ID
+func sameContainer(containerID, shortID string) bool {
+	return shortID != "" && strings.HasPrefix(containerID, shortID)
+}
+
+// serviceChanged reports whether a container's service differs from the applied one
+// in a way that needs new serve config: appearing, disappearing, or a new name, port or destination
+func serviceChanged(previous, current *apptypes.ContainerService) bool {
+	if previous == nil || current == nil {
+		return previous != current
+	}
+	return previous.ServiceName != current.ServiceName ||
+		previous.Port != current.Port ||
+		previous.ServiceProtocol != current.ServiceProtocol ||
+		tailscale.BuildDestination(previous) != tailscale.BuildDestination(current)
+}
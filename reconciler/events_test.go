@@ -0,0 +1,90 @@
+package reconciler
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/marvinvr/docktail/docker"
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+func TestServiceChanged(t *testing.T) {
+	web := &apptypes.ContainerService{ServiceName: "web", Port: "443", ServiceProtocol: "https", Protocol: "http", IPAddress: "172.17.0.2", TargetPort: "80"}
+	withIP := func(ip string) *apptypes.ContainerService {
+		svc := *web
+		svc.IPAddress = ip
+		return &svc
+	}
+	withPort := func(port string) *apptypes.ContainerService {
+		svc := *web
+		svc.TargetPort = port
+		return &svc
+	}
+
+	tests := []struct {
+		name     string
+		previous *apptypes.ContainerService
+		current  *apptypes.ContainerService
+		want     bool
+	}{
+		{"unchanged", web, withIP("172.17.0.2"), false},
+		{"new ip", web, withIP("172.17.0.9"), true},
+		{"new port", web, withPort("8080"), true},
+		{"newly enabled", nil, web, true},
+		{"no longer enabled", web, nil, true},
+		{"never managed", nil, nil, false},
+	}
+
+	for _, tt := range tests {
+		if got := serviceChanged(tt.previous, tt.current); got != tt.want {
+			t.Errorf("%s: serviceChanged() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReplaceListed(t *testing.T) {
+	web := &apptypes.ContainerService{ContainerID: "aaaaaaaaaaaa", ServiceName: "web"}
+	api := &apptypes.ContainerService{ContainerID: "bbbbbbbbbbbb", ServiceName: "api"}
+	broken := docker.ParseError{ContainerID: "cccccccccccc", ContainerName: "broken", Err: errors.New("missing label")}
+	listed := []*apptypes.ContainerService{web, api}
+	listedErrors := []docker.ParseError{broken}
+
+	movedAPI := &apptypes.ContainerService{ContainerID: "bbbbbbbbbbbb", ServiceName: "api", IPAddress: "172.17.0.9"}
+	fixed := &apptypes.ContainerService{ContainerID: "cccccccccccc", ServiceName: "broken"}
+	brokenWeb := &docker.ParseError{ContainerID: "aaaaaaaaaaaa", ContainerName: "web", Err: errors.New("invalid port")}
+
+	tests := []struct {
+		name        string
+		containerID string
+		current     *apptypes.ContainerService
+		parseErr    *docker.ParseError
+		wantIDs     []string
+		wantErrIDs  []string
+	}{
+		{"service changed", "bbbbbbbbbbbb0123", movedAPI, nil, []string{"aaaaaaaaaaaa", "bbbbbbbbbbbb"}, []string{"cccccccccccc"}},
+		{"container stopped", "aaaaaaaaaaaa0123", nil, nil, []string{"bbbbbbbbbbbb"}, []string{"cccccccccccc"}},
+		{"labels fixed", "cccccccccccc0123", fixed, nil, []string{"aaaaaaaaaaaa", "bbbbbbbbbbbb", "cccccccccccc"}, nil},
+		{"labels broken", "aaaaaaaaaaaa0123", nil, brokenWeb, []string{"bbbbbbbbbbbb"}, []string{"cccccccccccc", "aaaaaaaaaaaa"}},
+		{"new container", "dddddddddddd0123", &apptypes.ContainerService{ContainerID: "dddddddddddd"}, nil, []string{"aaaaaaaaaaaa", "bbbbbbbbbbbb", "dddddddddddd"}, []string{"cccccccccccc"}},
+	}
+
+	for _, tt := range tests {
+		containers, parseErrors := replaceListed(listed, listedErrors, tt.containerID, tt.current, tt.parseErr)
+
+		var ids, errIDs []string
+		for _, svc := range containers {
+			ids = append(ids, svc.ContainerID)
+		}
+		for _, pe := range parseErrors {
+			errIDs = append(errIDs, pe.ContainerID)
+		}
+		if !slices.Equal(ids, tt.wantIDs) || !slices.Equal(errIDs, tt.wantErrIDs) {
+			t.Errorf("%s: got containers %v errors %v, want %v %v", tt.name, ids, errIDs, tt.wantIDs, tt.wantErrIDs)
+		}
+	}
+
+	if len(listed) != 2 || listed[1] != api {
+		t.Error("replaceListed modified the previous listing")
+	}
+}
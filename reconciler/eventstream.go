@@ -0,0 +1,58 @@
+package reconciler
+
+import (
+	"context"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+)
+
+// maxEventStreamWait caps the backoff between reconnection attempts to the Docker event stream
+const maxEventStreamWait = 60 * time.Second
+
+// eventStreamDelay returns the backoff before the given reconnection attempt (1-based)
+func eventStreamDelay(attempt int) time.Duration {
+	if attempt > 7 {
+		return maxEventStreamWait
+	}
+	return min(time.Second<<(attempt-1), maxEventStreamWait)
+}
+
+// reconnectEvents re-subscribes to the Docker event stream after it failed with cause
+// While the daemon is down the reconciler reports not ready and stops reconciling, since every
+// pass would fail; attempts back off exponentially so a restarting daemon isn't hammered
+// Once the stream is back, a full reconciliation catches up on events missed in the meantime
+// Returns nil channels only when ctx is cancelled
+func (r *Reconciler) reconnectEvents(ctx context.Context, cause error) (<-chan events.Message, <-chan error) {
+	r.eventsDisconnected.Store(true)
+	err := cause
+
+	for {
+		r.eventStreamAttempts++
+		delay := eventStreamDelay(r.eventStreamAttempts)
+		log.Warn().
+			Err(err).
+			Int("attempt", r.eventStreamAttempts).
+			Dur("retry_in", delay).
+			Msg("Docker event stream disconnected, reconnecting")
+
+		select {
+		case <-ctx.Done():
+			return nil, nil
+		case <-time.After(delay):
+		}
+
+		if err = r.dockerClient.Ping(ctx); err == nil {
+			break
+		}
+	}
+
+	eventsChan, errChan := r.dockerClient.WatchEvents(ctx)
+	r.eventsDisconnected.Store(false)
+	log.Warn().
+		Int("attempts", r.eventStreamAttempts).
+		Msg("Docker event stream reconnected, running full resync")
+
+	r.reconcileAndReport(ctx, "Resync")
+	return eventsChan, errChan
+}
@@ -0,0 +1,41 @@
+package reconciler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEventStreamDelay(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{5, 16 * time.Second},
+		{6, 32 * time.Second},
+		{7, maxEventStreamWait},
+		{40, maxEventStreamWait},
+	}
+
+	for _, tt := range tests {
+		if got := eventStreamDelay(tt.attempt); got != tt.want {
+			t.Errorf("eventStreamDelay(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestReadyWhileEventsDisconnected(t *testing.T) {
+	r := NewReconciler(nil, nil, Config{Interval: time.Minute})
+	r.ready.Store(true)
+
+	r.eventsDisconnected.Store(true)
+	if r.Ready() {
+		t.Errorf("Ready() = true while the Docker event stream is disconnected")
+	}
+
+	r.eventsDisconnected.Store(false)
+	if !r.Ready() {
+		t.Errorf("Ready() = false after the Docker event stream reconnected")
+	}
+}
@@ -0,0 +1,75 @@
+package reconciler
+
+import (
+	"fmt"
+	"sort"
+
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+// selectGroupBackends collapses containers sharing a docktail.service.group into a single service
+// tailscale serve proxies each service port to exactly one destination, so one member is chosen as
+// the active backend (healthy first, then by container name) and the rest stand by for failover.
+// Members whose service settings disagree with the active one are returned as errors, keyed by container ID.
+func selectGroupBackends(services []*apptypes.ContainerService, healthy func(*apptypes.ContainerService) bool) ([]*apptypes.ContainerService, map[string]error) {
+	groups := make(map[string][]*apptypes.ContainerService)
+	var selected []*apptypes.ContainerService
+	for _, svc := range services {
+		if svc.Group == "" {
+			selected = append(selected, svc)
+			continue
+		}
+		groups[svc.Group] = append(groups[svc.Group], svc)
+	}
+
+	errs := make(map[string]error)
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		members := groups[name]
+		sort.SliceStable(members, func(i, j int) bool {
+			hi, hj := healthy(members[i]), healthy(members[j])
+			if hi != hj {
+				return hi
+			}
+			return members[i].ContainerName < members[j].ContainerName
+		})
+
+		active := members[0]
+		var standby []string
+		for _, member := range members[1:] {
+			if member.ServiceName != active.ServiceName || member.Port != active.Port || member.ServiceProtocol != active.ServiceProtocol {
+				errs[member.ContainerID] = fmt.Errorf(
+					"group '%s' member '%s' serves svc:%s on %s/%s, but the group serves svc:%s on %s/%s; all members must share the same service settings",
+					name, member.ContainerName, member.ServiceName, member.ServiceProtocol, member.Port,
+					active.ServiceName, active.ServiceProtocol, active.Port)
+				continue
+			}
+			standby = append(standby, member.ContainerName)
+		}
+
+		log.Debug().
+			Str("group", name).
+			Str("service", active.ServiceName).
+			Str("active", active.ContainerName).
+			Strs("standby", standby).
+			Msg("Selected active backend for service group")
+
+		selected = append(selected, active)
+	}
+
+	return selected, errs
+}
+
+// isHealthy reports whether a service's backend is not currently marked unhealthy
+func (r *Reconciler) isHealthy(svc *apptypes.ContainerService) bool {
+	r.statusMu.RLock()
+	defer r.statusMu.RUnlock()
+
+	st, ok := r.status[svc.ContainerID]
+	return !ok || st.Healthy || healthTarget(st.Service) != healthTarget(svc)
+}
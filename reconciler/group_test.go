@@ -0,0 +1,51 @@
+package reconciler
+
+import (
+	"testing"
+
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+func TestSelectGroupBackends(t *testing.T) {
+	web1 := &apptypes.ContainerService{ContainerID: "web1", ContainerName: "web-1", ServiceName: "web", Port: "443", ServiceProtocol: "https", Group: "web"}
+	web2 := &apptypes.ContainerService{ContainerID: "web2", ContainerName: "web-2", ServiceName: "web", Port: "443", ServiceProtocol: "https", Group: "web"}
+	web3 := &apptypes.ContainerService{ContainerID: "web3", ContainerName: "web-3", ServiceName: "other", Port: "443", ServiceProtocol: "https", Group: "web"}
+	db := &apptypes.ContainerService{ContainerID: "db", ContainerName: "db", ServiceName: "db", Port: "5432", ServiceProtocol: "tcp"}
+
+	allHealthy := func(*apptypes.ContainerService) bool { return true }
+
+	t.Run("first member by name is active", func(t *testing.T) {
+		selected, errs := selectGroupBackends([]*apptypes.ContainerService{web2, db, web1}, allHealthy)
+		if len(selected) != 2 || selected[0] != db || selected[1] != web1 {
+			t.Errorf("unexpected selection: %+v", selected)
+		}
+		if len(errs) != 0 {
+			t.Errorf("unexpected errors: %v", errs)
+		}
+	})
+
+	t.Run("unhealthy member fails over", func(t *testing.T) {
+		healthy := func(svc *apptypes.ContainerService) bool { return svc != web1 }
+		selected, _ := selectGroupBackends([]*apptypes.ContainerService{web1, web2}, healthy)
+		if len(selected) != 1 || selected[0] != web2 {
+			t.Errorf("expected web-2 to take over, got %+v", selected)
+		}
+	})
+
+	t.Run("dead member leaves the service to the rest", func(t *testing.T) {
+		selected, _ := selectGroupBackends([]*apptypes.ContainerService{web2}, allHealthy)
+		if len(selected) != 1 || selected[0] != web2 {
+			t.Errorf("expected web-2 to keep the service alive, got %+v", selected)
+		}
+	})
+
+	t.Run("mismatched member is rejected", func(t *testing.T) {
+		selected, errs := selectGroupBackends([]*apptypes.ContainerService{web1, web3}, allHealthy)
+		if len(selected) != 1 || selected[0] != web1 {
+			t.Errorf("unexpected selection: %+v", selected)
+		}
+		if errs["web3"] == nil {
+			t.Error("expected an error for the mismatched member")
+		}
+	})
+}
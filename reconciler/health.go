@@ -0,0 +1,172 @@
+package reconciler
+
+import (
+	"context"
+	"net"
+	"time"
+
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+// unhealthyThreshold is the number of consecutive failed probes before a backend is marked unhealthy
+const unhealthyThreshold = 3
+
+// runHealthChecks probes every active service backend on the health check interval
+func (r *Reconciler) runHealthChecks(ctx context.Context) {
+	log.Info().
+		Dur("interval", r.healthCheckInterval).
+		Bool("remove_unhealthy", r.removeUnhealthy).
+		Msg("Starting backend health checker")
+
+	ticker := time.NewTicker(r.healthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.checkHealth(ctx)
+		}
+	}
+}
+
+// checkHealth dials each backend once and updates the status map
+// A reconciliation is triggered when REMOVE_UNHEALTHY is set and any backend changes state
+func (r *Reconciler) checkHealth(ctx context.Context) {
+	// Snapshot the targets so probes run without holding the lock
+	targets := make(map[string]string)
+	r.statusMu.RLock()
+	for id, st := range r.status {
+		if target := healthTarget(st.Service); target != "" {
+			targets[id] = target
+		}
+	}
+	r.statusMu.RUnlock()
+
+	results := make(map[string]error, len(targets))
+	for id, target := range targets {
+		dialer := net.Dialer{Timeout: 1 * time.Second}
+		conn, err := dialer.DialContext(ctx, "tcp", target)
+		if err == nil {
+			_ = conn.Close()
+		}
+		results[id] = err
+	}
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	changed := false
+	maintenanceChanged := false
+	now := time.Now()
+
+	r.statusMu.Lock()
+	for id, err := range results {
+		st, ok := r.status[id]
+		// Skip containers that were removed or re-pointed while probing
+		if !ok || healthTarget(st.Service) != targets[id] {
+			continue
+		}
+		st.LastHealthCheck = now
+
+		if err == nil {
+			if !st.Healthy {
+				log.Info().
+					Str("container", st.ContainerName).
+					Str("target", targets[id]).
+					Msg("Backend recovered")
+				changed = true
+				maintenanceChanged = maintenanceChanged || st.Service.MaintenancePage != ""
+			}
+			st.Healthy = true
+			st.ConsecutiveFailures = 0
+			continue
+		}
+
+		st.ConsecutiveFailures++
+		log.Debug().
+			Err(err).
+			Str("container", st.ContainerName).
+			Str("target", targets[id]).
+			Int("consecutive_failures", st.ConsecutiveFailures).
+			Msg("Backend health check failed")
+
+		if st.Healthy && st.ConsecutiveFailures >= unhealthyThreshold {
+			st.Healthy = false
+			changed = true
+			maintenanceChanged = maintenanceChanged || st.Service.MaintenancePage != ""
+			log.Warn().
+				Str("container", st.ContainerName).
+				Str("target", targets[id]).
+				Int("consecutive_failures", st.ConsecutiveFailures).
+				Msg("Backend marked unhealthy")
+		}
+	}
+	r.statusMu.Unlock()
+
+	if (changed && r.removeUnhealthy) || maintenanceChanged {
+		r.Trigger()
+	}
+}
+
+// withoutUnhealthy filters out services whose backend is currently marked unhealthy
+func (r *Reconciler) withoutUnhealthy(services []*apptypes.ContainerService) []*apptypes.ContainerService {
+	r.statusMu.RLock()
+	defer r.statusMu.RUnlock()
+
+	filtered := make([]*apptypes.ContainerService, 0, len(services))
+	for _, svc := range services {
+		if svc.ServePath != "" {
+			// Served from a path (its own or the maintenance page), nothing to probe
+			filtered = append(filtered, svc)
+			continue
+		}
+		if st, ok := r.status[svc.ContainerID]; ok && !st.Healthy && healthTarget(st.Service) == healthTarget(svc) {
+			log.Warn().
+				Str("container", svc.ContainerName).
+				Str("service", svc.ServiceName).
+				Msg("Backend unhealthy, removing serve config until it recovers")
+			continue
+		}
+		filtered = append(filtered, svc)
+	}
+	return filtered
+}
+
+// withMaintenancePages swaps unhealthy http/https backends that have a maintenance page for
+// a copy serving that page, so clients get it instead of a 502 until the backend recovers
+func (r *Reconciler) withMaintenancePages(services []*apptypes.ContainerService) []*apptypes.ContainerService {
+	r.statusMu.RLock()
+	defer r.statusMu.RUnlock()
+
+	result := make([]*apptypes.ContainerService, 0, len(services))
+	for _, svc := range services {
+		st, ok := r.status[svc.ContainerID]
+		if svc.MaintenancePage == "" || !ok || st.Healthy || healthTarget(st.Service) != healthTarget(svc) {
+			result = append(result, svc)
+			continue
+		}
+
+		log.Warn().
+			Str("container", svc.ContainerName).
+			Str("service", svc.ServiceName).
+			Str("maintenance_page", svc.MaintenancePage).
+			Msg("Backend unhealthy, serving maintenance page until it recovers")
+		maintenance := *svc
+		maintenance.ServePath = svc.MaintenancePage
+		result = append(result, &maintenance)
+	}
+	return result
+}
+
+// healthTarget returns the host:port to probe for a service, or "" if it can't be probed
+// localhost destinations (host networking, published ports) are relative to tailscaled,
+// not DockTail, so they are not probed
+func healthTarget(svc *apptypes.ContainerService) string {
+	if svc == nil || svc.IPAddress == "" || svc.IPAddress == "localhost" {
+		return ""
+	}
+	return net.JoinHostPort(svc.IPAddress, svc.TargetPort)
+}
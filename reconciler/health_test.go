@@ -0,0 +1,100 @@
+package reconciler
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/marvinvr/docktail/tailscale"
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+func TestCheckHealth(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer func() { _ = listener.Close() }()
+	_, upPort, _ := net.SplitHostPort(listener.Addr().String())
+
+	// Grab a free port and close it so nothing is listening there
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	_, downPort, _ := net.SplitHostPort(closed.Addr().String())
+	_ = closed.Close()
+
+	up := &apptypes.ContainerService{ContainerID: "aaaaaaaaaaaa", ContainerName: "up", IPAddress: "127.0.0.1", TargetPort: upPort}
+	down := &apptypes.ContainerService{ContainerID: "bbbbbbbbbbbb", ContainerName: "down", IPAddress: "127.0.0.1", TargetPort: downPort}
+	published := &apptypes.ContainerService{ContainerID: "cccccccccccc", ContainerName: "published", IPAddress: "localhost", TargetPort: downPort}
+
+	r := NewReconciler(nil, nil, Config{RemoveUnhealthy: true})
+	services := []*apptypes.ContainerService{up, down, published}
+	r.recordStatus(services, nil, &tailscale.ReconcileResult{}, nil)
+
+	for i := 0; i < unhealthyThreshold; i++ {
+		r.checkHealth(context.Background())
+	}
+
+	byName := make(map[string]ContainerStatus)
+	for _, st := range r.GetStatus() {
+		byName[st.ContainerName] = st
+	}
+
+	if st := byName["up"]; !st.Healthy || st.ConsecutiveFailures != 0 || st.LastHealthCheck.IsZero() {
+		t.Errorf("up: expected healthy, got %+v", st)
+	}
+	if st := byName["down"]; st.Healthy || st.ConsecutiveFailures != unhealthyThreshold {
+		t.Errorf("down: expected unhealthy after %d failures, got %+v", unhealthyThreshold, st)
+	}
+	if st := byName["published"]; !st.Healthy || !st.LastHealthCheck.IsZero() {
+		t.Errorf("published: expected localhost destination to be skipped, got %+v", st)
+	}
+
+	select {
+	case <-r.trigger:
+	default:
+		t.Error("expected a reconciliation to be triggered when a backend became unhealthy")
+	}
+
+	filtered := r.withoutUnhealthy(services)
+	if len(filtered) != 2 {
+		t.Fatalf("expected unhealthy service to be filtered, got %d services", len(filtered))
+	}
+	for _, svc := range filtered {
+		if svc == down {
+			t.Error("expected unhealthy service to be removed from the desired set")
+		}
+	}
+}
+
+func TestWithMaintenancePages(t *testing.T) {
+	withPage := &apptypes.ContainerService{ContainerID: "aaaaaaaaaaaa", ContainerName: "web", ServiceName: "web", Protocol: "http", IPAddress: "172.17.0.2", TargetPort: "80", MaintenancePage: "/srv/maintenance.html"}
+	withoutPage := &apptypes.ContainerService{ContainerID: "bbbbbbbbbbbb", ContainerName: "api", ServiceName: "api", Protocol: "http", IPAddress: "172.17.0.3", TargetPort: "80"}
+
+	r := NewReconciler(nil, nil, Config{RemoveUnhealthy: true})
+	services := []*apptypes.ContainerService{withPage, withoutPage}
+	r.recordStatus(services, nil, &tailscale.ReconcileResult{}, nil)
+
+	if got := r.withMaintenancePages(services); got[0] != withPage {
+		t.Fatalf("healthy backend should keep proxying, got %+v", got[0])
+	}
+
+	r.statusMu.Lock()
+	for _, st := range r.status {
+		st.Healthy = false
+	}
+	r.statusMu.Unlock()
+
+	desired := r.withoutUnhealthy(r.withMaintenancePages(services))
+	if len(desired) != 1 {
+		t.Fatalf("expected only the maintenance page to remain, got %+v", desired)
+	}
+	if got := tailscale.BuildDestination(desired[0]); got != "/srv/maintenance.html" {
+		t.Errorf("unhealthy backend destination = %q, want the maintenance page", got)
+	}
+	if withPage.ServePath != "" {
+		t.Error("the parsed service must not be modified, the health checker still probes it")
+	}
+}
@@ -0,0 +1,121 @@
+package reconciler
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/marvinvr/docktail/tailscale"
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+// inventory is the snapshot of managed services written to INVENTORY_FILE after each reconciliation
+type inventory struct {
+	GeneratedAt time.Time        `json:"generated_at" yaml:"generated_at"`
+	Services    []inventoryEntry `json:"services" yaml:"services"`
+}
+
+// inventoryEntry is one applied service, as exposed on the tailnet (and publicly, with funnel)
+type inventoryEntry struct {
+	ContainerID     string            `json:"container_id" yaml:"container_id"`
+	ContainerName   string            `json:"container_name" yaml:"container_name"`
+	Service         string            `json:"service" yaml:"service"`
+	ServicePort     string            `json:"service_port" yaml:"service_port"`
+	ServiceProtocol string            `json:"service_protocol" yaml:"service_protocol"`
+	Protocol        string            `json:"protocol" yaml:"protocol"`
+	Destination     string            `json:"destination" yaml:"destination"`
+	Tags            []string          `json:"tags" yaml:"tags"`
+	Tailnet         string            `json:"tailnet,omitempty" yaml:"tailnet,omitempty"`
+	FunnelEnabled   bool              `json:"funnel_enabled" yaml:"funnel_enabled"`
+	FunnelOnly      bool              `json:"funnel_only,omitempty" yaml:"funnel_only,omitempty"`
+	Funnels         []inventoryFunnel `json:"funnels,omitempty" yaml:"funnels,omitempty"`
+}
+
+// inventoryFunnel is one public funnel port of a service
+type inventoryFunnel struct {
+	FunnelPort string `json:"funnel_port" yaml:"funnel_port"`
+	Protocol   string `json:"protocol" yaml:"protocol"`
+}
+
+// buildInventory lists the applied services, sorted by their svc:<name>:<port> key
+func buildInventory(applied map[string]*apptypes.ContainerService, now time.Time) inventory {
+	keys := make([]string, 0, len(applied))
+	for key := range applied {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	inv := inventory{GeneratedAt: now.UTC(), Services: make([]inventoryEntry, 0, len(keys))}
+	for _, key := range keys {
+		svc := applied[key]
+		tags := serviceTags(svc)
+		if tags == nil {
+			tags = []string{}
+		}
+		entry := inventoryEntry{
+			ContainerID:     svc.ContainerID,
+			ContainerName:   svc.ContainerName,
+			Service:         svc.ServiceName,
+			ServicePort:     svc.Port,
+			ServiceProtocol: svc.ServiceProtocol,
+			Protocol:        svc.Protocol,
+			Destination:     tailscale.BuildDestination(svc),
+			Tags:            tags,
+			Tailnet:         svc.Tailnet,
+			FunnelEnabled:   svc.FunnelEnabled,
+			FunnelOnly:      svc.FunnelOnly,
+		}
+		for _, funnel := range svc.Funnels {
+			entry.Funnels = append(entry.Funnels, inventoryFunnel{FunnelPort: funnel.FunnelPort, Protocol: funnel.Protocol})
+		}
+		inv.Services = append(inv.Services, entry)
+	}
+	return inv
+}
+
+// writeInventory writes the applied services to path, as YAML for .yaml/.yml files and JSON otherwise
+// The file is replaced atomically (temp file + rename) so readers never see a partial snapshot
+func writeInventory(path string, applied map[string]*apptypes.ContainerService, now time.Time) error {
+	inv := buildInventory(applied, now)
+
+	var data []byte
+	var err error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		data, err = yaml.Marshal(inv)
+	default:
+		data, err = json.MarshalIndent(inv, "", "  ")
+		data = append(data, '\n')
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode inventory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary inventory file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write inventory: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write inventory: %w", err)
+	}
+	// CreateTemp uses 0600; the inventory is meant for other tools to read
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return fmt.Errorf("failed to set inventory permissions: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace inventory file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package reconciler
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+func TestWriteInventory(t *testing.T) {
+	web := &apptypes.ContainerService{
+		ContainerID: "web", ContainerName: "web-1", ServiceName: "web", Port: "443",
+		ServiceProtocol: "https", Protocol: "http", IPAddress: "172.17.0.2", TargetPort: "80",
+		Tags: []string{"tag:web"}, FunnelEnabled: true, FunnelTags: []string{"tag:public"},
+		Funnels: []apptypes.FunnelConfig{{FunnelPort: "443", Protocol: "https"}},
+	}
+	db := &apptypes.ContainerService{
+		ContainerID: "db", ContainerName: "db-1", ServiceName: "db", Port: "5432",
+		ServiceProtocol: "tcp", Protocol: "tcp", IPAddress: "172.17.0.3", TargetPort: "5432",
+	}
+	applied := map[string]*apptypes.ContainerService{"svc:web:443": web, "svc:db:5432": db}
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	dir := t.TempDir()
+
+	jsonPath := filepath.Join(dir, "inventory.json")
+	if err := writeInventory(jsonPath, applied, now); err != nil {
+		t.Fatalf("writeInventory() error = %v", err)
+	}
+	data, err := os.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got inventory
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("inventory is not valid JSON: %v", err)
+	}
+	if !got.GeneratedAt.Equal(now) || len(got.Services) != 2 {
+		t.Fatalf("unexpected inventory: %+v", got)
+	}
+	if got.Services[0].Service != "db" || got.Services[0].Destination != "tcp://172.17.0.3:5432" || len(got.Services[0].Tags) != 0 {
+		t.Errorf("unexpected db entry: %+v", got.Services[0])
+	}
+	if entry := got.Services[1]; entry.Service != "web" || !entry.FunnelEnabled || len(entry.Funnels) != 1 || entry.Tags[0] != "tag:public" {
+		t.Errorf("unexpected web entry: %+v", entry)
+	}
+
+	yamlPath := filepath.Join(dir, "inventory.yaml")
+	if err := writeInventory(yamlPath, applied, now); err != nil {
+		t.Fatalf("writeInventory() error = %v", err)
+	}
+	data, err = os.ReadFile(yamlPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = inventory{}
+	if err := yaml.Unmarshal(data, &got); err != nil || len(got.Services) != 2 {
+		t.Errorf("inventory is not valid YAML: %v (%d services)", err, len(got.Services))
+	}
+
+	// Only the inventories themselves are left behind, no temporary files
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("expected 2 files in %s, got %d", dir, len(entries))
+	}
+}
@@ -0,0 +1,66 @@
+package reconciler
+
+import (
+	"fmt"
+	"sort"
+
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+// limitServices caps the number of distinct service names to maxServices (0 = unlimited)
+// Services already applied keep their slot so a flood of new containers can't evict them;
+// the remaining slots go to new services in container name order
+// Containers beyond the cap are returned as errors, keyed by container ID
+func limitServices(services []*apptypes.ContainerService, maxServices int, applied map[string]*apptypes.ContainerService) ([]*apptypes.ContainerService, map[string]error) {
+	errs := make(map[string]error)
+	if maxServices <= 0 {
+		return services, errs
+	}
+
+	existing := make(map[string]bool, len(applied))
+	for _, svc := range applied {
+		existing[svc.ServiceName] = true
+	}
+
+	ordered := make([]*apptypes.ContainerService, len(services))
+	copy(ordered, services)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		ei, ej := existing[ordered[i].ServiceName], existing[ordered[j].ServiceName]
+		if ei != ej {
+			return ei
+		}
+		return ordered[i].ContainerName < ordered[j].ContainerName
+	})
+
+	allowed := make(map[string]bool)
+	var skipped []string
+	for _, svc := range ordered {
+		if allowed[svc.ServiceName] {
+			continue
+		}
+		if len(allowed) < maxServices {
+			allowed[svc.ServiceName] = true
+			continue
+		}
+		errs[svc.ContainerID] = fmt.Errorf("MAX_SERVICES limit of %d reached, service svc:%s not created", maxServices, svc.ServiceName)
+		skipped = append(skipped, svc.ContainerName)
+	}
+
+	if len(skipped) == 0 {
+		return services, errs
+	}
+
+	log.Error().
+		Int("max_services", maxServices).
+		Int("skipped_count", len(skipped)).
+		Strs("skipped_containers", skipped).
+		Msg("MAX_SERVICES limit reached - skipping containers. Check for runaway docktail labels or raise MAX_SERVICES")
+
+	limited := make([]*apptypes.ContainerService, 0, len(services))
+	for _, svc := range services {
+		if allowed[svc.ServiceName] {
+			limited = append(limited, svc)
+		}
+	}
+	return limited, errs
+}
@@ -0,0 +1,43 @@
+package reconciler
+
+import (
+	"testing"
+
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+func TestLimitServices(t *testing.T) {
+	a := &apptypes.ContainerService{ContainerID: "a", ContainerName: "a", ServiceName: "alpha"}
+	b := &apptypes.ContainerService{ContainerID: "b", ContainerName: "b", ServiceName: "bravo"}
+	c := &apptypes.ContainerService{ContainerID: "c", ContainerName: "c", ServiceName: "charlie"}
+	a2 := &apptypes.ContainerService{ContainerID: "a2", ContainerName: "a2", ServiceName: "alpha"}
+	services := []*apptypes.ContainerService{c, b, a, a2}
+
+	t.Run("unlimited", func(t *testing.T) {
+		limited, errs := limitServices(services, 0, nil)
+		if len(limited) != 4 || len(errs) != 0 {
+			t.Errorf("expected all services, got %d (errors: %v)", len(limited), errs)
+		}
+	})
+
+	t.Run("new services by container name", func(t *testing.T) {
+		limited, errs := limitServices(services, 2, nil)
+		if len(limited) != 3 {
+			t.Errorf("expected alpha (2 containers) and bravo, got %+v", limited)
+		}
+		if errs["c"] == nil || len(errs) != 1 {
+			t.Errorf("expected only charlie to be skipped, got %v", errs)
+		}
+	})
+
+	t.Run("applied services keep their slot", func(t *testing.T) {
+		applied := map[string]*apptypes.ContainerService{"svc:charlie:80": c}
+		_, errs := limitServices(services, 2, applied)
+		if errs["c"] != nil {
+			t.Error("already applied service should not be skipped")
+		}
+		if errs["b"] == nil {
+			t.Error("expected bravo to be skipped")
+		}
+	})
+}
@@ -0,0 +1,23 @@
+package reconciler
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	zlog "github.com/rs/zerolog/log"
+)
+
+func TestLogFollowsGlobalLogger(t *testing.T) {
+	saved := zlog.Logger
+	defer func() { zlog.Logger = saved }()
+
+	// An embedder that only configures the global logger still gets the package's output
+	var buf bytes.Buffer
+	zlog.Logger = zerolog.New(&buf)
+	log.Info().Msg("hello")
+	if !strings.Contains(buf.String(), "hello") {
+		t.Errorf("package logger did not write to the global logger, got %q", buf.String())
+	}
+}
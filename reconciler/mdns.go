@@ -0,0 +1,138 @@
+package reconciler
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/grandcat/zeroconf"
+
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+// mdnsRecord is what gets advertised on the local network for one applied service
+type mdnsRecord struct {
+	instance string // e.g. web
+	service  string // e.g. _http._tcp
+	port     int
+	text     []string
+}
+
+// mdnsAdvertiser keeps an mDNS advertisement (MDNS_ADVERTISE) for every applied service
+// It is a LAN convenience on top of Tailscale: the records point at this host, so the backend
+// port has to be reachable from the LAN (published, or host networking)
+type mdnsAdvertiser struct {
+	// register starts advertising a record and returns the function that withdraws it
+	register func(mdnsRecord) (func(), error)
+	active   map[string]*mdnsAdvertisement // keyed like Reconciler.applied
+}
+
+type mdnsAdvertisement struct {
+	record   mdnsRecord
+	withdraw func()
+}
+
+func newMDNSAdvertiser() *mdnsAdvertiser {
+	return &mdnsAdvertiser{
+		register: registerZeroconf,
+		active:   make(map[string]*mdnsAdvertisement),
+	}
+}
+
+// registerZeroconf advertises a record on every multicast interface under the local. domain
+func registerZeroconf(record mdnsRecord) (func(), error) {
+	server, err := zeroconf.Register(record.instance, record.service, "local.", record.port, record.text, nil)
+	if err != nil {
+		return nil, err
+	}
+	return server.Shutdown, nil
+}
+
+// mdnsRecordFor returns the record for a service, or false if it has no port to advertise
+func mdnsRecordFor(svc *apptypes.ContainerService) (mdnsRecord, bool) {
+	if svc.UnixSocket != "" || svc.ServePath != "" {
+		return mdnsRecord{}, false
+	}
+	port, err := strconv.Atoi(svc.TargetPort)
+	if err != nil {
+		return mdnsRecord{}, false
+	}
+
+	service := "_docktail._tcp"
+	switch svc.Protocol {
+	case "http":
+		service = "_http._tcp"
+	case "https", "https+insecure":
+		service = "_https._tcp"
+	}
+	return mdnsRecord{
+		instance: svc.ServiceName,
+		service:  service,
+		port:     port,
+		text:     []string{"tailscale=svc:" + svc.ServiceName},
+	}, true
+}
+
+// update advertises newly applied services and withdraws removed or changed ones
+func (a *mdnsAdvertiser) update(applied map[string]*apptypes.ContainerService) {
+	desired := make(map[string]mdnsRecord, len(applied))
+	for key, svc := range applied {
+		if record, ok := mdnsRecordFor(svc); ok {
+			desired[key] = record
+		}
+	}
+
+	for key, ad := range a.active {
+		if record, ok := desired[key]; ok && sameRecord(record, ad.record) {
+			continue
+		}
+		ad.withdraw()
+		delete(a.active, key)
+		log.Info().
+			Str("instance", ad.record.instance).
+			Str("type", ad.record.service).
+			Msg("Withdrew mDNS advertisement")
+	}
+
+	keys := make([]string, 0, len(desired))
+	for key := range desired {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if _, ok := a.active[key]; ok {
+			continue
+		}
+		record := desired[key]
+		withdraw, err := a.register(record)
+		if err != nil {
+			// Retried on the next reconciliation
+			log.Warn().
+				Err(err).
+				Str("instance", record.instance).
+				Msg("Failed to advertise service over mDNS")
+			continue
+		}
+		a.active[key] = &mdnsAdvertisement{record: record, withdraw: withdraw}
+		log.Info().
+			Str("instance", record.instance).
+			Str("type", record.service).
+			Int("port", record.port).
+			Msg("Advertising service over mDNS")
+	}
+}
+
+// shutdown withdraws every advertisement; nil-safe so callers needn't check MDNS_ADVERTISE
+func (a *mdnsAdvertiser) shutdown() {
+	if a == nil {
+		return
+	}
+	for key, ad := range a.active {
+		ad.withdraw()
+		delete(a.active, key)
+	}
+	log.Info().Msg("Withdrew all mDNS advertisements")
+}
+
+func sameRecord(a, b mdnsRecord) bool {
+	return a.instance == b.instance && a.service == b.service && a.port == b.port
+}
@@ -0,0 +1,64 @@
+package reconciler
+
+import (
+	"testing"
+
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+func TestMDNSRecordFor(t *testing.T) {
+	tests := []struct {
+		name    string
+		svc     *apptypes.ContainerService
+		want    string
+		wantAdv bool
+	}{
+		{"http", &apptypes.ContainerService{ServiceName: "web", Protocol: "http", TargetPort: "8080"}, "_http._tcp", true},
+		{"https insecure", &apptypes.ContainerService{ServiceName: "web", Protocol: "https+insecure", TargetPort: "8443"}, "_https._tcp", true},
+		{"tcp", &apptypes.ContainerService{ServiceName: "db", Protocol: "tcp", TargetPort: "5432"}, "_docktail._tcp", true},
+		{"unix socket", &apptypes.ContainerService{ServiceName: "sock", Protocol: "http", UnixSocket: "/run/app.sock"}, "", false},
+	}
+
+	for _, tt := range tests {
+		record, ok := mdnsRecordFor(tt.svc)
+		if ok != tt.wantAdv {
+			t.Errorf("%s: advertised = %v, want %v", tt.name, ok, tt.wantAdv)
+			continue
+		}
+		if ok && record.service != tt.want {
+			t.Errorf("%s: service type = %s, want %s", tt.name, record.service, tt.want)
+		}
+	}
+}
+
+func TestMDNSAdvertiserUpdate(t *testing.T) {
+	live := make(map[string]int) // instance -> port
+	a := &mdnsAdvertiser{
+		register: func(record mdnsRecord) (func(), error) {
+			live[record.instance] = record.port
+			return func() { delete(live, record.instance) }, nil
+		},
+		active: make(map[string]*mdnsAdvertisement),
+	}
+
+	web := &apptypes.ContainerService{ServiceName: "web", Protocol: "http", TargetPort: "8080"}
+	api := &apptypes.ContainerService{ServiceName: "api", Protocol: "http", TargetPort: "9090"}
+	a.update(map[string]*apptypes.ContainerService{"svc:web:443": web, "svc:api:443": api})
+	if len(live) != 2 {
+		t.Fatalf("expected both services advertised, got %v", live)
+	}
+
+	moved := &apptypes.ContainerService{ServiceName: "web", Protocol: "http", TargetPort: "8081"}
+	a.update(map[string]*apptypes.ContainerService{"svc:web:443": moved})
+	if _, ok := live["api"]; ok {
+		t.Errorf("removed service must be withdrawn")
+	}
+	if live["web"] != 8081 {
+		t.Errorf("changed service must be re-advertised on its new port, got %d", live["web"])
+	}
+
+	a.shutdown()
+	if len(live) != 0 {
+		t.Errorf("shutdown must withdraw everything, still advertising %v", live)
+	}
+}
@@ -0,0 +1,28 @@
+package reconciler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/marvinvr/docktail/docker"
+)
+
+// ErrInvalidContainers is returned with FAIL_ON_PARSE_ERROR=true when enabled containers have invalid labels
+var ErrInvalidContainers = errors.New("invalid container configuration")
+
+// parseFailure joins the parse errors into one ErrInvalidContainers error, or returns nil if there are none
+// Restarting and paused containers are not misconfigured, so they never fail the run
+func parseFailure(parseErrors []docker.ParseError) error {
+	var problems []string
+	for _, pe := range parseErrors {
+		if errors.Is(pe.Err, docker.ErrRestarting) || errors.Is(pe.Err, docker.ErrPaused) {
+			continue
+		}
+		problems = append(problems, fmt.Sprintf("%s: %s", pe.ContainerName, pe.Err))
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w in %d container(s):\n  - %s", ErrInvalidContainers, len(problems), strings.Join(problems, "\n  - "))
+}
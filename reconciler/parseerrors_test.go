@@ -0,0 +1,43 @@
+package reconciler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/marvinvr/docktail/docker"
+)
+
+func TestParseFailure(t *testing.T) {
+	invalid := docker.ParseError{ContainerID: "web", ContainerName: "web", Err: fmt.Errorf("missing required label: %s", "docktail.service.port")}
+	restarting := docker.ParseError{ContainerID: "api", ContainerName: "api", Err: docker.ErrRestarting}
+
+	tests := []struct {
+		name        string
+		parseErrors []docker.ParseError
+		wantErr     bool
+	}{
+		{"none", nil, false},
+		{"restarting only", []docker.ParseError{restarting}, false},
+		{"invalid labels", []docker.ParseError{invalid, restarting}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := parseFailure(tt.parseErrors)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFailure() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				return
+			}
+			if !errors.Is(err, ErrInvalidContainers) {
+				t.Errorf("expected ErrInvalidContainers, got %v", err)
+			}
+			if !strings.Contains(err.Error(), "web: missing required label") || strings.Contains(err.Error(), "api:") {
+				t.Errorf("expected only the invalid container in the error, got %v", err)
+			}
+		})
+	}
+}
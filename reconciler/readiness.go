@@ -0,0 +1,44 @@
+package reconciler
+
+import (
+	"time"
+
+	"github.com/marvinvr/docktail/metrics"
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+// timeToReady measures DockTail's share of deploy latency: from a container first being seen
+// enabled to its service first being applied to Tailscale
+var timeToReady = metrics.NewHistogramVec(
+	"docktail_service_time_to_ready_seconds",
+	"Time from a container first being seen enabled to its service first being applied",
+	"service",
+	[]float64{1, 2, 5, 10, 30, 60, 120, 300, 600},
+)
+
+// recordTimeToReady observes the time to ready of each container whose service was applied
+// for the first time since the container was first seen
+// Containers already running when DockTail started are skipped, their start wasn't observed
+func (r *Reconciler) recordTimeToReady(applied map[string]*apptypes.ContainerService, now time.Time) {
+	for _, svc := range applied {
+		if r.readyObserved[svc.ContainerID] {
+			continue
+		}
+		seen, ok := r.firstSeen[svc.ContainerID]
+		if !ok {
+			continue
+		}
+		r.readyObserved[svc.ContainerID] = true
+		if seen.Equal(r.startedAt) {
+			continue
+		}
+
+		elapsed := now.Sub(seen)
+		timeToReady.Observe(svc.ServiceName, elapsed.Seconds())
+		log.Info().
+			Str("container", svc.ContainerName).
+			Str("service", svc.ServiceName).
+			Dur("time_to_ready", elapsed).
+			Msg("Service ready")
+	}
+}
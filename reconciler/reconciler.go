@@ -2,87 +2,418 @@ package reconciler
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/rs/zerolog/log"
-
 	"github.com/marvinvr/docktail/docker"
 	"github.com/marvinvr/docktail/tailscale"
+	apptypes "github.com/marvinvr/docktail/types"
 )
 
 // Reconciler manages the reconciliation loop
 type Reconciler struct {
-	dockerClient    *docker.Client
-	tailscaleClient *tailscale.Client
-	interval        time.Duration
+	dockerClient        *docker.Client
+	interval            time.Duration
+	jitter              time.Duration
+	healthCheckInterval time.Duration
+	removeUnhealthy     bool
+	maxServices         int
+	stabilizeDelay      time.Duration
+	removalGrace        time.Duration
+	failOnParseError    bool
+	requireTags         bool
+
+	// tailnets holds one Tailscale client per tailnet, keyed by name
+	tailnets map[string]*tailscale.Client
+	// defaultTailnet serves containers without a docktail.service.tailnet label
+	defaultTailnet string
+
+	// trigger requests an immediate reconciliation from outside the loop
+	trigger chan struct{}
+	// waiters are ReconcileNow callers waiting for the next reconciliation
+	waitersMu sync.Mutex
+	waiters   []chan reconcileOutcome
+	// lastSummary is the outcome of the most recent reconciliation, handed to the waiters
+	lastSummary ReconcileSummary
+	// fatal stops Run with the error of a reconciliation that must not be retried (FAIL_ON_PARSE_ERROR)
+	fatal chan error
+	// paused suspends applying changes without stopping the loop (maintenance mode)
+	paused atomic.Bool
+	// done is closed once Run has returned and the goroutines it started have exited
+	done chan struct{}
+	// workers tracks the goroutines Run starts (the health checker)
+	workers sync.WaitGroup
+
+	// ready is false until the first reconciliation and while tailscaled is unreachable
+	ready atomic.Bool
+	// eventsDisconnected is true while the Docker event stream is down
+	eventsDisconnected atomic.Bool
+	// eventStreamAttempts counts reconnection attempts since the event stream last proved healthy
+	eventStreamAttempts int
+	// dockerFailures counts consecutive reconciliations that couldn't reach the Docker daemon
+	dockerFailures int
+	// tailscaledWaitAttempts counts consecutive reconciliations that found tailscaled unreachable
+	tailscaledWaitAttempts int
+
+	// webhook is nil when WEBHOOK_URL is unset
+	webhook *webhookNotifier
+	// mdns is nil unless MDNS_ADVERTISE is set
+	mdns *mdnsAdvertiser
+	// inventoryFile receives a snapshot of the applied services after each reconciliation (empty = disabled)
+	inventoryFile string
+	// firstSeen records when each running container was first seen, for the stabilize delay
+	firstSeen map[string]time.Time
+	// startedAt is the start of the first reconciliation; containers first seen then predate DockTail
+	startedAt time.Time
+	// readyObserved records running containers whose time to ready was already recorded
+	readyObserved map[string]bool
+	// removals records when each draining service's container was first seen gone, keyed like applied
+	removals map[string]time.Time
+	// applied holds the services successfully applied in the last loop, keyed by svc:<name>:<port>
+	applied map[string]*apptypes.ContainerService
+	// listed and listedErrors are the enabled containers as last listed, kept up to date by
+	// targeted reconciliations so they can re-inspect one container instead of listing them all
+	listed       []*apptypes.ContainerService
+	listedErrors []docker.ParseError
+	// eventScopes maps a Docker event action to how much it reconciles (default: full)
+	eventScopes map[string]string
+
+	statusMu      sync.RWMutex
+	status        map[string]*ContainerStatus // container ID -> last reconcile outcome
+	lastReconcile time.Time
+}
+
+// Config holds configuration for creating a reconciler
+type Config struct {
+	Interval            time.Duration     // Periodic full reconciliation interval
+	Jitter              time.Duration     // Randomize each interval by up to ±Jitter (0 = disabled)
+	HealthCheckInterval time.Duration     // Backend TCP health probe interval (0 = disabled)
+	RemoveUnhealthy     bool              // Remove serve config for unhealthy backends until they recover
+	WebhookURL          string            // POST service added/removed events here (empty = disabled)
+	InventoryFile       string            // Write the applied services here as JSON (or YAML for .yaml/.yml) after each reconciliation (empty = disabled)
+	MaxServices         int               // Cap on distinct services (0 = unlimited)
+	StabilizeDelay      time.Duration     // Only create a container's service once it has run this long (0 = disabled)
+	RemovalGrace        time.Duration     // Keep a stopped container's service this long before removing it (0 = remove immediately)
+	MDNSAdvertise       bool              // Advertise applied services on the local network over mDNS
+	FailOnParseError    bool              // Fail the reconciliation (and stop Run) when enabled containers have invalid labels
+	EventScopes         map[string]string // Event action -> EventScopeFull or EventScopeTargeted (nil = DefaultEventScopes)
+	RequireTags         bool              // Skip services without tags instead of letting them fail to apply
+	DefaultTailnet      string            // Name of the tailnet served by the main Tailscale client (default: "default")
+	// Tailnets are additional tailnets containers can select with docktail.service.tailnet, keyed by name
+	Tailnets map[string]*tailscale.Client
 }
 
+// DefaultTailnetName names the main tailnet when Config.DefaultTailnet is empty
+const DefaultTailnetName = "default"
+
 // NewReconciler creates a new reconciler
-func NewReconciler(dockerClient *docker.Client, tailscaleClient *tailscale.Client, interval time.Duration) *Reconciler {
-	return &Reconciler{
-		dockerClient:    dockerClient,
-		tailscaleClient: tailscaleClient,
-		interval:        interval,
+// tailscaleClient serves the default tailnet; cfg.Tailnets adds more
+func NewReconciler(dockerClient *docker.Client, tailscaleClient *tailscale.Client, cfg Config) *Reconciler {
+	defaultTailnet := cfg.DefaultTailnet
+	if defaultTailnet == "" {
+		defaultTailnet = DefaultTailnetName
+	}
+	tailnets := map[string]*tailscale.Client{defaultTailnet: tailscaleClient}
+	for name, client := range cfg.Tailnets {
+		tailnets[name] = client
+	}
+
+	r := &Reconciler{
+		dockerClient:        dockerClient,
+		tailnets:            tailnets,
+		defaultTailnet:      defaultTailnet,
+		interval:            cfg.Interval,
+		jitter:              cfg.Jitter,
+		healthCheckInterval: cfg.HealthCheckInterval,
+		removeUnhealthy:     cfg.RemoveUnhealthy,
+		maxServices:         cfg.MaxServices,
+		stabilizeDelay:      cfg.StabilizeDelay,
+		removalGrace:        cfg.RemovalGrace,
+		removals:            make(map[string]time.Time),
+		failOnParseError:    cfg.FailOnParseError,
+		requireTags:         cfg.RequireTags,
+		inventoryFile:       cfg.InventoryFile,
+		eventScopes:         cfg.EventScopes,
+		firstSeen:           make(map[string]time.Time),
+		readyObserved:       make(map[string]bool),
+		trigger:             make(chan struct{}, 1),
+		fatal:               make(chan error, 1),
+		done:                make(chan struct{}),
+		applied:             make(map[string]*apptypes.ContainerService),
+		status:              make(map[string]*ContainerStatus),
+	}
+	if r.eventScopes == nil {
+		r.eventScopes = DefaultEventScopes
+	}
+	if cfg.WebhookURL != "" {
+		r.webhook = newWebhookNotifier(cfg.WebhookURL)
+	}
+	if cfg.MDNSAdvertise {
+		r.mdns = newMDNSAdvertiser()
 	}
+	return r
+}
+
+// Trigger requests an immediate reconciliation
+// Multiple triggers before the loop picks them up are coalesced into one
+func (r *Reconciler) Trigger() {
+	select {
+	case r.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Pause stops the loop from touching Tailscale until Resume is called
+// Events and timer ticks are still consumed, so nothing piles up while paused
+func (r *Reconciler) Pause() {
+	if r.paused.CompareAndSwap(false, true) {
+		log.Warn().Msg("Reconciliation paused, Tailscale services are left as they are until resumed")
+	}
+}
+
+// Resume re-enables reconciliation and immediately catches up on changes missed while paused
+func (r *Reconciler) Resume() {
+	if r.paused.CompareAndSwap(true, false) {
+		log.Info().Msg("Reconciliation resumed")
+		r.Trigger()
+	}
+}
+
+// TogglePause pauses a running reconciler or resumes a paused one
+func (r *Reconciler) TogglePause() {
+	if r.paused.Load() {
+		r.Resume()
+	} else {
+		r.Pause()
+	}
+}
+
+// Paused reports whether reconciliation is currently paused
+func (r *Reconciler) Paused() bool {
+	return r.paused.Load()
 }
 
 // Run starts the reconciliation loop
 func (r *Reconciler) Run(ctx context.Context) error {
+	// Done is signalled last, once nothing can touch the serve config anymore
+	defer close(r.done)
+	defer r.workers.Wait()
+
+	// mDNS advertisements go away with DockTail, even when KEEP_SERVICES_ON_SHUTDOWN keeps serve config
+	defer r.mdns.shutdown()
+
 	// Initial reconciliation
-	if err := r.Reconcile(ctx); err != nil {
-		log.Error().Err(err).Msg("Initial reconciliation failed")
+	r.reconcileAndReport(ctx, "Initial")
+
+	// Start backend health checker
+	if r.healthCheckInterval > 0 {
+		r.workers.Go(func() { r.runHealthChecks(ctx) })
 	}
 
 	// Start event watcher
 	eventsChan, errChan := r.dockerClient.WatchEvents(ctx)
 
-	// Start periodic reconciliation ticker
-	ticker := time.NewTicker(r.interval)
-	defer ticker.Stop()
+	// Start periodic reconciliation timer, re-armed with fresh jitter after every run
+	timer := time.NewTimer(r.nextInterval())
+	defer timer.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
 
+		case err := <-r.fatal:
+			return err
+
 		case err := <-errChan:
 			if err != nil {
-				log.Error().Err(err).Msg("Docker event stream error")
-				// Try to reconnect by continuing
-				time.Sleep(5 * time.Second)
-				eventsChan, errChan = r.dockerClient.WatchEvents(ctx)
+				// Usually a daemon restart; reconnect with backoff and resync
+				eventsChan, errChan = r.reconnectEvents(ctx, err)
 			}
 
 		case event := <-eventsChan:
+			// The stream is healthy again, so the next disconnect starts from the shortest backoff
+			r.eventStreamAttempts = 0
 			log.Debug().
 				Str("action", string(event.Action)).
 				Str("container", event.Actor.ID[:12]).
+				Str("name", event.Actor.Attributes["name"]).
 				Msg("Docker event received")
 
 			// Trigger reconciliation on relevant events
-			if err := r.Reconcile(ctx); err != nil {
-				log.Error().Err(err).Msg("Event-triggered reconciliation failed")
-			}
+			// update/rename re-inspect just that container instead of assuming start/stop semantics
+			r.handleEvent(ctx, event)
 
-		case <-ticker.C:
+		case <-timer.C:
+			r.eventStreamAttempts = 0
 			log.Debug().Msg("Running periodic reconciliation")
-			if err := r.Reconcile(ctx); err != nil {
-				log.Error().Err(err).Msg("Periodic reconciliation failed")
-			}
+			r.reconcileAndReport(ctx, "Periodic")
+			timer.Reset(r.nextInterval())
+
+		case <-r.trigger:
+			log.Debug().Msg("Running triggered reconciliation")
+			r.reconcileAndReport(ctx, "Triggered")
+		}
+	}
+}
+
+// RunOnce performs a single reconciliation instead of looping
+// Every container that failed to parse or apply is reported in the returned error
+func (r *Reconciler) RunOnce(ctx context.Context) error {
+	if err := r.Reconcile(ctx); err != nil {
+		return err
+	}
+
+	var problems []string
+	for _, st := range r.GetStatus() {
+		if st.LastError != "" {
+			problems = append(problems, fmt.Sprintf("%s: %s", st.ContainerName, st.LastError))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%d container(s) failed to apply:\n  - %s", len(problems), strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+// nextInterval returns the delay until the next periodic reconciliation
+// The jitter is capped at half the interval so the result is always positive
+func (r *Reconciler) nextInterval() time.Duration {
+	jitter := min(r.jitter, r.interval/2)
+	if jitter <= 0 {
+		return r.interval
+	}
+	return r.interval + time.Duration(rand.Int64N(int64(2*jitter)+1)) - jitter
+}
+
+// Ready reports whether the reconciler has completed a reconciliation, tailscaled is reachable
+// and the Docker event stream is connected
+func (r *Reconciler) Ready() bool {
+	return r.ready.Load() && !r.eventsDisconnected.Load()
+}
+
+// reconcileAndReport runs a full reconciliation and logs its outcome
+// If tailscaled is unreachable (e.g. restarting), the reconciler waits with backoff instead of failing hard
+func (r *Reconciler) reconcileAndReport(ctx context.Context, kind string) {
+	r.runAndReport(ctx, kind, r.Reconcile)
+}
+
+// runAndReport runs a full or targeted reconciliation and logs its outcome
+func (r *Reconciler) runAndReport(ctx context.Context, kind string, reconcile func(context.Context) error) {
+	// Callers that asked before this point get this run's outcome; later ones wait for the next
+	waiters := r.takeWaiters()
+	if r.paused.Load() {
+		log.Info().Str("trigger", kind).Msg("reconciliation paused")
+		notifyWaiters(waiters, reconcileOutcome{err: ErrPaused})
+		return
+	}
+	if ctx.Err() != nil {
+		// Shutting down: a reconciliation now could re-create services that cleanup is about to remove
+		log.Debug().Str("trigger", kind).Msg("Skipping reconciliation, shutting down")
+		notifyWaiters(waiters, reconcileOutcome{err: ErrStopped})
+		return
+	}
+
+	err := reconcile(ctx)
+	defer notifyWaiters(waiters, reconcileOutcome{summary: r.lastSummary, err: err})
+	if errors.Is(err, tailscale.ErrTailscaledUnavailable) {
+		r.waitForTailscaled(err)
+		return
+	}
+
+	if r.tailscaledWaitAttempts > 0 {
+		log.Info().
+			Int("attempts", r.tailscaledWaitAttempts).
+			Msg("tailscaled is reachable again, resuming reconciliation")
+		r.tailscaledWaitAttempts = 0
+	}
+	r.ready.Store(true)
+
+	if err != nil {
+		log.Error().Err(err).Msg(kind + " reconciliation failed")
+	}
+	if errors.Is(err, ErrInvalidContainers) {
+		select {
+		case r.fatal <- err:
+		default:
 		}
 	}
 }
 
+// maxTailscaledWait caps the backoff between reconnection attempts to tailscaled
+const maxTailscaledWait = 30 * time.Second
+
+// waitForTailscaled marks the reconciler not ready and schedules a retry with exponential backoff
+func (r *Reconciler) waitForTailscaled(err error) {
+	r.ready.Store(false)
+	r.tailscaledWaitAttempts++
+
+	delay := maxTailscaledWait
+	if r.tailscaledWaitAttempts <= 5 {
+		delay = min(time.Second<<(r.tailscaledWaitAttempts-1), maxTailscaledWait)
+	}
+
+	log.Warn().
+		Err(err).
+		Int("attempt", r.tailscaledWaitAttempts).
+		Dur("retry_in", delay).
+		Msg("Waiting for tailscaled")
+
+	time.AfterFunc(delay, r.Trigger)
+}
+
+// dockerReconnectThreshold is the number of consecutive Docker connection failures before the client is rebuilt
+const dockerReconnectThreshold = 3
+
+// recordDockerFailure counts Docker connection failures and rebuilds the Docker client once they reach
+// dockerReconnectThreshold, in case the daemon socket was replaced underneath the cached client
+// Errors from a reachable daemon don't count; a failed reconnect is retried on the next failure
+func (r *Reconciler) recordDockerFailure(ctx context.Context, err error) {
+	if !docker.IsConnectionError(err) {
+		r.dockerFailures = 0
+		return
+	}
+	r.dockerFailures++
+	if r.dockerFailures < dockerReconnectThreshold {
+		return
+	}
+
+	if reconnectErr := r.dockerClient.Reconnect(ctx); reconnectErr != nil {
+		log.Error().
+			Err(reconnectErr).
+			Int("failures", r.dockerFailures).
+			Msg("Failed to reconnect to Docker daemon")
+		return
+	}
+	r.dockerFailures = 0
+}
+
 // Reconcile performs a single reconciliation cycle
 func (r *Reconciler) Reconcile(ctx context.Context) error {
+	start := time.Now()
 	log.Info().Msg("Starting reconciliation")
+	r.lastSummary = ReconcileSummary{}
 
 	// Get all enabled containers from Docker
-	containers, err := r.dockerClient.GetEnabledContainers(ctx)
+	containers, parseErrors, err := r.dockerClient.GetEnabledContainers(ctx)
 	if err != nil {
+		r.recordDockerFailure(ctx, err)
 		return fmt.Errorf("failed to get enabled containers: %w", err)
 	}
+	r.dockerFailures = 0
+	r.listed, r.listedErrors = containers, parseErrors
+
+	return r.reconcileContainers(ctx, start, containers, parseErrors)
+}
+
+// reconcileContainers applies the enabled containers found by a full listing or a targeted re-inspect
+func (r *Reconciler) reconcileContainers(ctx context.Context, start time.Time, containers []*apptypes.ContainerService, parseErrors []docker.ParseError) error {
+	containers, parseErrors = keepRestarting(containers, parseErrors, r.applied)
 
 	log.Info().
 		Int("count", len(containers)).
@@ -103,10 +434,119 @@ func (r *Reconciler) Reconcile(ctx context.Context) error {
 	// This will compare current state with desired state and make incremental changes
 	// When containers stop, their services are gracefully drained (existing connections complete)
 	// then cleared (configuration removed) for security
-	if err := r.tailscaleClient.ReconcileServices(ctx, containers); err != nil {
+
+	// Crash-looping containers are held back until they have kept running for the stabilize delay
+	if r.startedAt.IsZero() {
+		r.startedAt = start
+	}
+	r.trackFirstSeen(containers, start)
+	desired, unstableErrors, stableIn := holdUnstable(containers, r.firstSeen, r.applied, r.stabilizeDelay, start)
+	if stableIn > 0 {
+		time.AfterFunc(stableIn, r.Trigger)
+	}
+
+	// Stopped containers keep their service for the removal grace period so in-flight requests complete
+	draining, drainedIn := drainRemoved(containers, parseErrors, r.applied, r.removals, r.removalGrace, start)
+	desired = append(desired, draining...)
+	if drainedIn > 0 {
+		time.AfterFunc(drainedIn, r.Trigger)
+	}
+
+	// Unhealthy backends serve their maintenance page if they have one; otherwise they are kept
+	// out of the desired set so their serve config is removed, but still recorded in the status
+	// map so the health checker can notice recovery
+	desired = r.withMaintenancePages(desired)
+	if r.removeUnhealthy {
+		desired = r.withoutUnhealthy(desired)
+	}
+
+	// on-error=skip services that failed to apply are left alone until their container is recreated
+	desired = r.withoutStoppedRetries(desired)
+
+	// Untagged services are guaranteed to fail, so REQUIRE_TAGS skips them before applying
+	var untaggedErrors map[string]error
+	if r.requireTags {
+		desired, untaggedErrors = withoutUntagged(desired)
+	}
+
+	// Replicas in a service group share one service; pick the backend that serves it
+	desired, groupErrors := selectGroupBackends(desired, r.isHealthy)
+
+	// Safety valve against runaway label propagation
+	desired, limitErrors := limitServices(desired, r.maxServices, r.applied)
+
+	// Each tailnet is reconciled separately; containers pick one with docktail.service.tailnet
+	result, err := r.reconcileTailnets(ctx, desired)
+	for id, groupErr := range groupErrors {
+		result.Failed[id] = groupErr
+	}
+	for id, limitErr := range limitErrors {
+		result.Failed[id] = limitErr
+	}
+	for id, untaggedErr := range untaggedErrors {
+		result.Failed[id] = untaggedErr
+	}
+	r.recordStatus(containers, parseErrors, result, unstableErrors)
+	changes := r.recordApplied(desired, result)
+	r.recordTimeToReady(r.applied, time.Now())
+	if r.mdns != nil {
+		r.mdns.update(r.applied)
+	}
+	if r.inventoryFile != "" {
+		if err := writeInventory(r.inventoryFile, r.applied, time.Now()); err != nil {
+			log.Warn().Err(err).Str("path", r.inventoryFile).Msg("Failed to write service inventory")
+		}
+	}
+
+	r.lastSummary = ReconcileSummary{
+		Enabled:  len(containers),
+		Created:  changes.created,
+		Updated:  changes.updated,
+		Removed:  changes.removed,
+		Errored:  len(parseErrors) + len(result.Failed),
+		Duration: time.Since(start),
+	}
+
+	// One machine-readable heartbeat line per loop, for scripting and monitoring
+	log.Info().
+		Int("enabled", r.lastSummary.Enabled).
+		Int("created", r.lastSummary.Created).
+		Int("updated", r.lastSummary.Updated).
+		Int("removed", r.lastSummary.Removed).
+		Int("errored", r.lastSummary.Errored).
+		Dur("duration", r.lastSummary.Duration).
+		Bool("success", err == nil).
+		Msg("Reconciliation summary")
+
+	if err != nil {
 		return fmt.Errorf("failed to reconcile services: %w", err)
 	}
 
+	// The valid containers were still applied; the invalid ones now fail the run instead of a warning
+	if r.failOnParseError {
+		if err := parseFailure(parseErrors); err != nil {
+			return err
+		}
+	}
+
 	log.Info().Msg("Reconciliation completed successfully")
 	return nil
 }
+
+// recordApplied updates the set of successfully applied services and notifies the webhook of changes
+func (r *Reconciler) recordApplied(desired []*apptypes.ContainerService, result *tailscale.ReconcileResult) serviceChanges {
+	current := make(map[string]*apptypes.ContainerService, len(desired))
+	for _, svc := range desired {
+		if result != nil && result.Failed[svc.ContainerID] != nil {
+			continue
+		}
+		current[fmt.Sprintf("svc:%s:%s", svc.ServiceName, svc.Port)] = svc
+	}
+
+	if r.webhook != nil {
+		r.webhook.send(diffServices(r.applied, current))
+	}
+	changes := countChanges(r.applied, current)
+	r.applied = current
+	return changes
+}
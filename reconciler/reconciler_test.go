@@ -0,0 +1,127 @@
+package reconciler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNextInterval(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval time.Duration
+		jitter   time.Duration
+		min      time.Duration
+		max      time.Duration
+	}{
+		{"no jitter", time.Minute, 0, time.Minute, time.Minute},
+		{"jitter", time.Minute, 10 * time.Second, 50 * time.Second, 70 * time.Second},
+		{"jitter capped at half the interval", 10 * time.Second, time.Minute, 5 * time.Second, 15 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Reconciler{interval: tt.interval, jitter: tt.jitter}
+			for i := 0; i < 1000; i++ {
+				got := r.nextInterval()
+				if got <= 0 || got < tt.min || got > tt.max {
+					t.Fatalf("nextInterval() = %s, want within [%s, %s]", got, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
+
+func TestPauseResume(t *testing.T) {
+	r := NewReconciler(nil, nil, Config{Interval: time.Minute})
+
+	r.TogglePause()
+	if !r.Paused() {
+		t.Fatalf("TogglePause() did not pause the reconciler")
+	}
+
+	// Paused reconciliations must not reach the (nil) Docker/Tailscale clients
+	r.reconcileAndReport(context.Background(), "Periodic")
+
+	r.Resume()
+	if r.Paused() {
+		t.Errorf("Resume() left the reconciler paused")
+	}
+	select {
+	case <-r.trigger:
+	default:
+		t.Errorf("Resume() did not trigger a catch-up reconciliation")
+	}
+
+	// Resuming a running reconciler is a no-op
+	r.Resume()
+	select {
+	case <-r.trigger:
+		t.Errorf("Resume() on a running reconciler triggered a reconciliation")
+	default:
+	}
+}
+
+func TestReconcileNowWhilePaused(t *testing.T) {
+	r := NewReconciler(nil, nil, Config{Interval: time.Minute})
+	r.Pause()
+
+	// Stand-in for the loop: both callers' triggers coalesce into one reconciliation
+	results := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := r.ReconcileNow(context.Background())
+			results <- err
+		}()
+	}
+	for {
+		r.waitersMu.Lock()
+		waiting := len(r.waiters)
+		r.waitersMu.Unlock()
+		if waiting == 2 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	<-r.trigger
+	r.reconcileAndReport(context.Background(), "Triggered")
+
+	for i := 0; i < 2; i++ {
+		if err := <-results; !errors.Is(err, ErrPaused) {
+			t.Errorf("ReconcileNow() error = %v, want ErrPaused", err)
+		}
+	}
+
+	// A caller that gives up stops waiting
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := r.ReconcileNow(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("ReconcileNow() with a cancelled context error = %v", err)
+	}
+}
+
+func TestShutdownSkipsReconciliation(t *testing.T) {
+	r := NewReconciler(nil, nil, Config{Interval: time.Minute})
+
+	// A trigger racing the shutdown must not reach the (nil) Docker/Tailscale clients
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	waiter := make(chan reconcileOutcome, 1)
+	r.waiters = append(r.waiters, waiter)
+	r.reconcileAndReport(ctx, "Triggered")
+	if outcome := <-waiter; !errors.Is(outcome.err, ErrStopped) {
+		t.Errorf("waiter error = %v, want ErrStopped", outcome.err)
+	}
+
+	if err := r.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait() before Run returned = %v, want context.Canceled", err)
+	}
+	close(r.done)
+	if err := r.Wait(context.Background()); err != nil {
+		t.Errorf("Wait() after Run returned = %v", err)
+	}
+	if _, err := r.ReconcileNow(context.Background()); !errors.Is(err, ErrStopped) {
+		t.Errorf("ReconcileNow() after Run returned = %v, want ErrStopped", err)
+	}
+}
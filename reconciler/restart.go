@@ -0,0 +1,41 @@
+package reconciler
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/marvinvr/docktail/docker"
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+// keepRestarting carries the applied services of containers caught mid-restart, or paused with
+// docktail.service.keep-on-pause, into this loop unchanged, so a docker restart or pause doesn't
+// tear down and re-create the service
+// Restarting and paused containers are not failures: they are removed from the parse errors, and
+// one without an applied service is simply picked up once it's running again
+func keepRestarting(containers []*apptypes.ContainerService, parseErrors []docker.ParseError, applied map[string]*apptypes.ContainerService) ([]*apptypes.ContainerService, []docker.ParseError) {
+	restarting := make(map[string]bool)
+	remaining := make([]docker.ParseError, 0, len(parseErrors))
+	for _, pe := range parseErrors {
+		if errors.Is(pe.Err, docker.ErrRestarting) || errors.Is(pe.Err, docker.ErrPaused) {
+			restarting[pe.ContainerID] = true
+			continue
+		}
+		remaining = append(remaining, pe)
+	}
+	if len(restarting) == 0 {
+		return containers, parseErrors
+	}
+
+	keys := make([]string, 0, len(applied))
+	for key := range applied {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if svc := applied[key]; restarting[svc.ContainerID] {
+			containers = append(containers, svc)
+		}
+	}
+	return containers, remaining
+}
@@ -0,0 +1,33 @@
+package reconciler
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/marvinvr/docktail/docker"
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+func TestKeepRestarting(t *testing.T) {
+	web := &apptypes.ContainerService{ContainerID: "web", ServiceName: "web", Port: "443"}
+	api := &apptypes.ContainerService{ContainerID: "api", ServiceName: "api", Port: "443"}
+	db := &apptypes.ContainerService{ContainerID: "db", ServiceName: "db", Port: "5432"}
+	applied := map[string]*apptypes.ContainerService{"svc:web:443": web, "svc:api:443": api, "svc:db:5432": db}
+
+	parseErrors := []docker.ParseError{
+		{ContainerID: "web", ContainerName: "web", Err: docker.ErrRestarting},
+		{ContainerID: "new", ContainerName: "new", Err: fmt.Errorf("wrapped: %w", docker.ErrRestarting)},
+		{ContainerID: "db", ContainerName: "db", Err: docker.ErrPaused},
+		{ContainerID: "bad", ContainerName: "bad", Err: errors.New("invalid port")},
+	}
+
+	containers, remaining := keepRestarting([]*apptypes.ContainerService{api}, parseErrors, applied)
+
+	if len(containers) != 3 || containers[0] != api || containers[1] != db || containers[2] != web {
+		t.Errorf("expected api plus the applied db and web services, got %v", containers)
+	}
+	if len(remaining) != 1 || remaining[0].ContainerID != "bad" {
+		t.Errorf("expected only the real parse error to remain, got %v", remaining)
+	}
+}
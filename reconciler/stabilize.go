@@ -0,0 +1,67 @@
+package reconciler
+
+import (
+	"fmt"
+	"time"
+
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+// trackFirstSeen records when each running container was first seen enabled
+// Containers no longer running are forgotten, so a crash-looping container starts over on every restart
+// and a restarted container's time to ready is measured again
+func (r *Reconciler) trackFirstSeen(services []*apptypes.ContainerService, now time.Time) {
+	running := make(map[string]bool, len(services))
+	for _, svc := range services {
+		running[svc.ContainerID] = true
+		if _, ok := r.firstSeen[svc.ContainerID]; !ok {
+			r.firstSeen[svc.ContainerID] = now
+		}
+	}
+	for id := range r.firstSeen {
+		if !running[id] {
+			delete(r.firstSeen, id)
+			delete(r.readyObserved, id)
+		}
+	}
+}
+
+// holdUnstable keeps containers running for less than delay out of the desired set (0 = disabled)
+// Uptime counts from the container's Docker start time, so containers that were already running when
+// DockTail (re)started aren't held back; the first-seen time is only the fallback when it's unknown
+// Containers whose service is already applied are never held back, so a running service isn't removed
+// Held containers are returned as pending reasons keyed by container ID, along with the shortest remaining wait
+func holdUnstable(services []*apptypes.ContainerService, firstSeen map[string]time.Time, applied map[string]*apptypes.ContainerService, delay time.Duration, now time.Time) ([]*apptypes.ContainerService, map[string]error, time.Duration) {
+	errs := make(map[string]error)
+	if delay <= 0 {
+		return services, errs, 0
+	}
+
+	appliedIDs := make(map[string]bool, len(applied))
+	for _, svc := range applied {
+		appliedIDs[svc.ContainerID] = true
+	}
+
+	stable := make([]*apptypes.ContainerService, 0, len(services))
+	var nextWait time.Duration
+	for _, svc := range services {
+		since := firstSeen[svc.ContainerID]
+		if !svc.StartedAt.IsZero() {
+			since = svc.StartedAt
+		}
+		remaining := delay - now.Sub(since)
+		if remaining <= 0 || appliedIDs[svc.ContainerID] {
+			stable = append(stable, svc)
+			continue
+		}
+		errs[svc.ContainerID] = fmt.Errorf("waiting for SERVICE_STABILIZE_DELAY, service svc:%s is created in %s if the container keeps running", svc.ServiceName, remaining.Round(time.Second))
+		if nextWait == 0 || remaining < nextWait {
+			nextWait = remaining
+		}
+		log.Debug().
+			Str("container", svc.ContainerName).
+			Dur("remaining", remaining).
+			Msg("Container not stable yet, holding back its service")
+	}
+	return stable, errs, nextWait
+}
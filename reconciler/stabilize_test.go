@@ -0,0 +1,103 @@
+package reconciler
+
+import (
+	"testing"
+	"time"
+
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+func TestHoldUnstable(t *testing.T) {
+	now := time.Now()
+	old := &apptypes.ContainerService{ContainerID: "old", ServiceName: "old", Port: "80"}
+	fresh := &apptypes.ContainerService{ContainerID: "fresh", ServiceName: "fresh", Port: "80"}
+	newer := &apptypes.ContainerService{ContainerID: "newer", ServiceName: "newer", Port: "80"}
+	applied := &apptypes.ContainerService{ContainerID: "applied", ServiceName: "applied", Port: "80"}
+	services := []*apptypes.ContainerService{old, fresh, newer, applied}
+	firstSeen := map[string]time.Time{
+		"old":     now.Add(-time.Minute),
+		"fresh":   now.Add(-20 * time.Second),
+		"newer":   now,
+		"applied": now,
+	}
+	appliedServices := map[string]*apptypes.ContainerService{"svc:applied:80": applied}
+
+	t.Run("disabled", func(t *testing.T) {
+		stable, errs, wait := holdUnstable(services, firstSeen, nil, 0, now)
+		if len(stable) != 4 || len(errs) != 0 || wait != 0 {
+			t.Errorf("expected every service with the delay disabled, got %d (errors: %v, wait %v)", len(stable), errs, wait)
+		}
+	})
+
+	t.Run("holds new containers", func(t *testing.T) {
+		stable, errs, wait := holdUnstable(services, firstSeen, appliedServices, 30*time.Second, now)
+		if len(stable) != 2 || stable[0] != old || stable[1] != applied {
+			t.Errorf("expected old and the already applied service, got %+v", stable)
+		}
+		if errs["fresh"] == nil || errs["newer"] == nil || len(errs) != 2 {
+			t.Errorf("expected fresh and newer to be held, got %v", errs)
+		}
+		if wait != 10*time.Second {
+			t.Errorf("expected the next check in 10s, got %v", wait)
+		}
+	})
+
+	t.Run("counts from the container start", func(t *testing.T) {
+		// Just seen after a DockTail restart, but the container has been running for an hour
+		longRunning := &apptypes.ContainerService{ContainerID: "newer", ServiceName: "newer", Port: "80", StartedAt: now.Add(-time.Hour)}
+		restarted := &apptypes.ContainerService{ContainerID: "old", ServiceName: "old", Port: "80", StartedAt: now.Add(-5 * time.Second)}
+		stable, errs, wait := holdUnstable([]*apptypes.ContainerService{longRunning, restarted}, firstSeen, nil, 30*time.Second, now)
+		if len(stable) != 1 || stable[0] != longRunning {
+			t.Errorf("expected only the long-running container, got %+v", stable)
+		}
+		if errs["old"] == nil || len(errs) != 1 {
+			t.Errorf("expected the recently restarted container to be held, got %v", errs)
+		}
+		if wait != 25*time.Second {
+			t.Errorf("expected the next check in 25s, got %v", wait)
+		}
+	})
+}
+
+func TestTrackFirstSeen(t *testing.T) {
+	r := NewReconciler(nil, nil, Config{})
+	start := time.Now()
+	a := &apptypes.ContainerService{ContainerID: "a"}
+	b := &apptypes.ContainerService{ContainerID: "b"}
+
+	r.trackFirstSeen([]*apptypes.ContainerService{a, b}, start)
+	r.trackFirstSeen([]*apptypes.ContainerService{a}, start.Add(time.Minute))
+	if !r.firstSeen["a"].Equal(start) {
+		t.Errorf("a container still running must keep its first-seen time")
+	}
+	if _, ok := r.firstSeen["b"]; ok {
+		t.Errorf("a stopped container must be forgotten")
+	}
+
+	r.trackFirstSeen([]*apptypes.ContainerService{a, b}, start.Add(2*time.Minute))
+	if !r.firstSeen["b"].Equal(start.Add(2 * time.Minute)) {
+		t.Errorf("a restarted container must start its delay over")
+	}
+}
+
+func TestRecordTimeToReady(t *testing.T) {
+	r := NewReconciler(nil, nil, Config{})
+	start := time.Now()
+	r.startedAt = start
+	old := &apptypes.ContainerService{ContainerID: "old", ServiceName: "old"}
+	fresh := &apptypes.ContainerService{ContainerID: "fresh", ServiceName: "fresh"}
+
+	r.trackFirstSeen([]*apptypes.ContainerService{old}, start)
+	r.trackFirstSeen([]*apptypes.ContainerService{old, fresh}, start.Add(time.Minute))
+	applied := map[string]*apptypes.ContainerService{"svc:old:443": old, "svc:fresh:443": fresh}
+	r.recordTimeToReady(applied, start.Add(time.Minute+3*time.Second))
+
+	if !r.readyObserved["old"] || !r.readyObserved["fresh"] {
+		t.Errorf("both containers should be marked observed, got %v", r.readyObserved)
+	}
+
+	r.trackFirstSeen([]*apptypes.ContainerService{old}, start.Add(2*time.Minute))
+	if r.readyObserved["fresh"] {
+		t.Errorf("a stopped container must be measured again when it restarts")
+	}
+}
@@ -0,0 +1,191 @@
+package reconciler
+
+import (
+	"sort"
+	"time"
+
+	"github.com/marvinvr/docktail/docker"
+	"github.com/marvinvr/docktail/metrics"
+	"github.com/marvinvr/docktail/tailscale"
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+// ContainerStatus is the last known reconcile outcome for a single enabled container
+type ContainerStatus struct {
+	ContainerID   string
+	ContainerName string
+	Service       *apptypes.ContainerService // nil if the container failed to parse
+	LastError     string                     // last parse or apply error, empty if the last reconcile succeeded
+	ErrorCategory string                     // kind of LastError: a docker.ErrorCategory for parse errors, "apply" otherwise
+	LastErrorTime time.Time
+	LastSuccess   time.Time // zero if the container has never been applied successfully
+	Pending       string    // why the service is held back and not applied yet (SERVICE_STABILIZE_DELAY), empty otherwise
+	FirstSeen     time.Time // when the container first showed up enabled, parsed or not
+	// RetriesStopped is set when an on-error=skip service failed to apply; it stays out of
+	// reconciliation until the container is recreated (and so gets a new ID and entry)
+	RetriesStopped bool
+
+	// Backend health, maintained by the health checker
+	Healthy             bool
+	ConsecutiveFailures int
+	LastHealthCheck     time.Time
+}
+
+// LastReconcile returns the time of the last reconcile that reached the status update, zero if none yet
+func (r *Reconciler) LastReconcile() time.Time {
+	r.statusMu.RLock()
+	defer r.statusMu.RUnlock()
+	return r.lastReconcile
+}
+
+// NodeName returns the node the default tailnet's services are advertised from ("" if unknown)
+func (r *Reconciler) NodeName() string {
+	return r.tailnets[r.defaultTailnet].NodeName()
+}
+
+// TailscaleStatus returns the serve and funnel status each tailnet's tailscaled last reported, keyed by tailnet
+func (r *Reconciler) TailscaleStatus() map[string]tailscale.RawStatus {
+	statuses := make(map[string]tailscale.RawStatus, len(r.tailnets))
+	for name, client := range r.tailnets {
+		statuses[name] = client.LastRawStatus()
+	}
+	return statuses
+}
+
+// GetStatus returns a snapshot of the per-container status, sorted by container name
+func (r *Reconciler) GetStatus() []ContainerStatus {
+	r.statusMu.RLock()
+	defer r.statusMu.RUnlock()
+
+	statuses := make([]ContainerStatus, 0, len(r.status))
+	for _, st := range r.status {
+		statuses = append(statuses, *st)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].ContainerName < statuses[j].ContainerName
+	})
+	return statuses
+}
+
+// recordStatus updates the status map from the outcome of a reconcile
+// Containers held back on purpose are in pending: neither a failure nor a success
+// Containers that are no longer enabled are dropped from the map
+func (r *Reconciler) recordStatus(services []*apptypes.ContainerService, parseErrors []docker.ParseError, result *tailscale.ReconcileResult, pending map[string]error) {
+	now := time.Now()
+
+	r.statusMu.Lock()
+	defer r.statusMu.Unlock()
+
+	r.lastReconcile = now
+	seen := make(map[string]bool, len(services)+len(parseErrors))
+
+	for _, pe := range parseErrors {
+		seen[pe.ContainerID] = true
+		st := r.statusEntry(pe.ContainerID, pe.ContainerName, now)
+		st.Service = nil
+		st.Pending = ""
+		st.LastError = pe.Err.Error()
+		st.ErrorCategory = docker.ErrorCategory(pe.Err)
+		st.LastErrorTime = now
+	}
+
+	for _, svc := range services {
+		seen[svc.ContainerID] = true
+		st := r.statusEntry(svc.ContainerID, svc.ContainerName, now)
+
+		// A new destination means previous probe results no longer apply
+		if st.Service == nil || healthTarget(st.Service) != healthTarget(svc) {
+			st.Healthy = true
+			st.ConsecutiveFailures = 0
+		}
+		st.Service = svc
+		st.Pending = ""
+
+		var applyErr error
+		if result != nil {
+			applyErr = result.Failed[svc.ContainerID]
+		}
+		switch {
+		case st.RetriesStopped:
+			// Not applied this loop, the error that stopped the retries stands
+		case applyErr != nil:
+			st.LastError = applyErr.Error()
+			st.ErrorCategory = "apply"
+			st.LastErrorTime = now
+			if svc.SkipOnError {
+				st.RetriesStopped = true
+				log.Warn().
+					Err(applyErr).
+					Str("container", svc.ContainerName).
+					Str("service", svc.ServiceName).
+					Msg("Apply failed, not retrying until the container is recreated (on-error=skip)")
+			}
+		case pending[svc.ContainerID] != nil:
+			// Not applied yet, but nothing is wrong with it either
+			st.Pending = pending[svc.ContainerID].Error()
+			st.LastError = ""
+			st.ErrorCategory = ""
+		default:
+			st.LastError = ""
+			st.ErrorCategory = ""
+			st.LastSuccess = now
+		}
+	}
+
+	for id := range r.status {
+		if !seen[id] {
+			delete(r.status, id)
+		}
+	}
+
+	oldestUnreconciled.Set(oldestUnreconciledAge(r.status, now).Seconds())
+}
+
+// oldestUnreconciled flags stuck containers: a climbing value means an enabled container
+// keeps failing to parse or apply, e.g. a persistent misconfiguration or a tailscaled problem
+var oldestUnreconciled = metrics.NewGauge(
+	"docktail_oldest_unreconciled_container_age_seconds",
+	"Age of the oldest enabled container that has never been applied successfully (0 = none), updated every reconciliation",
+)
+
+// oldestUnreconciledAge returns how long the oldest never-applied container has been enabled
+// Containers drop out once their service is applied; 0 means every container has been applied
+func oldestUnreconciledAge(status map[string]*ContainerStatus, now time.Time) time.Duration {
+	var oldest time.Duration
+	for _, st := range status {
+		if !st.LastSuccess.IsZero() {
+			continue
+		}
+		if age := now.Sub(st.FirstSeen); age > oldest {
+			oldest = age
+		}
+	}
+	return oldest
+}
+
+// withoutStoppedRetries filters out on-error=skip services whose last apply failed
+func (r *Reconciler) withoutStoppedRetries(services []*apptypes.ContainerService) []*apptypes.ContainerService {
+	r.statusMu.RLock()
+	defer r.statusMu.RUnlock()
+
+	filtered := make([]*apptypes.ContainerService, 0, len(services))
+	for _, svc := range services {
+		if st, ok := r.status[svc.ContainerID]; ok && st.RetriesStopped {
+			continue
+		}
+		filtered = append(filtered, svc)
+	}
+	return filtered
+}
+
+// statusEntry returns the status entry for a container, creating it if needed
+// Caller must hold statusMu
+func (r *Reconciler) statusEntry(containerID, containerName string, now time.Time) *ContainerStatus {
+	st, ok := r.status[containerID]
+	if !ok {
+		st = &ContainerStatus{ContainerID: containerID, Healthy: true, FirstSeen: now}
+		r.status[containerID] = st
+	}
+	st.ContainerName = containerName
+	return st
+}
@@ -0,0 +1,146 @@
+package reconciler
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/marvinvr/docktail/docker"
+	"github.com/marvinvr/docktail/tailscale"
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+func TestRecordStatus(t *testing.T) {
+	r := NewReconciler(nil, nil, Config{})
+
+	web := &apptypes.ContainerService{ContainerID: "aaaaaaaaaaaa", ContainerName: "web", ServiceName: "web"}
+	api := &apptypes.ContainerService{ContainerID: "bbbbbbbbbbbb", ContainerName: "api", ServiceName: "api"}
+	bad := docker.ParseError{ContainerID: "cccccccccccc", ContainerName: "bad", Err: errors.New("invalid protocol: ftp")}
+
+	r.recordStatus(
+		[]*apptypes.ContainerService{web, api},
+		[]docker.ParseError{bad},
+		&tailscale.ReconcileResult{Failed: map[string]error{api.ContainerID: errors.New("serve failed")}},
+		nil,
+	)
+
+	statuses := r.GetStatus()
+	if len(statuses) != 3 {
+		t.Fatalf("expected 3 statuses, got %d", len(statuses))
+	}
+
+	byName := make(map[string]ContainerStatus)
+	for _, st := range statuses {
+		byName[st.ContainerName] = st
+	}
+
+	if st := byName["web"]; st.LastError != "" || st.LastSuccess.IsZero() || st.Service != web {
+		t.Errorf("web: expected success with service set, got %+v", st)
+	}
+	if st := byName["api"]; st.LastError != "serve failed" || st.ErrorCategory != "apply" || !st.LastSuccess.IsZero() {
+		t.Errorf("api: expected apply error and no success, got %+v", st)
+	}
+	if st := byName["bad"]; st.LastError != "invalid protocol: ftp" || st.ErrorCategory != "invalid_config" || st.Service != nil {
+		t.Errorf("bad: expected parse error and nil service, got %+v", st)
+	}
+
+	// Second pass: api recovers, bad container is removed
+	r.recordStatus([]*apptypes.ContainerService{web, api}, nil, &tailscale.ReconcileResult{Failed: map[string]error{}}, nil)
+
+	statuses = r.GetStatus()
+	if len(statuses) != 2 {
+		t.Fatalf("expected 2 statuses after removal, got %d", len(statuses))
+	}
+	if statuses[0].ContainerName != "api" || statuses[1].ContainerName != "web" {
+		t.Errorf("expected statuses sorted by name, got %s, %s", statuses[0].ContainerName, statuses[1].ContainerName)
+	}
+	if statuses[0].LastError != "" || statuses[0].ErrorCategory != "" || statuses[0].LastSuccess.IsZero() {
+		t.Errorf("api: expected recovery, got %+v", statuses[0])
+	}
+	if statuses[0].LastErrorTime.IsZero() {
+		t.Error("api: expected last error time to be preserved after recovery")
+	}
+}
+
+func TestRecordStatusPending(t *testing.T) {
+	r := NewReconciler(nil, nil, Config{})
+	web := &apptypes.ContainerService{ContainerID: "aaaaaaaaaaaa", ContainerName: "web", ServiceName: "web"}
+	services := []*apptypes.ContainerService{web}
+
+	pending := map[string]error{web.ContainerID: errors.New("waiting for SERVICE_STABILIZE_DELAY")}
+	r.recordStatus(services, nil, &tailscale.ReconcileResult{Failed: map[string]error{}}, pending)
+	st := r.GetStatus()[0]
+	if st.Pending != "waiting for SERVICE_STABILIZE_DELAY" || st.LastError != "" || !st.LastSuccess.IsZero() {
+		t.Errorf("expected a pending container without error or success, got %+v", st)
+	}
+
+	r.recordStatus(services, nil, &tailscale.ReconcileResult{Failed: map[string]error{}}, nil)
+	st = r.GetStatus()[0]
+	if st.Pending != "" || st.LastSuccess.IsZero() {
+		t.Errorf("expected the pending state to clear once applied, got %+v", st)
+	}
+}
+
+func TestStoppedRetries(t *testing.T) {
+	r := NewReconciler(nil, nil, Config{})
+
+	critical := &apptypes.ContainerService{ContainerID: "aaaaaaaaaaaa", ContainerName: "critical", ServiceName: "critical"}
+	broken := &apptypes.ContainerService{ContainerID: "bbbbbbbbbbbb", ContainerName: "broken", ServiceName: "broken", SkipOnError: true}
+	services := []*apptypes.ContainerService{critical, broken}
+
+	failed := &tailscale.ReconcileResult{Failed: map[string]error{
+		critical.ContainerID: errors.New("serve failed"),
+		broken.ContainerID:   errors.New("serve failed"),
+	}}
+	r.recordStatus(services, nil, failed, nil)
+
+	desired := r.withoutStoppedRetries(services)
+	if len(desired) != 1 || desired[0] != critical {
+		t.Fatalf("expected only the retry service to stay desired, got %v", desired)
+	}
+
+	// Later loops don't apply the skipped service, so it has no result but keeps its error
+	r.recordStatus(services, nil, &tailscale.ReconcileResult{Failed: map[string]error{}}, nil)
+	for _, st := range r.GetStatus() {
+		switch st.ContainerName {
+		case "critical":
+			if st.RetriesStopped || st.LastError != "" {
+				t.Errorf("critical: expected a successful retry, got %+v", st)
+			}
+		case "broken":
+			if !st.RetriesStopped || st.LastError != "serve failed" || !st.LastSuccess.IsZero() {
+				t.Errorf("broken: expected retries to stay stopped with the error, got %+v", st)
+			}
+		}
+	}
+
+	// Recreating the container gives it a new ID, and a fresh start
+	recreated := &apptypes.ContainerService{ContainerID: "cccccccccccc", ContainerName: "broken", ServiceName: "broken", SkipOnError: true}
+	r.recordStatus([]*apptypes.ContainerService{critical, recreated}, nil, &tailscale.ReconcileResult{Failed: map[string]error{}}, nil)
+	if desired := r.withoutStoppedRetries([]*apptypes.ContainerService{critical, recreated}); len(desired) != 2 {
+		t.Errorf("expected the recreated container to be retried, got %v", desired)
+	}
+}
+
+func TestOldestUnreconciledAge(t *testing.T) {
+	now := time.Now()
+	status := map[string]*ContainerStatus{
+		"applied": {FirstSeen: now.Add(-time.Hour), LastSuccess: now.Add(-50 * time.Minute)},
+		"stuck":   {FirstSeen: now.Add(-10 * time.Minute)},
+		"new":     {FirstSeen: now.Add(-time.Minute)},
+	}
+	if got := oldestUnreconciledAge(status, now); got != 10*time.Minute {
+		t.Errorf("oldestUnreconciledAge() = %v, want 10m", got)
+	}
+
+	// The stuck container finally applies, only the newer one is left
+	status["stuck"].LastSuccess = now
+	if got := oldestUnreconciledAge(status, now); got != time.Minute {
+		t.Errorf("oldestUnreconciledAge() after success = %v, want 1m", got)
+	}
+
+	delete(status, "new")
+	if got := oldestUnreconciledAge(status, now); got != 0 {
+		t.Errorf("oldestUnreconciledAge() with everything applied = %v, want 0", got)
+	}
+}
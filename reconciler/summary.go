@@ -0,0 +1,34 @@
+package reconciler
+
+import (
+	"github.com/marvinvr/docktail/tailscale"
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+// serviceChanges counts how the applied service set changed in one reconciliation
+type serviceChanges struct {
+	created int
+	updated int
+	removed int
+}
+
+// countChanges compares the previously applied services with the current ones
+// A service counts as updated when it kept its key but now proxies somewhere else
+func countChanges(previous, current map[string]*apptypes.ContainerService) serviceChanges {
+	var changes serviceChanges
+	for key, svc := range current {
+		prev, ok := previous[key]
+		switch {
+		case !ok:
+			changes.created++
+		case tailscale.BuildDestination(prev) != tailscale.BuildDestination(svc):
+			changes.updated++
+		}
+	}
+	for key := range previous {
+		if _, ok := current[key]; !ok {
+			changes.removed++
+		}
+	}
+	return changes
+}
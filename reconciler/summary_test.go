@@ -0,0 +1,31 @@
+package reconciler
+
+import (
+	"testing"
+
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+func TestCountChanges(t *testing.T) {
+	web := &apptypes.ContainerService{ServiceName: "web", Port: "443", Protocol: "http", IPAddress: "172.17.0.2", TargetPort: "80"}
+	webMoved := &apptypes.ContainerService{ServiceName: "web", Port: "443", Protocol: "http", IPAddress: "172.17.0.9", TargetPort: "80"}
+	api := &apptypes.ContainerService{ServiceName: "api", Port: "80", Protocol: "http", IPAddress: "172.17.0.3", TargetPort: "3000"}
+	db := &apptypes.ContainerService{ServiceName: "db", Port: "5432", Protocol: "tcp", IPAddress: "172.17.0.4", TargetPort: "5432"}
+
+	previous := map[string]*apptypes.ContainerService{
+		"svc:web:443": web,
+		"svc:api:80":  api,
+	}
+	current := map[string]*apptypes.ContainerService{
+		"svc:web:443": webMoved,
+		"svc:db:5432": db,
+	}
+
+	want := serviceChanges{created: 1, updated: 1, removed: 1}
+	if got := countChanges(previous, current); got != want {
+		t.Errorf("countChanges() = %+v, want %+v", got, want)
+	}
+	if got := countChanges(previous, previous); got != (serviceChanges{}) {
+		t.Errorf("countChanges() on unchanged set = %+v, want no changes", got)
+	}
+}
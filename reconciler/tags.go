@@ -0,0 +1,39 @@
+package reconciler
+
+import (
+	"errors"
+
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+// ErrMissingTags is returned for services skipped with REQUIRE_TAGS=true because they have no tags
+var ErrMissingTags = errors.New("service requires at least one tag")
+
+// withoutUntagged skips services that would be advertised without any tag
+// An untagged service can only fail to apply, so with REQUIRE_TAGS=true it is skipped up front
+// Funnel-only services have no service definition and are always kept
+// Skipped containers are returned as errors, keyed by container ID
+func withoutUntagged(services []*apptypes.ContainerService) ([]*apptypes.ContainerService, map[string]error) {
+	errs := make(map[string]error)
+	kept := make([]*apptypes.ContainerService, 0, len(services))
+	for _, svc := range services {
+		if svc.FunnelOnly || len(serviceTags(svc)) > 0 {
+			kept = append(kept, svc)
+			continue
+		}
+		log.Warn().
+			Str("container", svc.ContainerName).
+			Str("service", svc.ServiceName).
+			Msg("Skipping container: service requires at least one tag (set docktail.tags or DEFAULT_SERVICE_TAGS)")
+		errs[svc.ContainerID] = ErrMissingTags
+	}
+	return kept, errs
+}
+
+// serviceTags returns the tags the service definition is advertised with
+func serviceTags(svc *apptypes.ContainerService) []string {
+	if svc.FunnelEnabled && len(svc.FunnelTags) > 0 {
+		return svc.FunnelTags
+	}
+	return svc.Tags
+}
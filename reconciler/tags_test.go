@@ -0,0 +1,35 @@
+package reconciler
+
+import (
+	"errors"
+	"testing"
+
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+func TestWithoutUntagged(t *testing.T) {
+	tagged := &apptypes.ContainerService{ContainerID: "tagged", ServiceName: "web", Tags: []string{"tag:container"}}
+	untagged := &apptypes.ContainerService{ContainerID: "untagged", ServiceName: "api"}
+	funnelTagged := &apptypes.ContainerService{ContainerID: "funnel", ServiceName: "public", FunnelEnabled: true, FunnelTags: []string{"tag:public"}}
+	funnelOnly := &apptypes.ContainerService{ContainerID: "funnel-only", ServiceName: "site", FunnelEnabled: true, FunnelOnly: true}
+
+	kept, errs := withoutUntagged([]*apptypes.ContainerService{tagged, untagged, funnelTagged, funnelOnly})
+
+	var got []string
+	for _, svc := range kept {
+		got = append(got, svc.ContainerID)
+	}
+	want := []string{"tagged", "funnel", "funnel-only"}
+	if len(got) != len(want) {
+		t.Fatalf("kept %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("kept %v, want %v", got, want)
+			break
+		}
+	}
+	if len(errs) != 1 || !errors.Is(errs["untagged"], ErrMissingTags) {
+		t.Errorf("expected only the untagged service to fail with ErrMissingTags, got %v", errs)
+	}
+}
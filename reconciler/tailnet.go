@@ -0,0 +1,69 @@
+package reconciler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/marvinvr/docktail/tailscale"
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+// partitionByTailnet groups services by the tailnet their container selects with docktail.service.tailnet
+// Containers without the label go to defaultTailnet; containers naming a tailnet missing from
+// clients are returned as errors, keyed by container ID
+// Every configured tailnet gets an entry, even an empty one, so its stale services are removed
+func partitionByTailnet(services []*apptypes.ContainerService, defaultTailnet string, clients map[string]*tailscale.Client) (map[string][]*apptypes.ContainerService, map[string]error) {
+	byTailnet := make(map[string][]*apptypes.ContainerService, len(clients))
+	for name := range clients {
+		byTailnet[name] = nil
+	}
+	errs := make(map[string]error)
+
+	for _, svc := range services {
+		name := svc.Tailnet
+		if name == "" {
+			name = defaultTailnet
+		}
+		if _, ok := clients[name]; !ok {
+			errs[svc.ContainerID] = fmt.Errorf("unknown tailnet %q in %s (configured: %v)", name, apptypes.LabelTailnet, tailnetNames(clients))
+			continue
+		}
+		byTailnet[name] = append(byTailnet[name], svc)
+	}
+	return byTailnet, errs
+}
+
+// tailnetNames returns the configured tailnet names in sorted order
+func tailnetNames(clients map[string]*tailscale.Client) []string {
+	names := make([]string, 0, len(clients))
+	for name := range clients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// reconcileTailnets applies the desired services on every tailnet, each through its own tailscaled
+// A failing tailnet doesn't stop the others; their errors are joined so errors.Is still matches
+func (r *Reconciler) reconcileTailnets(ctx context.Context, desired []*apptypes.ContainerService) (*tailscale.ReconcileResult, error) {
+	byTailnet, tailnetErrors := partitionByTailnet(desired, r.defaultTailnet, r.tailnets)
+
+	result := &tailscale.ReconcileResult{Failed: tailnetErrors}
+	var errs []error
+	for _, name := range tailnetNames(r.tailnets) {
+		tailnetResult, err := r.tailnets[name].ReconcileServices(ctx, byTailnet[name])
+		for id, svcErr := range tailnetResult.Failed {
+			result.Failed[id] = svcErr
+		}
+		if err == nil {
+			continue
+		}
+		if len(r.tailnets) > 1 {
+			err = fmt.Errorf("tailnet %s: %w", name, err)
+		}
+		errs = append(errs, err)
+	}
+	return result, errors.Join(errs...)
+}
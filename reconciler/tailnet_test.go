@@ -0,0 +1,55 @@
+package reconciler
+
+import (
+	"testing"
+
+	"github.com/marvinvr/docktail/tailscale"
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+func TestPartitionByTailnet(t *testing.T) {
+	clients := map[string]*tailscale.Client{"default": nil, "staging": nil, "prod": nil}
+	web := &apptypes.ContainerService{ContainerID: "web", ServiceName: "web"}
+	api := &apptypes.ContainerService{ContainerID: "api", ServiceName: "api", Tailnet: "staging"}
+	db := &apptypes.ContainerService{ContainerID: "db", ServiceName: "db", Tailnet: "default"}
+	typo := &apptypes.ContainerService{ContainerID: "typo", ServiceName: "typo", Tailnet: "stagign"}
+
+	byTailnet, errs := partitionByTailnet([]*apptypes.ContainerService{web, api, db, typo}, "default", clients)
+
+	tests := []struct {
+		tailnet string
+		want    []string
+	}{
+		{"default", []string{"web", "db"}},
+		{"staging", []string{"api"}},
+		{"prod", nil},
+	}
+	for _, tt := range tests {
+		services, ok := byTailnet[tt.tailnet]
+		if !ok {
+			t.Errorf("tailnet %s missing from partition, its stale services would never be removed", tt.tailnet)
+			continue
+		}
+		var got []string
+		for _, svc := range services {
+			got = append(got, svc.ContainerID)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("tailnet %s: expected %v, got %v", tt.tailnet, tt.want, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("tailnet %s: expected %v, got %v", tt.tailnet, tt.want, got)
+				break
+			}
+		}
+	}
+
+	if errs["typo"] == nil || len(errs) != 1 {
+		t.Errorf("expected only the unknown tailnet to fail, got %v", errs)
+	}
+	if _, ok := byTailnet["stagign"]; ok {
+		t.Error("unknown tailnet should not get a partition")
+	}
+}
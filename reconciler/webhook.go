@@ -0,0 +1,127 @@
+package reconciler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/marvinvr/docktail/tailscale"
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+// Webhook event types
+const (
+	eventServiceAdded   = "service_added"
+	eventServiceRemoved = "service_removed"
+)
+
+// webhookEvent is the JSON payload POSTed to WEBHOOK_URL
+type webhookEvent struct {
+	Event       string    `json:"event"`
+	Service     string    `json:"service"`
+	Container   string    `json:"container"`
+	Destination string    `json:"destination"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+// webhookNotifier POSTs service change events to a configured URL
+type webhookNotifier struct {
+	url        string
+	httpClient *http.Client
+}
+
+func newWebhookNotifier(url string) *webhookNotifier {
+	return &webhookNotifier{
+		url:        url,
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// diffServices compares the previously applied services with the current ones
+// Services are keyed by svc:<name>:<port>, matching the keys used by the Tailscale reconciliation
+func diffServices(previous, current map[string]*apptypes.ContainerService) []webhookEvent {
+	now := time.Now()
+	var events []webhookEvent
+
+	for key, svc := range current {
+		if _, ok := previous[key]; !ok {
+			events = append(events, newWebhookEvent(eventServiceAdded, svc, now))
+		}
+	}
+	for key, svc := range previous {
+		if _, ok := current[key]; !ok {
+			events = append(events, newWebhookEvent(eventServiceRemoved, svc, now))
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].Event != events[j].Event {
+			return events[i].Event < events[j].Event
+		}
+		return events[i].Service < events[j].Service
+	})
+	return events
+}
+
+func newWebhookEvent(event string, svc *apptypes.ContainerService, now time.Time) webhookEvent {
+	return webhookEvent{
+		Event:       event,
+		Service:     "svc:" + svc.ServiceName,
+		Container:   svc.ContainerName,
+		Destination: tailscale.BuildDestination(svc),
+		Timestamp:   now,
+	}
+}
+
+// send delivers events one by one in the background
+// Failures are logged and never affect reconciliation
+func (n *webhookNotifier) send(events []webhookEvent) {
+	if len(events) == 0 {
+		return
+	}
+
+	go func() {
+		for _, event := range events {
+			if err := n.post(event); err != nil {
+				log.Warn().
+					Err(err).
+					Str("event", event.Event).
+					Str("service", event.Service).
+					Msg("Failed to deliver webhook")
+			}
+		}
+	}()
+}
+
+func (n *webhookNotifier) post(event webhookEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+
+	log.Debug().
+		Str("event", event.Event).
+		Str("service", event.Service).
+		Msg("Webhook delivered")
+	return nil
+}
@@ -0,0 +1,38 @@
+package reconciler
+
+import (
+	"testing"
+
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+func TestDiffServices(t *testing.T) {
+	web := &apptypes.ContainerService{ServiceName: "web", ContainerName: "nginx", Port: "443", Protocol: "http", IPAddress: "172.17.0.2", TargetPort: "80"}
+	api := &apptypes.ContainerService{ServiceName: "api", ContainerName: "api", Port: "80", Protocol: "http", IPAddress: "172.17.0.3", TargetPort: "3000"}
+	db := &apptypes.ContainerService{ServiceName: "db", ContainerName: "postgres", Port: "5432", Protocol: "tcp", IPAddress: "172.17.0.4", TargetPort: "5432"}
+
+	previous := map[string]*apptypes.ContainerService{
+		"svc:web:443": web,
+		"svc:api:80":  api,
+	}
+	current := map[string]*apptypes.ContainerService{
+		"svc:web:443": web,
+		"svc:db:5432": db,
+	}
+
+	events := diffServices(previous, current)
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d: %+v", len(events), events)
+	}
+
+	if events[0].Event != eventServiceAdded || events[0].Service != "svc:db" || events[0].Container != "postgres" || events[0].Destination != "tcp://172.17.0.4:5432" {
+		t.Errorf("unexpected added event: %+v", events[0])
+	}
+	if events[1].Event != eventServiceRemoved || events[1].Service != "svc:api" || events[1].Destination != "http://172.17.0.3:3000" {
+		t.Errorf("unexpected removed event: %+v", events[1])
+	}
+
+	if events := diffServices(current, current); len(events) != 0 {
+		t.Errorf("expected no events for unchanged set, got %+v", events)
+	}
+}
@@ -0,0 +1,30 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/pprof"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// RunPprof serves the net/http/pprof profiling endpoints under /debug/pprof/ until the context is cancelled
+// Handlers are registered on a dedicated mux so they are never exposed on the health server
+func RunPprof(ctx context.Context, addr string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	log.Warn().Str("addr", addr).Msg("pprof server listening - do not expose this address publicly")
+	return serve(ctx, srv)
+}
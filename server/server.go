@@ -0,0 +1,308 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/marvinvr/docktail/metrics"
+	"github.com/marvinvr/docktail/reconciler"
+	"github.com/marvinvr/docktail/tailscale"
+)
+
+// Server exposes health and status endpoints over HTTP
+type Server struct {
+	httpServer *http.Server
+	reconciler *reconciler.Reconciler
+	// config is the effective configuration served by /config, secrets already redacted
+	config map[string]any
+	// controlToken guards POST /reconcile when set (CONTROL_TOKEN)
+	controlToken string
+}
+
+// New creates a new health/status server listening on addr
+// config is served as-is by /config, so secrets must be redacted by the caller
+// A non-empty controlToken must be sent as a bearer token to POST /reconcile
+func New(addr string, rec *reconciler.Reconciler, config map[string]any, controlToken string) *Server {
+	s := &Server{reconciler: rec, config: config, controlToken: controlToken}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", s.handleHealth)
+	mux.HandleFunc("GET /readyz", s.handleReady)
+	mux.HandleFunc("GET /status", s.handleStatus)
+	mux.HandleFunc("GET /status/errors", s.handleStatusErrors)
+	mux.HandleFunc("GET /config", s.handleConfig)
+	mux.HandleFunc("GET /debug/tailscale-status", s.handleTailscaleStatus)
+	mux.Handle("GET /metrics", metrics.Handler())
+	mux.HandleFunc("POST /reconcile", s.handleReconcile)
+
+	s.httpServer = &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	return s
+}
+
+// Run serves HTTP until the context is cancelled
+func (s *Server) Run(ctx context.Context) error {
+	log.Info().Str("addr", s.httpServer.Addr).Msg("Health server listening")
+	return serve(ctx, s.httpServer)
+}
+
+// serve runs an HTTP server until the context is cancelled, then shuts it down gracefully
+func serve(ctx context.Context, srv *http.Server) error {
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, _ *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("ok\n"))
+}
+
+func (s *Server) handleReady(w http.ResponseWriter, _ *http.Request) {
+	if !s.reconciler.Ready() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte("not ready\n"))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("ok\n"))
+}
+
+// statusResponse is the JSON body returned by /status
+type statusResponse struct {
+	LastReconcile *time.Time      `json:"last_reconcile"`
+	Node          string          `json:"node,omitempty"`
+	Summary       statusSummary   `json:"summary"`
+	Services      []serviceStatus `json:"services"`
+}
+
+type statusSummary struct {
+	Total     int `json:"total"`
+	Healthy   int `json:"healthy"`
+	Failing   int `json:"failing"`
+	Pending   int `json:"pending"`
+	Unhealthy int `json:"unhealthy"`
+}
+
+type serviceStatus struct {
+	ContainerID     string     `json:"container_id"`
+	ContainerName   string     `json:"container_name"`
+	ServiceName     string     `json:"service_name,omitempty"`
+	ServicePort     string     `json:"service_port,omitempty"`
+	ServiceProtocol string     `json:"service_protocol,omitempty"`
+	Protocol        string     `json:"protocol,omitempty"`
+	Destination     string     `json:"destination,omitempty"`
+	FunnelEnabled   bool       `json:"funnel_enabled"`
+	FunnelOnly      bool       `json:"funnel_only,omitempty"`
+	LastResult      string     `json:"last_result"` // "ok", "error" or "pending"
+	LastError       string     `json:"last_error,omitempty"`
+	Pending         string     `json:"pending,omitempty"`
+	LastSuccess     *time.Time `json:"last_success,omitempty"`
+	RetriesStopped  bool       `json:"retries_stopped,omitempty"`
+
+	BackendHealthy      bool       `json:"backend_healthy"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LastHealthCheck     *time.Time `json:"last_health_check,omitempty"`
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, _ *http.Request) {
+	resp := statusResponse{Node: s.reconciler.NodeName(), Services: []serviceStatus{}}
+
+	if last := s.reconciler.LastReconcile(); !last.IsZero() {
+		resp.LastReconcile = &last
+	}
+
+	for _, st := range s.reconciler.GetStatus() {
+		entry := serviceStatus{
+			ContainerID:    st.ContainerID,
+			ContainerName:  st.ContainerName,
+			LastResult:     "ok",
+			LastError:      st.LastError,
+			Pending:        st.Pending,
+			RetriesStopped: st.RetriesStopped,
+
+			BackendHealthy:      st.Healthy,
+			ConsecutiveFailures: st.ConsecutiveFailures,
+		}
+		if st.Service != nil {
+			entry.ServiceName = st.Service.ServiceName
+			entry.ServicePort = st.Service.Port
+			entry.ServiceProtocol = st.Service.ServiceProtocol
+			entry.Protocol = st.Service.Protocol
+			entry.Destination = tailscale.BuildDestination(st.Service)
+			entry.FunnelEnabled = st.Service.FunnelEnabled
+			entry.FunnelOnly = st.Service.FunnelOnly
+		}
+		if !st.LastSuccess.IsZero() {
+			lastSuccess := st.LastSuccess
+			entry.LastSuccess = &lastSuccess
+		}
+		if !st.LastHealthCheck.IsZero() {
+			lastHealthCheck := st.LastHealthCheck
+			entry.LastHealthCheck = &lastHealthCheck
+		}
+		if !st.Healthy {
+			resp.Summary.Unhealthy++
+		}
+
+		resp.Summary.Total++
+		switch {
+		case st.LastError != "":
+			entry.LastResult = "error"
+			resp.Summary.Failing++
+		case st.Pending != "":
+			entry.LastResult = "pending"
+			resp.Summary.Pending++
+		default:
+			resp.Summary.Healthy++
+		}
+		resp.Services = append(resp.Services, entry)
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// statusErrorsResponse is the JSON body returned by /status/errors
+type statusErrorsResponse struct {
+	LastReconcile *time.Time         `json:"last_reconcile"`
+	Errors        []containerProblem `json:"errors"`
+}
+
+// containerProblem is why one container is currently not served as configured
+type containerProblem struct {
+	ContainerID   string    `json:"container_id"`
+	ContainerName string    `json:"container_name"`
+	ServiceName   string    `json:"service_name,omitempty"`
+	Category      string    `json:"category"`
+	Message       string    `json:"message"`
+	LastSeen      time.Time `json:"last_seen"`
+}
+
+// handleStatusErrors lists the containers whose last parse or apply failed, for dashboards
+// A container drops off the list once it parses and applies again
+func (s *Server) handleStatusErrors(w http.ResponseWriter, _ *http.Request) {
+	resp := statusErrorsResponse{Errors: []containerProblem{}}
+	if last := s.reconciler.LastReconcile(); !last.IsZero() {
+		resp.LastReconcile = &last
+	}
+
+	for _, st := range s.reconciler.GetStatus() {
+		if st.LastError == "" {
+			continue
+		}
+		problem := containerProblem{
+			ContainerID:   st.ContainerID,
+			ContainerName: st.ContainerName,
+			Category:      st.ErrorCategory,
+			Message:       st.LastError,
+			LastSeen:      st.LastErrorTime,
+		}
+		if st.Service != nil {
+			problem.ServiceName = st.Service.ServiceName
+		}
+		resp.Errors = append(resp.Errors, problem)
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// tailscaleStatusResponse is the JSON body returned by /debug/tailscale-status
+type tailscaleStatusResponse struct {
+	Tailnets map[string]tailscale.RawStatus `json:"tailnets"`
+}
+
+// handleTailscaleStatus returns the serve and funnel status DockTail last fetched and parsed,
+// to compare with /status without running 'tailscale serve status' inside the container
+func (s *Server) handleTailscaleStatus(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, tailscaleStatusResponse{Tailnets: s.reconciler.TailscaleStatus()})
+}
+
+// handleConfig returns the configuration DockTail loaded, after env and CONFIG_FILE precedence
+func (s *Server) handleConfig(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, s.config)
+}
+
+// reconcileResponse is the JSON body returned by POST /reconcile
+type reconcileResponse struct {
+	Success    bool   `json:"success"`
+	Error      string `json:"error,omitempty"`
+	Enabled    int    `json:"enabled"`
+	Created    int    `json:"created"`
+	Updated    int    `json:"updated"`
+	Removed    int    `json:"removed"`
+	Errored    int    `json:"errored"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// handleReconcile runs a reconciliation now and reports what it changed
+func (s *Server) handleReconcile(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeJSON(w, http.StatusUnauthorized, reconcileResponse{Error: "missing or invalid control token"})
+		return
+	}
+
+	summary, err := s.reconciler.ReconcileNow(r.Context())
+	resp := reconcileResponse{
+		Success:    err == nil,
+		Enabled:    summary.Enabled,
+		Created:    summary.Created,
+		Updated:    summary.Updated,
+		Removed:    summary.Removed,
+		Errored:    summary.Errored,
+		DurationMs: summary.Duration.Milliseconds(),
+	}
+
+	status := http.StatusOK
+	switch {
+	case err == nil:
+	case errors.Is(err, reconciler.ErrPaused):
+		status = http.StatusConflict
+	case errors.Is(err, tailscale.ErrTailscaledUnavailable), errors.Is(err, reconciler.ErrStopped):
+		status = http.StatusServiceUnavailable
+	default:
+		status = http.StatusInternalServerError
+	}
+	if err != nil {
+		resp.Error = err.Error()
+	}
+	writeJSON(w, status, resp)
+}
+
+// authorized checks the request's bearer token against CONTROL_TOKEN (always true when unset)
+func (s *Server) authorized(r *http.Request) bool {
+	if s.controlToken == "" {
+		return true
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.controlToken)) == 1
+}
+
+// writeJSON writes v as an indented JSON response
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		log.Debug().Err(err).Msg("Failed to write JSON response")
+	}
+}
@@ -0,0 +1,39 @@
+package tailscale
+
+import (
+	"errors"
+	"fmt"
+
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+// ErrBasicAuthUnsupported is reported for services with docktail.service.basic-auth.* labels
+// while tailscale serve can't enforce them
+var ErrBasicAuthUnsupported = errors.New("basic auth is not supported by tailscale serve")
+
+// serveSupportsBasicAuth reports whether tailscale serve can require HTTP basic auth
+// No CLI release has a flag or serve config field for it, so services asking for it are refused
+// instead of being exposed without the protection they were labelled with
+const serveSupportsBasicAuth = false
+
+// withoutUnsupportedAuth drops desired services that require basic auth, recording an error for each
+// affected container; any serve config they already have is removed like that of a stopped container
+func withoutUnsupportedAuth(desired []*apptypes.ContainerService, result *ReconcileResult) []*apptypes.ContainerService {
+	if serveSupportsBasicAuth {
+		return desired
+	}
+
+	kept := make([]*apptypes.ContainerService, 0, len(desired))
+	for _, svc := range desired {
+		if svc.BasicAuth == nil {
+			kept = append(kept, svc)
+			continue
+		}
+		log.Warn().
+			Str("service", svc.ServiceName).
+			Str("container", svc.ContainerName).
+			Msg("Service requires basic auth, which tailscale serve can't enforce; not serving it")
+		result.Failed[svc.ContainerID] = fmt.Errorf("%w: remove the docktail.service.basic-auth labels from %s or put an authenticating proxy in front of it (docktail.service.allowed-tags can restrict who reaches the service instead)", ErrBasicAuthUnsupported, svc.ContainerName)
+	}
+	return kept
+}
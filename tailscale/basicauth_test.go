@@ -0,0 +1,26 @@
+package tailscale
+
+import (
+	"errors"
+	"testing"
+
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+func TestWithoutUnsupportedAuth(t *testing.T) {
+	web := &apptypes.ContainerService{ContainerID: "web", ContainerName: "web", ServiceName: "web"}
+	admin := &apptypes.ContainerService{ContainerID: "admin", ContainerName: "admin", ServiceName: "admin", BasicAuth: &apptypes.BasicAuth{User: "admin"}}
+	result := &ReconcileResult{Failed: make(map[string]error)}
+
+	kept := withoutUnsupportedAuth([]*apptypes.ContainerService{web, admin}, result)
+
+	if len(kept) != 1 || kept[0] != web {
+		t.Errorf("expected only web to be kept, got %v", kept)
+	}
+	if !errors.Is(result.Failed["admin"], ErrBasicAuthUnsupported) {
+		t.Errorf("admin error = %v, want ErrBasicAuthUnsupported", result.Failed["admin"])
+	}
+	if result.Failed["web"] != nil {
+		t.Errorf("web should not fail, got %v", result.Failed["web"])
+	}
+}
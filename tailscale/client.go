@@ -4,14 +4,17 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/rs/zerolog/log"
+	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/clientcredentials"
 
 	apptypes "github.com/marvinvr/docktail/types"
@@ -21,18 +24,52 @@ import (
 type Client struct {
 	socketPath     string
 	tailnet        string
+	nodeName       string
+	version        string
 	baseURL        string
 	httpClient     *http.Client
+	limiter        *apiLimiter
 	apiSyncEnabled bool
+	// tokens caches the OAuth access token (nil unless OAuth credentials are configured)
+	tokens   *cachedTokenSource
+	retryMax int
+	// traceCLI logs every CLI invocation with its raw output (TRACE_TAILSCALE)
+	traceCLI bool
+
+	allowServiceOverwrite bool
+	// knownManaged caches service names confirmed to be DockTail's, so collisions are checked once
+	knownManaged map[string]bool
+
+	// aclSync allows writing docktail.service.allowed-tags grants to the policy file
+	aclSync bool
+	// advisoryLogged records services whose allowed tags were reported as advisory, to log them once
+	advisoryLogged map[string]bool
+
+	// adoptOrphans removes DockTail services left by a previous run that no container claims
+	adoptOrphans   bool
+	orphansChecked bool
+	// preserved holds unclaimed services found on startup that are kept until a container claims them
+	preserved map[string]bool
+
+	// raw is the last parsed serve and funnel status, served by /debug/tailscale-status
+	rawMu sync.Mutex
+	raw   RawStatus
 }
 
 // ClientConfig holds configuration for creating a Tailscale client
 type ClientConfig struct {
-	SocketPath        string
-	Tailnet           string
-	APIKey            string
-	OAuthClientID     string
-	OAuthClientSecret string
+	SocketPath            string
+	Tailnet               string
+	APIKey                string
+	OAuthClientID         string
+	OAuthClientSecret     string
+	RetryMax              int     // Max attempts for transient serve/funnel CLI failures
+	APIRate               float64 // Average Tailscale API requests per second (0 = unlimited)
+	ACLSync               bool    // Write docktail.service.allowed-tags grants to the policy file (rewrites it as JSON)
+	AdoptOrphans          bool    // Remove DockTail services left by a previous run on startup
+	TraceCLI              bool    // Log every CLI command line and its raw output at debug level
+	AllowServiceOverwrite bool    // Serve services whose name belongs to a manually created service definition
+	NodeName              string  // Name of the node behind SocketPath, instead of asking tailscaled (empty = ask)
 }
 
 // NewClient creates a new Tailscale client
@@ -41,9 +78,23 @@ func NewClient(cfg ClientConfig) *Client {
 	client := &Client{
 		socketPath: cfg.SocketPath,
 		tailnet:    cfg.Tailnet,
+		nodeName:   cfg.NodeName,
 		baseURL:    "https://api.tailscale.com",
+		retryMax:   cfg.RetryMax,
+		traceCLI:   cfg.TraceCLI,
+		limiter:    newAPILimiter(cfg.APIRate),
+
+		allowServiceOverwrite: cfg.AllowServiceOverwrite,
+		knownManaged:          make(map[string]bool),
+		aclSync:               cfg.ACLSync,
+		advisoryLogged:        make(map[string]bool),
+		adoptOrphans:          cfg.AdoptOrphans,
+		preserved:             make(map[string]bool),
 	}
 
+	// One pooled transport for every API call, so keep-alive connections are reused across calls
+	transport := newAPITransport()
+
 	// Prefer OAuth over API key
 	if cfg.OAuthClientID != "" && cfg.OAuthClientSecret != "" {
 		oauthConfig := &clientcredentials.Config{
@@ -51,23 +102,27 @@ func NewClient(cfg ClientConfig) *Client {
 			ClientSecret: cfg.OAuthClientSecret,
 			TokenURL:     "https://api.tailscale.com/api/v2/oauth/token",
 		}
-		// The oauth2 client handles token refresh automatically
-		client.httpClient = oauthConfig.Client(context.Background())
-		client.httpClient.Timeout = 10 * time.Second
+		// Reuse one access token across reconciliations, refreshing it shortly before expiry
+		client.tokens = newCachedTokenSource(oauthConfig)
+		client.httpClient = &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: &oauth2.Transport{Source: client.tokens, Base: transport},
+		}
 		client.apiSyncEnabled = true
 		log.Info().Msg("Tailscale API: using OAuth client credentials")
 	} else if cfg.APIKey != "" {
 		// Fall back to API key with custom transport
 		client.httpClient = &http.Client{
 			Timeout:   10 * time.Second,
-			Transport: &apiKeyTransport{apiKey: cfg.APIKey},
+			Transport: &apiKeyTransport{apiKey: cfg.APIKey, base: transport},
 		}
 		client.apiSyncEnabled = true
 		log.Info().Msg("Tailscale API: using API key")
 	} else {
 		// No API credentials - API sync disabled
 		client.httpClient = &http.Client{
-			Timeout: 10 * time.Second,
+			Timeout:   10 * time.Second,
+			Transport: transport,
 		}
 		client.apiSyncEnabled = false
 		log.Info().Msg("Tailscale API: no credentials configured, control plane sync disabled")
@@ -76,14 +131,23 @@ func NewClient(cfg ClientConfig) *Client {
 	return client
 }
 
+// newAPITransport returns the transport shared by all of a client's API calls
+// It keeps enough idle connections per host for the concurrent shutdown cleanup to reuse them
+func newAPITransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = cleanupConcurrency
+	return transport
+}
+
 // apiKeyTransport adds the API key as a Bearer token to requests
 type apiKeyTransport struct {
 	apiKey string
+	base   http.RoundTripper
 }
 
 func (t *apiKeyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	req.Header.Set("Authorization", "Bearer "+t.apiKey)
-	return http.DefaultTransport.RoundTrip(req)
+	return t.base.RoundTrip(req)
 }
 
 // ServiceEndpoint represents a single endpoint for comparison
@@ -114,25 +178,44 @@ type TailscaleWebConfig struct {
 }
 
 type TailscaleHandler struct {
-	Proxy string `json:"Proxy"`
+	Proxy    string `json:"Proxy"`
+	Redirect string `json:"Redirect,omitempty"`
+	Path     string `json:"Path,omitempty"`
+}
+
+// ReconcileResult holds the per-container outcome of a ReconcileServices call
+type ReconcileResult struct {
+	Failed map[string]error // container ID -> error from applying its service
 }
 
 // ReconcileServices compares desired services with current services and makes necessary changes
-func (c *Client) ReconcileServices(ctx context.Context, desiredServices []*apptypes.ContainerService) error {
+// The returned result is always non-nil, even when an error is returned
+func (c *Client) ReconcileServices(ctx context.Context, desiredServices []*apptypes.ContainerService) (*ReconcileResult, error) {
+	result := &ReconcileResult{Failed: make(map[string]error)}
+
 	log.Info().
 		Int("desired_count", len(desiredServices)).
 		Msg("Starting service reconciliation using CLI commands")
 
-	// Build map of desired services for easy lookup
-	desiredMap := make(map[string]*apptypes.ContainerService)
-	for _, svc := range desiredServices {
-		key := fmt.Sprintf("svc:%s:%s", svc.ServiceName, svc.Port)
-		desiredMap[key] = svc
-	}
+	// Basic auth can't be enforced yet, so those services must not be exposed without it
+	desiredServices = withoutUnsupportedAuth(desiredServices, result)
+	// Neither can TCP timeouts, and serving with other timeouts than labelled would be just as surprising
+	desiredServices = withoutUnsupportedTimeouts(desiredServices, result)
+
+	// Funnel-only services get no serve config or service definition, only their funnels
+	served, funnelOnly := splitFunnelOnly(desiredServices)
+
+	// Never take over a service someone configured by hand
+	served, collided := c.withoutCollisions(ctx, served, result)
+
+	desiredMap := desiredServiceMap(served)
 
 	// Get current services
 	currentServices, err := c.GetCurrentServices(ctx)
-	if err != nil {
+	if errors.Is(err, ErrTailscaledUnavailable) {
+		// Every serve command would fail too, so don't attempt any
+		return result, err
+	} else if err != nil {
 		log.Warn().Err(err).Msg("Failed to get current services, will apply all desired services")
 		currentServices = make(map[string]ServiceEndpoint)
 	}
@@ -141,56 +224,23 @@ func (c *Client) ReconcileServices(ctx context.Context, desiredServices []*appty
 		Int("current_service_count", len(currentServices)).
 		Msg("Retrieved current service state from Tailscale")
 
-	// Track what we need to add and remove
-	toAdd := make(map[string]*apptypes.ContainerService)
-	toRemove := make(map[string]ServiceEndpoint)
-
-	// Find services to add (in desired but not in current, or changed)
-	for key, desired := range desiredMap {
-		if current, exists := currentServices[key]; !exists {
-			// Service doesn't exist - add it
-			toAdd[key] = desired
-			log.Debug().
-				Str("key", key).
-				Str("service", desired.ServiceName).
-				Msg("Service not found in current state, will add")
-		} else {
-			// Service exists - check if configuration changed
-			expectedDest := buildDestination(desired)
-			if current.Destination != expectedDest || current.Protocol != desired.ServiceProtocol {
-				toAdd[key] = desired
-				log.Info().
-					Str("key", key).
-					Str("service", desired.ServiceName).
-					Str("current_dest", current.Destination).
-					Str("expected_dest", expectedDest).
-					Str("current_protocol", current.Protocol).
-					Str("expected_protocol", desired.ServiceProtocol).
-					Msg("Service configuration changed, will update")
-			} else {
-				// Service exists and matches - no action needed
-				log.Debug().
-					Str("key", key).
-					Str("service", desired.ServiceName).
-					Str("protocol", current.Protocol).
-					Str("destination", current.Destination).
-					Msg("Service already exists with correct configuration, skipping")
-			}
-		}
+	if !c.orphansChecked && err == nil {
+		c.checkOrphans(ctx, desiredMap, currentServices)
 	}
 
-	// Find services to remove (in current but not in desired)
-	for key, current := range currentServices {
-		if _, exists := desiredMap[key]; !exists {
-			toRemove[key] = current
-		}
-	}
+	toAdd, toRemove := planServices(desiredMap, currentServices, c.keptServices(desiredMap, collided))
 
 	log.Info().
 		Int("to_add", len(toAdd)).
 		Int("to_remove", len(toRemove)).
 		Msg("Calculated reconciliation actions")
 
+	// Services that keep other ports (e.g. a dropped https-redirect) only lose the stale port
+	stillDesired := make(map[string]bool)
+	for _, svc := range desiredMap {
+		stillDesired["svc:"+svc.ServiceName] = true
+	}
+
 	// Remove old services first
 	for key, svc := range toRemove {
 		log.Info().
@@ -198,7 +248,13 @@ func (c *Client) ReconcileServices(ctx context.Context, desiredServices []*appty
 			Str("port", svc.Port).
 			Msg("Removing service")
 
-		if err := c.removeService(ctx, svc.ServiceName); err != nil {
+		var err error
+		if stillDesired[svc.ServiceName] {
+			err = c.removeServicePort(ctx, svc)
+		} else {
+			err = c.removeService(ctx, svc.ServiceName)
+		}
+		if err != nil {
 			log.Error().
 				Err(err).
 				Str("service", svc.ServiceName).
@@ -228,6 +284,7 @@ func (c *Client) ReconcileServices(ctx context.Context, desiredServices []*appty
 
 		if err := c.addService(ctx, svc); err != nil {
 			failCount++
+			result.Failed[svc.ContainerID] = err
 			log.Error().
 				Err(err).
 				Str("service", svc.ServiceName).
@@ -251,27 +308,107 @@ func (c *Client) ReconcileServices(ctx context.Context, desiredServices []*appty
 		Msg("Service reconciliation completed")
 
 	if failCount > 0 {
-		return fmt.Errorf("failed to add %d services", failCount)
+		return result, fmt.Errorf("failed to add %d services", failCount)
 	}
 
 	// Reconcile funnel configuration (independent of serve)
 	// Funnel and serve are separate features that can be used together or independently
-	if err := c.reconcileFunnels(ctx, desiredServices); err != nil {
+	if err := c.reconcileFunnels(ctx, append(served, funnelOnly...)); err != nil {
 		log.Error().Err(err).Msg("Failed to reconcile funnel configurations")
-		return fmt.Errorf("funnel reconciliation failed: %w", err)
+		return result, fmt.Errorf("funnel reconciliation failed: %w", err)
 	}
 
 	// Sync Service Definitions to Control Plane (API)
 	// This is done after local serve commands to ensure local state is consistent first,
 	// but failures here are non-blocking for the local advertisement.
 	if c.apiSyncEnabled {
-		if err := c.syncServiceDefinitions(ctx, desiredServices); err != nil {
+		if err := c.syncServiceDefinitions(ctx, served); err != nil {
 			// Log error but do NOT return it - we don't want API failures to break local serving
 			log.Error().Err(err).Msg("Failed to sync service definitions to Tailscale API")
 		}
 	}
+	c.syncServiceGrants(ctx, served)
 
-	return nil
+	return result, nil
+}
+
+// splitFunnelOnly separates funnel-only services, which skip serve, from the ones to serve
+func splitFunnelOnly(desiredServices []*apptypes.ContainerService) ([]*apptypes.ContainerService, []*apptypes.ContainerService) {
+	var served, funnelOnly []*apptypes.ContainerService
+	for _, svc := range desiredServices {
+		if svc.FunnelOnly {
+			funnelOnly = append(funnelOnly, svc)
+		} else {
+			served = append(served, svc)
+		}
+	}
+	return served, funnelOnly
+}
+
+// desiredServiceMap keys the desired services by svc:<name>:<port>, adding https-redirect entries
+func desiredServiceMap(desiredServices []*apptypes.ContainerService) map[string]*apptypes.ContainerService {
+	desiredMap := make(map[string]*apptypes.ContainerService)
+	for _, svc := range desiredServices {
+		key := fmt.Sprintf("svc:%s:%s", svc.ServiceName, svc.Port)
+		desiredMap[key] = svc
+		if svc.HTTPSRedirect {
+			redirect := redirectEntry(svc)
+			desiredMap[fmt.Sprintf("svc:%s:%s", redirect.ServiceName, redirect.Port)] = redirect
+		}
+	}
+	return desiredMap
+}
+
+// planServices diffs the desired services against the current serve config
+// Anything served that is no longer desired is removed, whether its container stopped, was removed
+// or is still running with docktail.service.enable no longer true; kept services are left alone
+func planServices(desiredMap map[string]*apptypes.ContainerService, currentServices map[string]ServiceEndpoint, kept map[string]bool) (map[string]*apptypes.ContainerService, map[string]ServiceEndpoint) {
+	toAdd := make(map[string]*apptypes.ContainerService)
+	toRemove := make(map[string]ServiceEndpoint)
+
+	// Find services to add (in desired but not in current, or changed)
+	for key, desired := range desiredMap {
+		if current, exists := currentServices[key]; !exists {
+			// Service doesn't exist - add it
+			toAdd[key] = desired
+			log.Debug().
+				Str("key", key).
+				Str("service", desired.ServiceName).
+				Msg("Service not found in current state, will add")
+		} else {
+			// Service exists - re-apply only if its serve configuration changed
+			if currentHash, expectedHash := endpointHash(current), configHash(desired); currentHash != expectedHash {
+				toAdd[key] = desired
+				log.Info().
+					Str("key", key).
+					Str("service", desired.ServiceName).
+					Str("current_dest", current.Destination).
+					Str("expected_dest", BuildDestination(desired)).
+					Str("current_protocol", current.Protocol).
+					Str("expected_protocol", desired.ServiceProtocol).
+					Str("current_hash", currentHash).
+					Str("expected_hash", expectedHash).
+					Msg("Service configuration changed, will update")
+			} else {
+				// Service exists and matches - no action needed
+				log.Debug().
+					Str("key", key).
+					Str("service", desired.ServiceName).
+					Str("protocol", current.Protocol).
+					Str("destination", current.Destination).
+					Msg("Service already exists with correct configuration, skipping")
+			}
+		}
+	}
+
+	// Find services to remove (in current but not in desired)
+	for key, current := range currentServices {
+		if _, exists := desiredMap[key]; !exists && !kept[current.ServiceName] {
+			toRemove[key] = current
+		}
+	}
+
+	return toAdd, toRemove
 }
 
 // syncServiceDefinitions syncs all desired services to the Tailscale Control Plane
@@ -279,8 +416,9 @@ func (c *Client) syncServiceDefinitions(ctx context.Context, services []*apptype
 	// Deduplicate by service name - we only need to upsert each service definition once
 	// We also need to capture the port to send to the API
 	type serviceDef struct {
-		Tags []string
-		Port string
+		Tags    []string
+		Ports   []string
+		Comment string
 	}
 	uniqueServices := make(map[string]serviceDef)
 
@@ -288,9 +426,19 @@ func (c *Client) syncServiceDefinitions(ctx context.Context, services []*apptype
 		// If multiple containers share a service name, we use the tags/port from the last one seen.
 		// In a consistent config, they should be identical.
 		// Note: svc.Port is the "service-port" (Tailscale side), not the container port.
+		ports := []string{svc.Port}
+		if svc.HTTPSRedirect {
+			ports = append(ports, redirectPort)
+		}
+		// Funnel has no tags of its own; public services get their funnel tags on the service definition
+		tags := svc.Tags
+		if svc.FunnelEnabled && len(svc.FunnelTags) > 0 {
+			tags = svc.FunnelTags
+		}
 		uniqueServices[svc.ServiceName] = serviceDef{
-			Tags: svc.Tags,
-			Port: svc.Port,
+			Tags:    tags,
+			Ports:   ports,
+			Comment: svc.Comment,
 		}
 	}
 
@@ -300,7 +448,7 @@ func (c *Client) syncServiceDefinitions(ctx context.Context, services []*apptype
 
 	var failed []string
 	for name, def := range uniqueServices {
-		if err := c.SyncServiceDefinition(ctx, name, def.Tags, def.Port); err != nil {
+		if err := c.SyncServiceDefinition(ctx, name, def.Tags, def.Ports, def.Comment); err != nil {
 			failed = append(failed, name)
 			log.Error().
 				Err(err).
@@ -319,7 +467,7 @@ func (c *Client) syncServiceDefinitions(ctx context.Context, services []*apptype
 
 // SyncServiceDefinition ensures a service definition exists in the Tailscale API.
 // Only creates if the service doesn't exist. Does NOT update existing services.
-func (c *Client) SyncServiceDefinition(ctx context.Context, serviceName string, tags []string, port string) error {
+func (c *Client) SyncServiceDefinition(ctx context.Context, serviceName string, tags []string, ports []string, comment string) error {
 	if !strings.HasPrefix(serviceName, "svc:") {
 		serviceName = "svc:" + serviceName
 	}
@@ -349,17 +497,21 @@ func (c *Client) SyncServiceDefinition(ctx context.Context, serviceName string,
 	apiURL := fmt.Sprintf("%s/api/v2/tailnet/%s/services/%s", c.baseURL, url.PathEscape(c.tailnet), url.PathEscape(serviceName))
 
 	// Tailscale API requires "ports" to be present.
-	if port == "" {
-		port = "443"
+	if len(ports) == 0 {
+		ports = []string{"443"}
 	}
 
 	// Tailscale API requires prefix for creation
-	portStr := fmt.Sprintf("tcp:%s", port)
+	portStrs := make([]string, 0, len(ports))
+	for _, port := range ports {
+		portStrs = append(portStrs, fmt.Sprintf("tcp:%s", port))
+	}
 
 	payload := map[string]interface{}{
-		"name":  serviceName,
-		"tags":  tags,
-		"ports": []string{portStr},
+		"name":    serviceName,
+		"tags":    tags,
+		"ports":   portStrs,
+		"comment": withManagedMarker(comment),
 	}
 
 	body, err := json.Marshal(payload)
@@ -380,11 +532,11 @@ func (c *Client) SyncServiceDefinition(ctx context.Context, serviceName string,
 		RawJSON("payload", body).
 		Msg("Sending Control Plane request")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.doAPI(req)
 	if err != nil {
 		return fmt.Errorf("request failed: %w", err)
 	}
-	defer func() { _ = resp.Body.Close() }()
+	defer closeBody(resp)
 
 	if resp.StatusCode != http.StatusOK {
 		respBody, _ := io.ReadAll(resp.Body)
@@ -404,9 +556,10 @@ func (c *Client) SyncServiceDefinition(ctx context.Context, serviceName string,
 }
 
 type apiService struct {
-	Addrs []string `json:"addrs"`
-	Tags  []string `json:"tags"`
-	Ports []string `json:"ports"`
+	Addrs   []string `json:"addrs"`
+	Tags    []string `json:"tags"`
+	Ports   []string `json:"ports"`
+	Comment string   `json:"comment"`
 }
 
 // getService fetches the existing service definition from the Tailscale API
@@ -424,11 +577,11 @@ func (c *Client) getService(ctx context.Context, serviceName string) (*apiServic
 		Str("url", apiURL).
 		Msg("Fetching existing service definition")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.doAPI(req)
 	if err != nil {
 		return nil, fmt.Errorf("GET request failed: %w", err)
 	}
-	defer func() { _ = resp.Body.Close() }()
+	defer closeBody(resp)
 
 	if resp.StatusCode == http.StatusNotFound {
 		return nil, nil
@@ -447,34 +600,40 @@ func (c *Client) getService(ctx context.Context, serviceName string) (*apiServic
 	return &svc, nil
 }
 
+// cleanupConcurrency bounds how many services are drained and cleared in parallel during shutdown
+const cleanupConcurrency = 4
+
 // CleanupAllServices removes all services and funnels managed by DockTail
 // This is called on shutdown to ensure no orphaned services remain advertised
+// Services are removed concurrently; any that can't be removed before the context deadline
+// are listed in the returned error
 func (c *Client) CleanupAllServices(ctx context.Context) error {
 	log.Info().Msg("Starting cleanup: removing all managed Tailscale services and funnels")
 
-	var totalErrors []error
+	var totalErrors []string
 
 	// Cleanup funnels first (independent of services)
+	// A single reset clears every funnel port
 	currentFunnels, err := c.getCurrentFunnels(ctx)
 	if err != nil {
 		log.Warn().Err(err).Msg("Failed to get current funnels for cleanup, continuing with service cleanup")
 	} else if len(currentFunnels) > 0 {
-		log.Info().
-			Int("funnel_count", len(currentFunnels)).
-			Msg("Found funnels to clean up")
+		ports := make([]string, 0, len(currentFunnels))
+		for port := range currentFunnels {
+			ports = append(ports, port)
+		}
+		sort.Strings(ports)
 
-		for _, port := range currentFunnels {
-			log.Info().
-				Str("public_port", port).
-				Msg("Cleaning up funnel")
+		log.Info().
+			Strs("public_ports", ports).
+			Msg("Cleaning up funnels")
 
-			if err := c.removeFunnel(ctx, "cleanup", port); err != nil {
-				log.Error().
-					Err(err).
-					Str("public_port", port).
-					Msg("Failed to clean up funnel")
-				totalErrors = append(totalErrors, err)
-			}
+		if err := c.removeFunnel(ctx, "cleanup", strings.Join(ports, ",")); err != nil {
+			log.Error().
+				Err(err).
+				Strs("public_ports", ports).
+				Msg("Failed to clean up funnels")
+			totalErrors = append(totalErrors, fmt.Sprintf("funnels %s: %v", strings.Join(ports, ","), err))
 		}
 	}
 
@@ -485,50 +644,79 @@ func (c *Client) CleanupAllServices(ctx context.Context) error {
 		return err
 	}
 
-	if len(currentServices) == 0 {
+	// A service with several ports has one entry per port but is cleared as a whole
+	serviceNames := make(map[string]bool)
+	for _, svc := range currentServices {
+		serviceNames[svc.ServiceName] = true
+	}
+
+	if len(serviceNames) == 0 {
 		log.Info().Msg("No services to clean up")
 		if len(totalErrors) > 0 {
-			return fmt.Errorf("cleanup completed with %d funnel errors", len(totalErrors))
+			return fmt.Errorf("cleanup failed for: %s", strings.Join(totalErrors, "; "))
 		}
 		return nil
 	}
 
 	log.Info().
-		Int("service_count", len(currentServices)).
+		Int("service_count", len(serviceNames)).
 		Msg("Found services to clean up")
 
-	// Remove each service (drain + clear)
-	successCount := 0
-	failCount := 0
+	// Remove each service (drain + clear), a bounded number at a time
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		failed []string
+	)
+	sem := make(chan struct{}, cleanupConcurrency)
+
+	for name := range serviceNames {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				mu.Lock()
+				failed = append(failed, fmt.Sprintf("%s: %v", name, ctx.Err()))
+				mu.Unlock()
+				return
+			}
 
-	for _, svc := range currentServices {
-		log.Info().
-			Str("service", svc.ServiceName).
-			Str("port", svc.Port).
-			Str("protocol", svc.Protocol).
-			Msg("Cleaning up service")
+			log.Info().
+				Str("service", name).
+				Msg("Cleaning up service")
 
-		if err := c.removeService(ctx, svc.ServiceName); err != nil {
-			failCount++
-			log.Error().
-				Err(err).
-				Str("service", svc.ServiceName).
-				Msg("Failed to clean up service")
-			totalErrors = append(totalErrors, err)
-		} else {
-			successCount++
-		}
+			err := c.removeService(ctx, name)
+			if err == nil && ctx.Err() != nil {
+				err = ctx.Err()
+			}
+			if err != nil {
+				log.Error().
+					Err(err).
+					Str("service", name).
+					Msg("Failed to clean up service")
+				mu.Lock()
+				failed = append(failed, fmt.Sprintf("%s: %v", name, err))
+				mu.Unlock()
+			}
+		}(name)
 	}
+	wg.Wait()
+
+	sort.Strings(failed)
+	totalErrors = append(totalErrors, failed...)
 
 	log.Info().
-		Int("services_cleaned", successCount).
-		Int("services_failed", failCount).
-		Int("funnels_cleaned", len(currentFunnels)-len(totalErrors)).
+		Int("services_cleaned", len(serviceNames)-len(failed)).
+		Int("services_failed", len(failed)).
 		Int("total_errors", len(totalErrors)).
 		Msg("Cleanup completed")
 
 	if len(totalErrors) > 0 {
-		return fmt.Errorf("cleanup completed with %d errors", len(totalErrors))
+		return fmt.Errorf("cleanup failed for %d item(s): %s", len(totalErrors), strings.Join(totalErrors, "; "))
 	}
 
 	return nil
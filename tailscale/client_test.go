@@ -0,0 +1,99 @@
+package tailscale
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+func TestPlanServicesEnableToggledOff(t *testing.T) {
+	web := &apptypes.ContainerService{ContainerID: "web", ServiceName: "web", Port: "443", ServiceProtocol: "https", Protocol: "http", IPAddress: "172.17.0.2", TargetPort: "80"}
+	served := ServiceEndpoint{ServiceName: "svc:web", Port: "443", Protocol: "https", Destination: BuildDestination(web)}
+
+	// docktail.service.enable=true: the running container is served
+	toAdd, toRemove := planServices(desiredServiceMap([]*apptypes.ContainerService{web}), map[string]ServiceEndpoint{}, nil)
+	if toAdd["svc:web:443"] == nil || len(toRemove) != 0 {
+		t.Fatalf("enabled container: expected svc:web:443 to be added, got add=%v remove=%v", toAdd, toRemove)
+	}
+
+	// docktail.service.enable=false on the still-running container: it drops out of the desired set
+	current := map[string]ServiceEndpoint{"svc:web:443": served}
+	toAdd, toRemove = planServices(desiredServiceMap(nil), current, nil)
+	if _, ok := toRemove["svc:web:443"]; !ok || len(toAdd) != 0 {
+		t.Errorf("disabled container: expected svc:web:443 to be removed, got add=%v remove=%v", toAdd, toRemove)
+	}
+
+	// Unchanged and still enabled: nothing to do
+	toAdd, toRemove = planServices(desiredServiceMap([]*apptypes.ContainerService{web}), current, nil)
+	if len(toAdd) != 0 || len(toRemove) != 0 {
+		t.Errorf("unchanged container: expected no changes, got add=%v remove=%v", toAdd, toRemove)
+	}
+
+	// A hand-configured service with the same name is never removed
+	_, toRemove = planServices(desiredServiceMap(nil), current, map[string]bool{"svc:web": true})
+	if len(toRemove) != 0 {
+		t.Errorf("collided service: expected no removal, got %v", toRemove)
+	}
+}
+
+func TestCheckOrphans(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/svc:manual"):
+			_ = json.NewEncoder(w).Encode(apiService{Comment: "hand-made"})
+		case strings.HasSuffix(r.URL.Path, "/svc:stale"):
+			_ = json.NewEncoder(w).Encode(apiService{Comment: "managed by docktail: old"})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	current := map[string]ServiceEndpoint{
+		"svc:web:443":    {ServiceName: "svc:web", Port: "443", Protocol: "https", Destination: "http://localhost:8080"},
+		"svc:stale:443":  {ServiceName: "svc:stale", Port: "443", Protocol: "https", Destination: "http://localhost:8081"},
+		"svc:manual:443": {ServiceName: "svc:manual", Port: "443", Protocol: "https", Destination: "http://localhost:8082"},
+	}
+	desired := desiredServiceMap([]*apptypes.ContainerService{
+		{ServiceName: "web", Port: "443", ServiceProtocol: "https", Protocol: "http", IPAddress: "localhost", TargetPort: "8080"},
+	})
+
+	tests := []struct {
+		name         string
+		adoptOrphans bool
+		wantRemoved  []string
+	}{
+		{"orphans adopted", true, []string{"svc:stale:443"}},
+		{"orphans kept", false, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{
+				tailnet:        "-",
+				baseURL:        server.URL,
+				httpClient:     server.Client(),
+				apiSyncEnabled: true,
+				knownManaged:   make(map[string]bool),
+				adoptOrphans:   tt.adoptOrphans,
+				preserved:      make(map[string]bool),
+			}
+			c.checkOrphans(context.Background(), desired, current)
+
+			_, toRemove := planServices(desired, current, c.keptServices(desired, nil))
+			var removed []string
+			for key := range toRemove {
+				removed = append(removed, key)
+			}
+			if !reflect.DeepEqual(removed, tt.wantRemoved) {
+				t.Errorf("expected %v removed, got %v", tt.wantRemoved, removed)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package tailscale
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+// managedMarker is carried in the comment of every service definition DockTail creates
+// It is how a DockTail-created service is told apart from one configured by hand
+const managedMarker = "managed by docktail"
+
+// withManagedMarker ensures a service comment carries the managed marker
+func withManagedMarker(comment string) string {
+	if strings.Contains(comment, managedMarker) {
+		return comment
+	}
+	if comment == "" {
+		return managedMarker
+	}
+	return comment + " (" + managedMarker + ")"
+}
+
+// withoutCollisions drops desired services whose name belongs to a service definition
+// that DockTail did not create, recording an error for each affected container
+// The colliding names are returned (as svc:<name>) so their local serve config is left alone too
+// Only possible with API credentials; without them every svc: service is treated as managed
+func (c *Client) withoutCollisions(ctx context.Context, desired []*apptypes.ContainerService, result *ReconcileResult) ([]*apptypes.ContainerService, map[string]bool) {
+	collided := make(map[string]bool)
+	if !c.apiSyncEnabled || c.allowServiceOverwrite {
+		return desired, collided
+	}
+
+	collisions := make(map[string]error)
+	checked := make(map[string]bool)
+	for _, svc := range desired {
+		if checked[svc.ServiceName] || c.knownManaged[svc.ServiceName] {
+			continue
+		}
+		checked[svc.ServiceName] = true
+
+		existing, err := c.getService(ctx, "svc:"+svc.ServiceName)
+		if err != nil {
+			// Don't block serving on API trouble; the next loop checks again
+			log.Warn().
+				Err(err).
+				Str("service", svc.ServiceName).
+				Msg("Could not check service for collisions, proceeding")
+			continue
+		}
+		if existing == nil || strings.Contains(existing.Comment, managedMarker) {
+			// Either DockTail will create it or already did
+			c.knownManaged[svc.ServiceName] = true
+			continue
+		}
+
+		collisions[svc.ServiceName] = fmt.Errorf("service svc:%s already exists in the tailnet and was not created by DockTail; "+
+			"rename the service or set ALLOW_SERVICE_OVERWRITE=true to take it over", svc.ServiceName)
+		log.Error().
+			Str("service", svc.ServiceName).
+			Str("existing_comment", existing.Comment).
+			Msg("Service name collides with a manually created service, skipping")
+	}
+
+	if len(collisions) == 0 {
+		return desired, collided
+	}
+
+	filtered := make([]*apptypes.ContainerService, 0, len(desired))
+	for _, svc := range desired {
+		if err, ok := collisions[svc.ServiceName]; ok {
+			result.Failed[svc.ContainerID] = err
+			collided["svc:"+svc.ServiceName] = true
+			continue
+		}
+		filtered = append(filtered, svc)
+	}
+	return filtered, collided
+}
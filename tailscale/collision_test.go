@@ -0,0 +1,81 @@
+package tailscale
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+func TestWithManagedMarker(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"", "managed by docktail"},
+		{"managed by docktail: nginx", "managed by docktail: nginx"},
+		{"Company wiki", "Company wiki (managed by docktail)"},
+	}
+
+	for _, tt := range tests {
+		if got := withManagedMarker(tt.input); got != tt.expected {
+			t.Errorf("withManagedMarker(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestWithoutCollisions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/svc:manual"):
+			_ = json.NewEncoder(w).Encode(apiService{Comment: "hand-made"})
+		case strings.HasSuffix(r.URL.Path, "/svc:ours"):
+			_ = json.NewEncoder(w).Encode(apiService{Comment: "managed by docktail: web"})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	manual := &apptypes.ContainerService{ContainerID: "aaa", ServiceName: "manual"}
+	ours := &apptypes.ContainerService{ContainerID: "bbb", ServiceName: "ours"}
+	fresh := &apptypes.ContainerService{ContainerID: "ccc", ServiceName: "fresh"}
+	desired := []*apptypes.ContainerService{manual, ours, fresh}
+
+	newClient := func(allowOverwrite bool) *Client {
+		return &Client{
+			tailnet:               "-",
+			baseURL:               server.URL,
+			httpClient:            server.Client(),
+			apiSyncEnabled:        true,
+			allowServiceOverwrite: allowOverwrite,
+			knownManaged:          make(map[string]bool),
+		}
+	}
+
+	t.Run("manual service is skipped", func(t *testing.T) {
+		result := &ReconcileResult{Failed: make(map[string]error)}
+		filtered, collided := newClient(false).withoutCollisions(context.Background(), desired, result)
+		if len(filtered) != 2 || filtered[0] != ours || filtered[1] != fresh {
+			t.Errorf("unexpected filtered services: %+v", filtered)
+		}
+		if result.Failed["aaa"] == nil {
+			t.Error("expected an error for the colliding container")
+		}
+		if !collided["svc:manual"] || len(collided) != 1 {
+			t.Errorf("unexpected collided set: %v", collided)
+		}
+	})
+
+	t.Run("overwrite allowed", func(t *testing.T) {
+		result := &ReconcileResult{Failed: make(map[string]error)}
+		filtered, _ := newClient(true).withoutCollisions(context.Background(), desired, result)
+		if len(filtered) != 3 || len(result.Failed) != 0 {
+			t.Errorf("expected all services to pass, got %d (failed: %v)", len(filtered), result.Failed)
+		}
+	})
+}
@@ -0,0 +1,29 @@
+package tailscale
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+// configHash returns a stable hash of a service's serve-relevant configuration: the service,
+// port, protocol and destination `tailscale serve` is called with
+// Serve config has no comment to store it in, but `tailscale serve status` reports the same
+// fields, so the hash of what is live is recomputed each loop (see endpointHash) and survives restarts
+func configHash(svc *apptypes.ContainerService) string {
+	return serveHash(svc.ServiceName, svc.Port, svc.ServiceProtocol, BuildDestination(svc))
+}
+
+// endpointHash is configHash of a service as currently served
+func endpointHash(endpoint ServiceEndpoint) string {
+	return serveHash(endpoint.ServiceName, endpoint.Port, endpoint.Protocol, endpoint.Destination)
+}
+
+// serveHash hashes the fields of one serve entry; the service name is taken without its svc: prefix
+func serveHash(serviceName, port, protocol, destination string) string {
+	fields := []string{strings.TrimPrefix(serviceName, "svc:"), port, protocol, destination}
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\x00")))
+	return hex.EncodeToString(sum[:8])
+}
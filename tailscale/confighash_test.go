@@ -0,0 +1,48 @@
+package tailscale
+
+import (
+	"testing"
+
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+func TestConfigHash(t *testing.T) {
+	base := func() *apptypes.ContainerService {
+		return &apptypes.ContainerService{ContainerID: "web", ContainerName: "web-1", ServiceName: "web", Port: "443", ServiceProtocol: "https", Protocol: "http", IPAddress: "172.17.0.2", TargetPort: "80"}
+	}
+	want := configHash(base())
+
+	if got := configHash(base()); got != want {
+		t.Fatalf("configHash() is not stable: %s != %s", got, want)
+	}
+	if got := endpointHash(ServiceEndpoint{ServiceName: "svc:web", Port: "443", Protocol: "https", Destination: "http://172.17.0.2:80"}); got != want {
+		t.Errorf("endpointHash() of the served config = %s, want %s", got, want)
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(*apptypes.ContainerService)
+		changed bool
+	}{
+		{"service name", func(s *apptypes.ContainerService) { s.ServiceName = "api" }, true},
+		{"service port", func(s *apptypes.ContainerService) { s.Port = "8443" }, true},
+		{"service protocol", func(s *apptypes.ContainerService) { s.ServiceProtocol = "http" }, true},
+		{"backend protocol", func(s *apptypes.ContainerService) { s.Protocol = "https" }, true},
+		{"ip address", func(s *apptypes.ContainerService) { s.IPAddress = "172.17.0.3" }, true},
+		{"target port", func(s *apptypes.ContainerService) { s.TargetPort = "8080" }, true},
+		{"unix socket", func(s *apptypes.ContainerService) { s.UnixSocket = "/run/app.sock" }, true},
+		{"serve path", func(s *apptypes.ContainerService) { s.ServePath = "/srv/site" }, true},
+		{"container id", func(s *apptypes.ContainerService) { s.ContainerID = "web2" }, false},
+		{"container name", func(s *apptypes.ContainerService) { s.ContainerName = "web-2" }, false},
+		{"tags", func(s *apptypes.ContainerService) { s.Tags = []string{"tag:web"} }, false},
+		{"comment", func(s *apptypes.ContainerService) { s.Comment = "docs" }, false},
+	}
+
+	for _, tt := range tests {
+		svc := base()
+		tt.mutate(svc)
+		if got := configHash(svc) != want; got != tt.changed {
+			t.Errorf("%s: hash changed = %v, want %v", tt.name, got, tt.changed)
+		}
+	}
+}
@@ -4,11 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os/exec"
+	"sort"
 	"strings"
 
-	"github.com/rs/zerolog/log"
-
 	apptypes "github.com/marvinvr/docktail/types"
 )
 
@@ -28,10 +26,10 @@ type FunnelHandler struct {
 }
 
 // getCurrentFunnels retrieves the current funnel status
-// Returns a map where the value is the port (e.g., "443") for cleanup
+// Returns a map of public port (e.g., "443") to the proxy destination serving it ("" if unknown)
 func (c *Client) getCurrentFunnels(ctx context.Context) (map[string]string, error) {
-	cmd := exec.CommandContext(ctx, "tailscale", "funnel", "status", "--json")
-	output, err := cmd.CombinedOutput()
+	cmd := c.command(ctx, "funnel", "status", "--json")
+	output, err := c.combinedOutput(cmd)
 
 	// Funnel status command doesn't exist or no funnels configured
 	// This is expected when funnel isn't being used
@@ -46,6 +44,7 @@ func (c *Client) getCurrentFunnels(ctx context.Context) (map[string]string, erro
 	// Check if output indicates no funnels (before trying to parse JSON)
 	if isNotFoundError(outputStr) || len(outputStr) == 0 || outputStr == "\n" {
 		log.Debug().Msg("No existing funnels found")
+		c.recordFunnelStatus(&FunnelStatus{})
 		return make(map[string]string), nil
 	}
 
@@ -56,21 +55,8 @@ func (c *Client) getCurrentFunnels(ctx context.Context) (map[string]string, erro
 		return make(map[string]string), nil
 	}
 
-	// Extract ports from AllowFunnel section
-	// Format: "hostname.tailnet.ts.net:443" -> true
-	funnels := make(map[string]string)
-	for hostPort := range status.AllowFunnel {
-		// Extract port from "hostname.tailnet.ts.net:443"
-		parts := strings.Split(hostPort, ":")
-		if len(parts) == 2 {
-			port := parts[1]
-			funnels[hostPort] = port
-			log.Debug().
-				Str("host_port", hostPort).
-				Str("port", port).
-				Msg("Detected active funnel")
-		}
-	}
+	c.recordFunnelStatus(&status)
+	funnels := status.allowedPorts()
 
 	log.Debug().
 		Int("funnel_count", len(funnels)).
@@ -79,6 +65,45 @@ func (c *Client) getCurrentFunnels(ctx context.Context) (map[string]string, erro
 	return funnels, nil
 }
 
+// allowedPorts returns the public ports listed in AllowFunnel, mapped to the proxy destination
+// of their web handler when one is configured
+func (s FunnelStatus) allowedPorts() map[string]string {
+	// Web keys look like "hostname.tailnet.ts.net:443" (optionally with a scheme)
+	proxies := make(map[string]string)
+	for hostPort, web := range s.Web {
+		if handler, ok := web.Handlers["/"]; ok {
+			proxies[portOf(hostPort)] = handler.Proxy
+		}
+	}
+
+	// Format: "hostname.tailnet.ts.net:443" -> true
+	funnels := make(map[string]string)
+	for hostPort, allowed := range s.AllowFunnel {
+		if !allowed {
+			continue
+		}
+		port := portOf(hostPort)
+		if port == "" {
+			continue
+		}
+		funnels[port] = proxies[port]
+		log.Debug().
+			Str("host_port", hostPort).
+			Str("port", port).
+			Msg("Detected active funnel")
+	}
+	return funnels
+}
+
+// portOf returns the port after the last colon of a host:port string, or "" if there is none
+func portOf(hostPort string) string {
+	idx := strings.LastIndex(hostPort, ":")
+	if idx < 0 || idx == len(hostPort)-1 {
+		return ""
+	}
+	return hostPort[idx+1:]
+}
+
 // reconcileFunnels manages funnel configuration for all desired services
 // Funnel is INDEPENDENT of serve and can be configured separately
 func (c *Client) reconcileFunnels(ctx context.Context, desiredServices []*apptypes.ContainerService) error {
@@ -90,35 +115,34 @@ func (c *Client) reconcileFunnels(ctx context.Context, desiredServices []*apptyp
 	currentFunnels, err := c.getCurrentFunnels(ctx)
 	if err != nil {
 		log.Warn().Err(err).Msg("Failed to get current funnels, will proceed with desired state")
-		currentFunnels = make(map[string]string) // service:port -> port
+		currentFunnels = make(map[string]string) // public port -> proxy destination
 	}
 
-	// Build map of desired funnels and check for duplicate funnel-ports
+	// Build map of desired funnels keyed by public port
 	// Tailscale limitation: only ONE funnel can be active per funnel-port
-	desiredFunnels := make(map[string]*apptypes.ContainerService)
-	funnelPortUsage := make(map[string]string) // funnel-port -> container name
+	desiredFunnels := make(map[string]desiredFunnel)
 	var duplicatePortErrors []string
 
 	for _, svc := range desiredServices {
-		if svc.FunnelEnabled {
-			key := fmt.Sprintf("svc:%s", svc.ServiceName)
-			desiredFunnels[key] = svc
-
+		if !svc.FunnelEnabled {
+			continue
+		}
+		for _, funnel := range svc.Funnels {
 			// Check for duplicate funnel-port usage
-			if existingContainer, exists := funnelPortUsage[svc.FunnelFunnelPort]; exists {
+			if existing, exists := desiredFunnels[funnel.FunnelPort]; exists {
 				errMsg := fmt.Sprintf(
 					"funnel-port %s conflict: containers '%s' and '%s' cannot share the same funnel-port (Tailscale limitation: only ONE funnel per port)",
-					svc.FunnelFunnelPort, existingContainer, svc.ContainerName,
+					funnel.FunnelPort, existing.svc.ContainerName, svc.ContainerName,
 				)
 				duplicatePortErrors = append(duplicatePortErrors, errMsg)
 				log.Error().
-					Str("funnel_port", svc.FunnelFunnelPort).
-					Str("container1", existingContainer).
+					Str("funnel_port", funnel.FunnelPort).
+					Str("container1", existing.svc.ContainerName).
 					Str("container2", svc.ContainerName).
 					Msg("Duplicate funnel-port detected - only one funnel can be active per port")
-			} else {
-				funnelPortUsage[svc.FunnelFunnelPort] = svc.ContainerName
+				continue
 			}
+			desiredFunnels[funnel.FunnelPort] = desiredFunnel{svc: svc, funnel: funnel}
 		}
 	}
 
@@ -130,121 +154,117 @@ func (c *Client) reconcileFunnels(ctx context.Context, desiredServices []*apptyp
 		return fmt.Errorf("funnel configuration error: %d containers have conflicting funnel-ports (only ONE funnel allowed per port)", len(duplicatePortErrors))
 	}
 
-	// Find funnels to add
-	for serviceName, svc := range desiredFunnels {
-		currentPort, exists := currentFunnels[serviceName]
-
-		if !exists || currentPort != svc.FunnelFunnelPort {
-			// Funnel doesn't exist or port changed - add/update it
-			if exists {
-				// Remove old funnel first if port changed
-				log.Info().
-					Str("container", svc.ContainerName).
-					Str("old_public_port", currentPort).
-					Str("new_public_port", svc.FunnelFunnelPort).
-					Msg("Funnel port changed, updating")
-				if err := c.removeFunnel(ctx, svc.ContainerName, currentPort); err != nil {
-					log.Error().Err(err).Str("container", svc.ContainerName).Msg("Failed to remove old funnel")
-				}
-			}
+	toAdd, stale := planFunnels(currentFunnels, desiredFunnels)
 
-			log.Info().
-				Str("container", svc.ContainerName).
-				Str("public_port", svc.FunnelFunnelPort).
-				Msg("Enabling funnel")
+	// Disable funnels that are still allowed but no longer desired (e.g. a container dropped funnel.enable)
+	// Note: funnel reset clears ALL funnel config, so every desired funnel has to be re-enabled afterwards
+	if len(stale) > 0 {
+		log.Info().
+			Strs("public_ports", stale).
+			Msg("Disabling funnel (no longer desired)")
 
-			if err := c.addFunnel(ctx, svc); err != nil {
-				log.Error().
-					Err(err).
-					Str("container", svc.ContainerName).
-					Msg("Failed to enable funnel")
-				// Continue with other services
-			}
+		if err := c.removeFunnel(ctx, "unknown", strings.Join(stale, ",")); err != nil {
+			log.Error().
+				Err(err).
+				Strs("public_ports", stale).
+				Msg("Failed to disable funnel")
 		} else {
-			log.Debug().
-				Str("container", svc.ContainerName).
-				Str("public_port", svc.FunnelFunnelPort).
-				Msg("Funnel already configured correctly")
+			toAdd, _ = planFunnels(map[string]string{}, desiredFunnels)
 		}
 	}
 
-	// Find funnels to remove (in current but not in desired)
-	// Note: We track by public port (funnel-port) since funnel doesn't use service names
-	for _, port := range currentFunnels {
-		portInUse := false
-		for _, svc := range desiredFunnels {
-			if svc.FunnelFunnelPort == port {
-				portInUse = true
-				break
-			}
+	for _, desired := range toAdd {
+		svc, funnel := desired.svc, desired.funnel
+
+		log.Info().
+			Str("container", svc.ContainerName).
+			Str("public_port", funnel.FunnelPort).
+			Msg("Enabling funnel")
+
+		if err := c.addFunnel(ctx, svc, funnel); err != nil {
+			log.Error().
+				Err(err).
+				Str("container", svc.ContainerName).
+				Str("public_port", funnel.FunnelPort).
+				Msg("Failed to enable funnel")
+			// Continue with other services
 		}
+	}
+
+	log.Debug().
+		Int("desired", len(desiredFunnels)).
+		Int("enabled", len(toAdd)).
+		Int("disabled", len(stale)).
+		Msg("Funnel reconciliation complete")
 
-		if !portInUse {
-			log.Info().
+	return nil
+}
+
+// planFunnels diffs the allowed funnel ports against the desired funnels
+// It returns the funnels to enable (missing or pointing at a different destination), sorted by public port,
+// and the allowed public ports that are no longer desired
+func planFunnels(current map[string]string, desired map[string]desiredFunnel) ([]desiredFunnel, []string) {
+	var toAdd []desiredFunnel
+	for port, d := range desired {
+		proxy, allowed := current[port]
+		if allowed && (proxy == "" || proxy == funnelDestination(d.svc, d.funnel)) {
+			log.Debug().
+				Str("container", d.svc.ContainerName).
 				Str("public_port", port).
-				Msg("Disabling funnel (no longer desired)")
+				Msg("Funnel already configured correctly")
+			continue
+		}
+		toAdd = append(toAdd, d)
+	}
+	sort.Slice(toAdd, func(i, j int) bool { return toAdd[i].funnel.FunnelPort < toAdd[j].funnel.FunnelPort })
 
-			if err := c.removeFunnel(ctx, "unknown", port); err != nil {
-				log.Error().
-					Err(err).
-					Str("public_port", port).
-					Msg("Failed to disable funnel")
-				// Continue with other services
-			}
+	var stale []string
+	for port := range current {
+		if _, ok := desired[port]; !ok {
+			stale = append(stale, port)
 		}
 	}
+	sort.Strings(stale)
 
-	return nil
+	return toAdd, stale
+}
+
+// desiredFunnel pairs a funnel entry with the container service it belongs to
+type desiredFunnel struct {
+	svc    *apptypes.ContainerService
+	funnel apptypes.FunnelConfig
 }
 
-// addFunnel enables Tailscale Funnel for a service (public internet access)
+// addFunnel enables Tailscale Funnel for one funnel entry of a service (public internet access)
 // Funnel is INDEPENDENT of serve - uses the machine's hostname, not service names
 // Exposes at: https://<machine-hostname>.<tailnet>.ts.net:<funnel-port>
-func (c *Client) addFunnel(ctx context.Context, svc *apptypes.ContainerService) error {
+func (c *Client) addFunnel(ctx context.Context, svc *apptypes.ContainerService, funnel apptypes.FunnelConfig) error {
 	if !svc.FunnelEnabled {
 		return nil
 	}
 
 	// Build destination using funnel's own target port
-	funnelDestination := fmt.Sprintf("http://%s:%s", svc.IPAddress, svc.FunnelTargetPort)
-
-	var cmd *exec.Cmd
-
-	// Build funnel command based on protocol
-	// Note: Funnel uses machine hostname, NOT service names
-	switch svc.FunnelProtocol {
-	case "https", "http":
-		// HTTPS funnel: tailscale funnel --bg --https=<funnel-port> http://localhost:<host-port>
-		portArg := fmt.Sprintf("--https=%s", svc.FunnelFunnelPort)
-		cmd = exec.CommandContext(ctx, "tailscale", "funnel", "--bg", portArg, funnelDestination)
-
-	case "tcp":
-		// TCP funnel: tailscale funnel --bg --tcp=<funnel-port> tcp://localhost:<host-port>
-		portArg := fmt.Sprintf("--tcp=%s", svc.FunnelFunnelPort)
-		tcpDest := fmt.Sprintf("tcp://%s:%s", svc.IPAddress, svc.FunnelTargetPort)
-		cmd = exec.CommandContext(ctx, "tailscale", "funnel", "--bg", portArg, tcpDest)
-
-	case "tls-terminated-tcp":
-		// TLS-terminated TCP funnel
-		portArg := fmt.Sprintf("--tls-terminated-tcp=%s", svc.FunnelFunnelPort)
-		tcpDest := fmt.Sprintf("tcp://%s:%s", svc.IPAddress, svc.FunnelTargetPort)
-		cmd = exec.CommandContext(ctx, "tailscale", "funnel", "--bg", portArg, tcpDest)
+	destination := funnelDestination(svc, funnel)
+	if funnel.TargetProtocol == "https" {
+		warnTargetCAFallback(svc)
+	}
 
-	default:
-		return fmt.Errorf("unsupported funnel protocol: %s", svc.FunnelProtocol)
+	args, err := funnelArgs(funnel, destination)
+	if err != nil {
+		return err
 	}
 
 	log.Debug().
-		Str("command", cmd.String()).
+		Str("command", "tailscale "+strings.Join(args, " ")).
 		Str("container", svc.ContainerName).
-		Str("funnel_protocol", svc.FunnelProtocol).
-		Str("funnel_container_port", svc.FunnelPort).
-		Str("funnel_host_port", svc.FunnelTargetPort).
-		Str("funnel_public_port", svc.FunnelFunnelPort).
-		Str("destination", funnelDestination).
+		Str("funnel_protocol", funnel.Protocol).
+		Str("funnel_container_port", funnel.Port).
+		Str("funnel_host_port", funnel.TargetPort).
+		Str("funnel_public_port", funnel.FunnelPort).
+		Str("destination", destination).
 		Msg("Executing tailscale funnel command (uses machine hostname, not service name)")
 
-	output, err := cmd.CombinedOutput()
+	output, err := c.runWithRetry(ctx, args...)
 	if err != nil {
 		stderr := string(output)
 		return fmt.Errorf("failed to enable funnel: %w\nOutput: %s", err, stderr)
@@ -252,14 +272,56 @@ func (c *Client) addFunnel(ctx context.Context, svc *apptypes.ContainerService)
 
 	log.Info().
 		Str("container", svc.ContainerName).
-		Str("public_port", svc.FunnelFunnelPort).
-		Str("protocol", svc.FunnelProtocol).
-		Msg("Funnel enabled - publicly accessible at https://<machine-hostname>.<tailnet>.ts.net:" + svc.FunnelFunnelPort)
+		Str("public_port", funnel.FunnelPort).
+		Str("protocol", funnel.Protocol).
+		Msg("Funnel enabled - publicly accessible at " + funnelPublicURL(funnel))
 
 	return nil
 }
 
-// removeFunnel disables Tailscale Funnel using reset
+// funnelArgs builds the tailscale funnel command for a funnel entry
+// Note: Funnel uses machine hostname, NOT service names
+func funnelArgs(funnel apptypes.FunnelConfig, destination string) ([]string, error) {
+	switch funnel.Protocol {
+	case "https", "http":
+		// HTTPS funnel: tailscale funnel --bg --https=<funnel-port> http://localhost:<host-port>
+		return []string{"funnel", "--bg", "--https=" + funnel.FunnelPort, destination}, nil
+
+	case "tcp":
+		// Raw TCP funnel, TLS is passed through untouched:
+		// tailscale funnel --bg --tcp=<funnel-port> tcp://localhost:<host-port>
+		return []string{"funnel", "--bg", "--tcp=" + funnel.FunnelPort, destination}, nil
+
+	case "tls-terminated-tcp":
+		// TLS-terminated TCP funnel
+		return []string{"funnel", "--bg", "--tls-terminated-tcp=" + funnel.FunnelPort, destination}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported funnel protocol: %s", funnel.Protocol)
+	}
+}
+
+// funnelPublicURL describes where a funnel entry is reachable from the internet
+func funnelPublicURL(funnel apptypes.FunnelConfig) string {
+	scheme := "https"
+	if funnel.Protocol == "tcp" || funnel.Protocol == "tls-terminated-tcp" {
+		scheme = "tcp"
+	}
+	return fmt.Sprintf("%s://<machine-hostname>.<tailnet>.ts.net:%s", scheme, funnel.FunnelPort)
+}
+
+// funnelDestination builds the backend URL a funnel entry proxies to
+func funnelDestination(svc *apptypes.ContainerService, funnel apptypes.FunnelConfig) string {
+	scheme := funnel.TargetProtocol
+	if funnel.Protocol == "tcp" || funnel.Protocol == "tls-terminated-tcp" {
+		scheme = "tcp"
+	} else if scheme == "" {
+		scheme = "http"
+	}
+	return fmt.Sprintf("%s://%s:%s", backendScheme(svc, scheme), svc.IPAddress, funnel.TargetPort)
+}
+
+// removeFunnel disables Tailscale Funnel using reset (port is only used for logging)
 // This removes ALL public internet access (funnel is independent of serve and service names)
 // Note: tailscale funnel reset removes ALL funnel configs, not just a specific port
 func (c *Client) removeFunnel(ctx context.Context, containerName string, port string) error {
@@ -270,7 +332,7 @@ func (c *Client) removeFunnel(ctx context.Context, containerName string, port st
 
 	// Command: tailscale funnel reset
 	// Note: This resets ALL funnel configuration, not just one port
-	cmd := exec.CommandContext(ctx, "tailscale", "funnel", "reset")
+	cmd := c.command(ctx, "funnel", "reset")
 
 	log.Debug().
 		Str("command", cmd.String()).
@@ -278,7 +340,7 @@ func (c *Client) removeFunnel(ctx context.Context, containerName string, port st
 		Str("port", port).
 		Msg("Executing tailscale funnel reset command")
 
-	output, err := cmd.CombinedOutput()
+	output, err := c.combinedOutput(cmd)
 	if err != nil {
 		stderr := string(output)
 		// Ignore errors if funnel doesn't exist
@@ -0,0 +1,130 @@
+package tailscale
+
+import (
+	"reflect"
+	"testing"
+
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+func TestFunnelStatusAllowedPorts(t *testing.T) {
+	status := FunnelStatus{
+		Web: map[string]FunnelWebConfig{
+			"myhost.tail1234.ts.net:443": {Handlers: map[string]FunnelHandler{"/": {Proxy: "http://172.17.0.2:80"}}},
+		},
+		AllowFunnel: map[string]bool{
+			"myhost.tail1234.ts.net:443":   true,
+			"myhost.tail1234.ts.net:10000": true,
+			"myhost.tail1234.ts.net:8443":  false,
+		},
+	}
+
+	want := map[string]string{"443": "http://172.17.0.2:80", "10000": ""}
+	if got := status.allowedPorts(); !reflect.DeepEqual(got, want) {
+		t.Errorf("allowedPorts() = %v, want %v", got, want)
+	}
+}
+
+func TestPlanFunnels(t *testing.T) {
+	web := &apptypes.ContainerService{ContainerName: "web", IPAddress: "172.17.0.2"}
+	db := &apptypes.ContainerService{ContainerName: "db", IPAddress: "172.17.0.3"}
+	webFunnel := desiredFunnel{svc: web, funnel: apptypes.FunnelConfig{TargetPort: "80", FunnelPort: "443", Protocol: "https"}}
+	dbFunnel := desiredFunnel{svc: db, funnel: apptypes.FunnelConfig{TargetPort: "5432", FunnelPort: "10000", Protocol: "tcp"}}
+
+	tests := []struct {
+		name      string
+		current   map[string]string
+		desired   map[string]desiredFunnel
+		wantAdd   []string
+		wantStale []string
+	}{
+		{
+			name:    "nothing allowed yet",
+			current: map[string]string{},
+			desired: map[string]desiredFunnel{"443": webFunnel, "10000": dbFunnel},
+			wantAdd: []string{"10000", "443"},
+		},
+		{
+			name:    "already allowed is a no-op",
+			current: map[string]string{"443": "http://172.17.0.2:80", "10000": ""},
+			desired: map[string]desiredFunnel{"443": webFunnel, "10000": dbFunnel},
+		},
+		{
+			name:    "destination changed",
+			current: map[string]string{"443": "http://172.17.0.9:80"},
+			desired: map[string]desiredFunnel{"443": webFunnel},
+			wantAdd: []string{"443"},
+		},
+		{
+			name:      "funnel no longer desired",
+			current:   map[string]string{"443": "http://172.17.0.2:80", "8443": ""},
+			desired:   map[string]desiredFunnel{"443": webFunnel},
+			wantStale: []string{"8443"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			toAdd, stale := planFunnels(tt.current, tt.desired)
+
+			var gotAdd []string
+			for _, d := range toAdd {
+				gotAdd = append(gotAdd, d.funnel.FunnelPort)
+			}
+			if !reflect.DeepEqual(gotAdd, tt.wantAdd) {
+				t.Errorf("toAdd = %v, want %v", gotAdd, tt.wantAdd)
+			}
+			if !reflect.DeepEqual(stale, tt.wantStale) {
+				t.Errorf("stale = %v, want %v", stale, tt.wantStale)
+			}
+		})
+	}
+}
+
+func TestFunnelArgs(t *testing.T) {
+	svc := &apptypes.ContainerService{ContainerName: "mc", IPAddress: "localhost"}
+
+	tests := []struct {
+		name    string
+		funnel  apptypes.FunnelConfig
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:   "https",
+			funnel: apptypes.FunnelConfig{TargetPort: "8080", FunnelPort: "443", Protocol: "https"},
+			want:   []string{"funnel", "--bg", "--https=443", "http://localhost:8080"},
+		},
+		{
+			name:   "https to https backend",
+			funnel: apptypes.FunnelConfig{TargetPort: "8443", FunnelPort: "443", Protocol: "https", TargetProtocol: "https+insecure"},
+			want:   []string{"funnel", "--bg", "--https=443", "https+insecure://localhost:8443"},
+		},
+		{
+			name:   "raw tcp",
+			funnel: apptypes.FunnelConfig{TargetPort: "35565", FunnelPort: "10000", Protocol: "tcp"},
+			want:   []string{"funnel", "--bg", "--tcp=10000", "tcp://localhost:35565"},
+		},
+		{
+			name:   "tls-terminated tcp",
+			funnel: apptypes.FunnelConfig{TargetPort: "5432", FunnelPort: "8443", Protocol: "tls-terminated-tcp"},
+			want:   []string{"funnel", "--bg", "--tls-terminated-tcp=8443", "tcp://localhost:5432"},
+		},
+		{
+			name:    "unsupported",
+			funnel:  apptypes.FunnelConfig{TargetPort: "53", FunnelPort: "443", Protocol: "udp"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := funnelArgs(tt.funnel, funnelDestination(svc, tt.funnel))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: funnelArgs() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: funnelArgs() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package tailscale
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+// serviceGrants collects docktail.service.allowed-tags by service, as svc:<name> -> sorted tags
+func serviceGrants(services []*apptypes.ContainerService) map[string][]string {
+	grants := make(map[string][]string)
+	for _, svc := range services {
+		if len(svc.AllowedTags) == 0 {
+			continue
+		}
+		tags := slices.Clone(svc.AllowedTags)
+		sort.Strings(tags)
+		grants["svc:"+svc.ServiceName] = slices.Compact(tags)
+	}
+	return grants
+}
+
+// syncServiceGrants makes sure the policy file grants each service with allowed tags to exactly those tags
+// Only done with ACL_SYNC, because the policy file has to be written back as plain JSON, which drops
+// its comments and formatting; otherwise the label is advisory and only logged
+func (c *Client) syncServiceGrants(ctx context.Context, services []*apptypes.ContainerService) {
+	grants := serviceGrants(services)
+	if len(grants) == 0 {
+		return
+	}
+
+	if !c.apiSyncEnabled || !c.aclSync {
+		for service, tags := range grants {
+			if c.advisoryLogged[service] {
+				continue
+			}
+			c.advisoryLogged[service] = true
+			log.Info().
+				Str("service", service).
+				Strs("allowed_tags", tags).
+				Msg("docktail.service.allowed-tags is advisory without API credentials and ACL_SYNC=true, add the grant to your policy file yourself")
+		}
+		return
+	}
+
+	if err := c.pushServiceGrants(ctx, grants); err != nil {
+		log.Error().Err(err).Msg("Failed to sync service grants to the tailnet policy file")
+	}
+}
+
+// pushServiceGrants updates the policy file if any service grant is missing or out of date
+// The write is conditional on the ETag of the read, so concurrent policy edits are never overwritten
+func (c *Client) pushServiceGrants(ctx context.Context, grants map[string][]string) error {
+	apiURL := fmt.Sprintf("%s/api/v2/tailnet/%s/acl", c.baseURL, url.PathEscape(c.tailnet))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create GET request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.doAPI(req)
+	if err != nil {
+		return fmt.Errorf("GET request failed: %w", err)
+	}
+	defer closeBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("GET API returned error status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var policy map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&policy); err != nil {
+		return fmt.Errorf("failed to decode policy file: %w", err)
+	}
+	if !applyServiceGrants(policy, grants) {
+		log.Debug().Int("services", len(grants)).Msg("Service grants already up to date")
+		return nil
+	}
+
+	body, err := json.Marshal(policy)
+	if err != nil {
+		return fmt.Errorf("failed to marshal policy file: %w", err)
+	}
+	req, err = http.NewRequestWithContext(ctx, "POST", apiURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create POST request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		req.Header.Set("If-Match", etag)
+	}
+
+	postResp, err := c.doAPI(req)
+	if err != nil {
+		return fmt.Errorf("POST request failed: %w", err)
+	}
+	defer closeBody(postResp)
+
+	if postResp.StatusCode == http.StatusPreconditionFailed {
+		return fmt.Errorf("policy file changed while updating service grants, retrying next reconciliation")
+	}
+	if postResp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(postResp.Body)
+		return fmt.Errorf("POST API returned error status %d: %s", postResp.StatusCode, string(respBody))
+	}
+
+	log.Info().Int("services", len(grants)).Msg("Updated service grants in the tailnet policy file")
+	return nil
+}
+
+// applyServiceGrants sets, for each service, the src tags of the grant whose only destination is
+// that service, appending a new grant if there is none. Returns whether the policy changed
+// Grants of services that no longer have allowed tags are left alone, like service definitions
+func applyServiceGrants(policy map[string]any, grants map[string][]string) bool {
+	existing, _ := policy["grants"].([]any)
+
+	services := make([]string, 0, len(grants))
+	for service := range grants {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+
+	changed := false
+	for _, service := range services {
+		tags := grants[service]
+		found := false
+		for _, entry := range existing {
+			grant, ok := entry.(map[string]any)
+			if !ok || !slices.Equal(stringList(grant["dst"]), []string{service}) {
+				continue
+			}
+			found = true
+			current := stringList(grant["src"])
+			sort.Strings(current)
+			if !slices.Equal(current, tags) {
+				grant["src"] = tags
+				changed = true
+			}
+		}
+		if !found {
+			existing = append(existing, map[string]any{
+				"src": tags,
+				"dst": []string{service},
+				"ip":  []string{"*"},
+			})
+			changed = true
+		}
+	}
+
+	if changed {
+		policy["grants"] = existing
+	}
+	return changed
+}
+
+// stringList converts a decoded JSON array of strings
+func stringList(value any) []string {
+	items, _ := value.([]any)
+	list := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			list = append(list, s)
+		}
+	}
+	return list
+}
@@ -0,0 +1,76 @@
+package tailscale
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+func TestServiceGrants(t *testing.T) {
+	got := serviceGrants([]*apptypes.ContainerService{
+		{ServiceName: "web", AllowedTags: []string{"tag:ops", "tag:dev", "tag:ops"}},
+		{ServiceName: "api"},
+	})
+	want := map[string][]string{"svc:web": {"tag:dev", "tag:ops"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestApplyServiceGrants(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      string
+		grants      map[string][]string
+		wantChanged bool
+		wantGrants  string
+	}{
+		{
+			name:        "no grants section",
+			policy:      `{"tagOwners": {}}`,
+			grants:      map[string][]string{"svc:web": {"tag:ops"}},
+			wantChanged: true,
+			wantGrants:  `[{"dst":["svc:web"],"ip":["*"],"src":["tag:ops"]}]`,
+		},
+		{
+			name:        "already up to date",
+			policy:      `{"grants": [{"src": ["tag:ops", "tag:dev"], "dst": ["svc:web"], "ip": ["tcp:443"]}]}`,
+			grants:      map[string][]string{"svc:web": {"tag:dev", "tag:ops"}},
+			wantChanged: false,
+			wantGrants:  `[{"dst":["svc:web"],"ip":["tcp:443"],"src":["tag:ops","tag:dev"]}]`,
+		},
+		{
+			name:        "src updated, other grants kept",
+			policy:      `{"grants": [{"src": ["*"], "dst": ["*"], "ip": ["*"]}, {"src": ["tag:dev"], "dst": ["svc:web"], "ip": ["*"]}]}`,
+			grants:      map[string][]string{"svc:web": {"tag:ops"}},
+			wantChanged: true,
+			wantGrants:  `[{"dst":["*"],"ip":["*"],"src":["*"]},{"dst":["svc:web"],"ip":["*"],"src":["tag:ops"]}]`,
+		},
+		{
+			name:        "shared grant is not ours",
+			policy:      `{"grants": [{"src": ["tag:dev"], "dst": ["svc:web", "svc:api"], "ip": ["*"]}]}`,
+			grants:      map[string][]string{"svc:web": {"tag:ops"}},
+			wantChanged: true,
+			wantGrants:  `[{"dst":["svc:web","svc:api"],"ip":["*"],"src":["tag:dev"]},{"dst":["svc:web"],"ip":["*"],"src":["tag:ops"]}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var policy map[string]any
+			if err := json.Unmarshal([]byte(tt.policy), &policy); err != nil {
+				t.Fatalf("bad test policy: %v", err)
+			}
+			changed := applyServiceGrants(policy, tt.grants)
+			if changed != tt.wantChanged {
+				t.Errorf("expected changed=%v, got %v", tt.wantChanged, changed)
+			}
+			got, _ := json.Marshal(policy["grants"])
+			if string(got) != tt.wantGrants {
+				t.Errorf("expected grants %s, got %s", tt.wantGrants, got)
+			}
+		})
+	}
+}
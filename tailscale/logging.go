@@ -0,0 +1,14 @@
+package tailscale
+
+import (
+	"github.com/rs/zerolog"
+	zlog "github.com/rs/zerolog/log"
+)
+
+// log is the tailscale package's logger: the global logger, read at use time, until SetLogger replaces it
+var log = &zlog.Logger
+
+// SetLogger replaces the package logger; call it before starting any goroutines
+func SetLogger(l zerolog.Logger) {
+	log = &l
+}
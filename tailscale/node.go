@@ -0,0 +1,29 @@
+package tailscale
+
+import (
+	"context"
+)
+
+// NodeName returns the name of the node services are advertised from ("" if unknown)
+func (c *Client) NodeName() string {
+	return c.nodeName
+}
+
+// ResolveNodeName looks up the node behind the tailscaled socket, which is the node every
+// service is advertised from: serve config only ever lands on that tailscaled
+// NODE_NAME_OVERRIDE (ClientConfig.NodeName) skips the lookup
+// Must be called before reconciliation starts
+func (c *Client) ResolveNodeName(ctx context.Context) {
+	if c.nodeName == "" {
+		status, err := c.getLocalStatus(ctx)
+		if err != nil {
+			log.Warn().Err(err).Msg("Could not read the local node name")
+			return
+		}
+		c.nodeName = status.Self.HostName
+	}
+	log.Info().
+		Str("node", c.nodeName).
+		Str("tailscale_socket", c.socketPath).
+		Msg("Advertising services from this node")
+}
@@ -0,0 +1,62 @@
+package tailscale
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// tokenRefreshSkew refreshes an OAuth access token this long before it expires,
+// so it never runs out halfway through a reconciliation
+const tokenRefreshSkew = 60 * time.Second
+
+// tokenExchangeTimeout bounds a single client credentials exchange
+const tokenExchangeTimeout = 10 * time.Second
+
+// cachedTokenSource hands out the cached OAuth access token and only exchanges the
+// client credentials for a new one when it is missing or about to expire
+// Safe for concurrent use: callers share one token and at most one exchange runs at a time
+type cachedTokenSource struct {
+	config *clientcredentials.Config
+	now    func() time.Time
+
+	mu     sync.Mutex
+	token  *oauth2.Token
+	expiry time.Time
+}
+
+func newCachedTokenSource(config *clientcredentials.Config) *cachedTokenSource {
+	return &cachedTokenSource{config: config, now: time.Now}
+}
+
+// Token returns a valid access token, refreshing it within tokenRefreshSkew of expiry
+func (s *cachedTokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != nil && (s.expiry.IsZero() || s.now().Before(s.expiry.Add(-tokenRefreshSkew))) {
+		return s.token, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), tokenExchangeTimeout)
+	defer cancel()
+
+	// Config.Token always performs the exchange; caching is done here
+	token, err := s.config.Token(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain Tailscale OAuth access token: %w", err)
+	}
+
+	log.Debug().
+		Time("expiry", token.Expiry).
+		Bool("initial", s.token == nil).
+		Msg("Refreshed Tailscale OAuth access token")
+
+	s.token = token
+	s.expiry = token.Expiry
+	return token, nil
+}
@@ -0,0 +1,54 @@
+package tailscale
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+func TestCachedTokenSource(t *testing.T) {
+	var exchanges atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := exchanges.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		// Expiry is computed from the real clock, so each token lives an hour longer than the last
+		_, _ = fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"Bearer","expires_in":%d}`, n, 3600*n)
+	}))
+	defer srv.Close()
+
+	now := time.Now()
+	src := newCachedTokenSource(&clientcredentials.Config{ClientID: "id", ClientSecret: "secret", TokenURL: srv.URL})
+	src.now = func() time.Time { return now }
+
+	steps := []struct {
+		name      string
+		advance   time.Duration
+		wantToken string
+	}{
+		{"initial exchange", 0, "token-1"},
+		{"cached", 30 * time.Minute, "token-1"},
+		{"still outside the skew window", 28 * time.Minute, "token-1"},
+		{"refreshed within the skew window", time.Minute + time.Second, "token-2"},
+		{"new token is cached", time.Minute, "token-2"},
+	}
+
+	for _, step := range steps {
+		now = now.Add(step.advance)
+		tok, err := src.Token()
+		if err != nil {
+			t.Fatalf("%s: Token() error = %v", step.name, err)
+		}
+		if tok.AccessToken != step.wantToken {
+			t.Errorf("%s: Token() = %s, want %s", step.name, tok.AccessToken, step.wantToken)
+		}
+	}
+
+	if got := exchanges.Load(); got != 2 {
+		t.Errorf("expected 2 token exchanges, got %d", got)
+	}
+}
@@ -0,0 +1,85 @@
+package tailscale
+
+import (
+	"context"
+	"strings"
+
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+// checkOrphans runs once, on the first reconciliation that could read the serve config
+// It sorts the services no enabled container claims into orphans left by a previous run, which are
+// removed unless ADOPT_ORPHANS=false, and services DockTail never created, which are always kept
+// Kept services stay in c.preserved until a container claims their name
+func (c *Client) checkOrphans(ctx context.Context, desiredMap map[string]*apptypes.ContainerService, currentServices map[string]ServiceEndpoint) {
+	c.orphansChecked = true
+
+	claimed := make(map[string]bool)
+	for _, svc := range desiredMap {
+		claimed["svc:"+svc.ServiceName] = true
+	}
+
+	checked := make(map[string]bool)
+	orphans := 0
+	for _, current := range currentServices {
+		name := current.ServiceName
+		if claimed[name] || checked[name] || !isManagedService(name) {
+			continue
+		}
+		checked[name] = true
+
+		managed, err := c.isDockTailService(ctx, name)
+		if err != nil {
+			// Removing something we can't prove is ours isn't worth the risk
+			log.Warn().Err(err).Str("service", name).Msg("Could not check whether unclaimed service is DockTail's, keeping it")
+			c.preserved[name] = true
+			continue
+		}
+		switch {
+		case !managed:
+			log.Info().Str("service", name).Msg("Keeping unclaimed service not created by DockTail")
+			c.preserved[name] = true
+		case !c.adoptOrphans:
+			log.Info().Str("service", name).Msg("Keeping orphaned service left by a previous run (ADOPT_ORPHANS=false)")
+			c.preserved[name] = true
+		default:
+			orphans++
+			log.Info().Str("service", name).Msg("Removing orphaned service left by a previous run")
+		}
+	}
+
+	log.Info().
+		Int("orphaned", orphans).
+		Int("kept", len(c.preserved)).
+		Msg("Checked for orphaned services on startup")
+}
+
+// isDockTailService reports whether a served service's definition carries the managed marker
+// Without API credentials there are no definitions to check and every svc: service counts as managed
+func (c *Client) isDockTailService(ctx context.Context, name string) (bool, error) {
+	if !c.apiSyncEnabled || c.knownManaged[strings.TrimPrefix(name, "svc:")] {
+		return true, nil
+	}
+	existing, err := c.getService(ctx, name)
+	if err != nil {
+		return false, err
+	}
+	// Served without a definition can only be left over from us
+	return existing == nil || strings.Contains(existing.Comment, managedMarker), nil
+}
+
+// keptServices returns the service names (svc:<name>) whose serve config must not be removed:
+// collided services plus preserved ones no container has claimed since startup
+func (c *Client) keptServices(desiredMap map[string]*apptypes.ContainerService, collided map[string]bool) map[string]bool {
+	for _, svc := range desiredMap {
+		delete(c.preserved, "svc:"+svc.ServiceName)
+	}
+	kept := make(map[string]bool, len(collided)+len(c.preserved))
+	for name := range collided {
+		kept[name] = true
+	}
+	for name := range c.preserved {
+		kept[name] = true
+	}
+	return kept
+}
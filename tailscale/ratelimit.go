@@ -0,0 +1,135 @@
+package tailscale
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// DefaultAPIRate is the default average number of Tailscale API requests per second (TS_API_RATE)
+const DefaultAPIRate = 5.0
+
+// maxRateLimitRetries bounds how often a request answered with 429 is retried
+const maxRateLimitRetries = 3
+
+// defaultRetryAfter is the backoff after a 429 that carries no usable Retry-After header
+const defaultRetryAfter = 5 * time.Second
+
+// maxRetryAfter caps the wait for a single 429, so a bogus header can't stall reconciliation
+const maxRetryAfter = 60 * time.Second
+
+// apiLimiter smooths Tailscale API calls with a token bucket
+type apiLimiter struct {
+	limiter *rate.Limiter // nil = unlimited
+	// throttled is set while calls are being delayed, so throttling is logged once per burst
+	throttled atomic.Bool
+}
+
+// newAPILimiter allows perSecond requests per second on average, bursting up to one second's worth
+// A rate of 0 or less disables the limiter
+func newAPILimiter(perSecond float64) *apiLimiter {
+	l := &apiLimiter{}
+	if perSecond > 0 {
+		l.limiter = rate.NewLimiter(rate.Limit(perSecond), max(1, int(math.Ceil(perSecond))))
+	}
+	return l
+}
+
+// wait blocks until the token bucket allows the request (a nil limiter never blocks)
+func (l *apiLimiter) wait(req *http.Request) error {
+	if l == nil || l.limiter == nil {
+		return nil
+	}
+	reservation := l.limiter.Reserve()
+	delay := reservation.Delay()
+	if delay <= 0 {
+		l.throttled.Store(false)
+		return nil
+	}
+
+	if l.throttled.CompareAndSwap(false, true) {
+		log.Info().
+			Float64("rate", float64(l.limiter.Limit())).
+			Dur("delay", delay).
+			Msg("Throttling Tailscale API calls (TS_API_RATE)")
+	}
+	select {
+	case <-req.Context().Done():
+		reservation.Cancel()
+		return req.Context().Err()
+	case <-time.After(delay):
+		return nil
+	}
+}
+
+// doAPI sends a Tailscale API request through the rate limiter
+// Requests rejected with 429 Too Many Requests are retried after the Retry-After delay
+func (c *Client) doAPI(req *http.Request) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		if err := c.limiter.wait(req); err != nil {
+			return nil, err
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil || resp.StatusCode != http.StatusTooManyRequests || attempt > maxRateLimitRetries {
+			return resp, err
+		}
+		// Retrying needs a fresh copy of the body
+		if req.Body != nil && req.GetBody == nil {
+			return resp, nil
+		}
+
+		delay := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		closeBody(resp)
+		log.Warn().
+			Str("path", req.URL.Path).
+			Int("attempt", attempt).
+			Dur("retry_in", delay).
+			Msg("Tailscale API rate limit hit (429), backing off")
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(delay):
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("failed to rewind request body for retry: %w", err)
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// maxDrainBytes caps how much of an unread response body closeBody discards
+const maxDrainBytes = 64 << 10
+
+// closeBody drains what's left of a response body before closing it, so its keep-alive connection
+// goes back to the pool instead of being torn down (e.g. after a 404 or a decoded JSON body)
+func closeBody(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainBytes))
+	_ = resp.Body.Close()
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return defaultRetryAfter
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return min(time.Duration(seconds)*time.Second, maxRetryAfter)
+	}
+	if when, err := http.ParseTime(value); err == nil {
+		return min(max(when.Sub(now), 0), maxRetryAfter)
+	}
+	return defaultRetryAfter
+}
@@ -0,0 +1,112 @@
+package tailscale
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", defaultRetryAfter},
+		{"3", 3 * time.Second},
+		{"0", 0},
+		{"3600", maxRetryAfter},
+		{now.Add(10 * time.Second).Format(http.TimeFormat), 10 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+		{"soon", defaultRetryAfter},
+	}
+
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestDoAPIRetriesTooManyRequests(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := make([]byte, 16)
+		n, _ := r.Body.Read(body)
+		if string(body[:n]) != `{"name":"web"}` {
+			t.Errorf("attempt %d: body = %q, want it replayed", calls.Load()+1, body[:n])
+		}
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	c := &Client{httpClient: server.Client(), limiter: newAPILimiter(100)}
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPut, server.URL, strings.NewReader(`{"name":"web"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := c.doAPI(req)
+	if err != nil {
+		t.Fatalf("doAPI() error = %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK || calls.Load() != 2 {
+		t.Errorf("expected a retry after the 429, got status %d after %d calls", resp.StatusCode, calls.Load())
+	}
+}
+
+// countingServer serves a 404 with a body for every request and counts the connections dialed to it
+func countingServer() (*httptest.Server, *atomic.Int32) {
+	var conns atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"service not found"}`, http.StatusNotFound)
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	server.Start()
+	return server, &conns
+}
+
+func TestAPIConnectionReuse(t *testing.T) {
+	server, conns := countingServer()
+	defer server.Close()
+
+	c := &Client{httpClient: &http.Client{Transport: newAPITransport()}, baseURL: server.URL, tailnet: "-"}
+	for i := 0; i < 10; i++ {
+		if svc, err := c.getService(context.Background(), "svc:web"); err != nil || svc != nil {
+			t.Fatalf("getService() = %v, %v", svc, err)
+		}
+	}
+	if got := conns.Load(); got != 1 {
+		t.Errorf("10 sequential API calls dialed %d connections, want 1", got)
+	}
+}
+
+func BenchmarkAPICalls(b *testing.B) {
+	server, conns := countingServer()
+	defer server.Close()
+
+	c := &Client{httpClient: &http.Client{Transport: newAPITransport()}, baseURL: server.URL, tailnet: "-"}
+	b.ResetTimer()
+	for b.Loop() {
+		if _, err := c.getService(context.Background(), "svc:web"); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(conns.Load()), "conns")
+}
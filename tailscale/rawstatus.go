@@ -0,0 +1,40 @@
+package tailscale
+
+import (
+	"time"
+)
+
+// RawStatus is the serve and funnel status tailscaled last reported, as DockTail parsed it
+// A nil status means it hasn't been fetched yet
+type RawStatus struct {
+	Serve           *TailscaleStatus `json:"serve"`
+	ServeFetchedAt  *time.Time       `json:"serve_fetched_at,omitempty"`
+	Funnel          *FunnelStatus    `json:"funnel"`
+	FunnelFetchedAt *time.Time       `json:"funnel_fetched_at,omitempty"`
+}
+
+// LastRawStatus returns the most recently fetched serve and funnel status, for debugging
+// discrepancies between what DockTail expects and what tailscaled reports
+func (c *Client) LastRawStatus() RawStatus {
+	c.rawMu.Lock()
+	defer c.rawMu.Unlock()
+	return c.raw
+}
+
+// recordServeStatus keeps the parsed 'tailscale serve status --json' output
+func (c *Client) recordServeStatus(status *TailscaleStatus) {
+	now := time.Now()
+	c.rawMu.Lock()
+	defer c.rawMu.Unlock()
+	c.raw.Serve = status
+	c.raw.ServeFetchedAt = &now
+}
+
+// recordFunnelStatus keeps the parsed 'tailscale funnel status --json' output
+func (c *Client) recordFunnelStatus(status *FunnelStatus) {
+	now := time.Now()
+	c.rawMu.Lock()
+	defer c.rawMu.Unlock()
+	c.raw.Funnel = status
+	c.raw.FunnelFetchedAt = &now
+}
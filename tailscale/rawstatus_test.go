@@ -0,0 +1,33 @@
+package tailscale
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestLastRawStatus(t *testing.T) {
+	c := &Client{}
+	if raw := c.LastRawStatus(); raw.Serve != nil || raw.Funnel != nil {
+		t.Fatalf("expected no status before the first fetch, got %+v", raw)
+	}
+
+	c.recordServeStatus(&TailscaleStatus{Services: map[string]TailscaleService{
+		"svc:web": {Web: map[string]TailscaleWebConfig{
+			"web.tailnet.ts.net:443": {Handlers: map[string]TailscaleHandler{"/": {Proxy: "http://172.17.0.2:80"}}},
+		}},
+	}})
+	c.recordFunnelStatus(&FunnelStatus{})
+
+	raw := c.LastRawStatus()
+	if raw.ServeFetchedAt == nil || raw.FunnelFetchedAt == nil {
+		t.Errorf("expected fetch times to be recorded, got %+v", raw)
+	}
+	body, err := json.Marshal(raw)
+	if err != nil {
+		t.Fatalf("failed to encode raw status: %v", err)
+	}
+	if !strings.Contains(string(body), `"Proxy":"http://172.17.0.2:80"`) {
+		t.Errorf("serve status missing from %s", body)
+	}
+}
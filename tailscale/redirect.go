@@ -0,0 +1,27 @@
+package tailscale
+
+import (
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+// redirectPort is where the HTTP-to-HTTPS redirect handler of a service listens
+const redirectPort = "80"
+
+// redirectEntry derives the extra serve entry for docktail.service.https-redirect:
+// an HTTP handler on port 80 of the same service that redirects to its HTTPS endpoint
+// ${HOST} and ${REQUEST_URI} are expanded by tailscaled, so the tailnet name isn't needed
+func redirectEntry(svc *apptypes.ContainerService) *apptypes.ContainerService {
+	target := "https://${HOST}${REQUEST_URI}"
+	if svc.Port != "443" {
+		target = "https://${HOST}:" + svc.Port + "${REQUEST_URI}"
+	}
+
+	entry := *svc
+	entry.Port = redirectPort
+	entry.ServiceProtocol = "http"
+	entry.HTTPSRedirect = false
+	entry.Redirect = target
+	entry.FunnelEnabled = false
+	entry.Funnels = nil
+	return &entry
+}
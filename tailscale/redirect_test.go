@@ -0,0 +1,45 @@
+package tailscale
+
+import (
+	"testing"
+
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+func TestRedirectEntry(t *testing.T) {
+	tests := []struct {
+		port     string
+		wantDest string
+	}{
+		{"443", "redirect:https://${HOST}${REQUEST_URI}"},
+		{"8443", "redirect:https://${HOST}:8443${REQUEST_URI}"},
+	}
+
+	for _, tt := range tests {
+		svc := &apptypes.ContainerService{
+			ServiceName:     "web",
+			Port:            tt.port,
+			ServiceProtocol: "https",
+			Protocol:        "http",
+			IPAddress:       "172.17.0.2",
+			TargetPort:      "80",
+			HTTPSRedirect:   true,
+			FunnelEnabled:   true,
+			Funnels:         []apptypes.FunnelConfig{{Port: "80", FunnelPort: "443", Protocol: "https"}},
+		}
+
+		entry := redirectEntry(svc)
+		if entry.Port != "80" || entry.ServiceProtocol != "http" {
+			t.Errorf("port %s: redirect entry serves %s on %s, want http on 80", tt.port, entry.ServiceProtocol, entry.Port)
+		}
+		if got := BuildDestination(entry); got != tt.wantDest {
+			t.Errorf("port %s: BuildDestination() = %s, want %s", tt.port, got, tt.wantDest)
+		}
+		if entry.HTTPSRedirect || entry.FunnelEnabled || len(entry.Funnels) != 0 {
+			t.Errorf("port %s: redirect entry must not carry the redirect or funnel settings: %+v", tt.port, entry)
+		}
+		if svc.Port != tt.port || svc.Redirect != "" {
+			t.Errorf("port %s: redirectEntry() modified the original service: %+v", tt.port, svc)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package tailscale
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// localStatus is the subset of 'tailscale status --json' used by the startup self-check
+type localStatus struct {
+	Self struct {
+		HostName string   `json:"HostName"`
+		Tags     []string `json:"Tags"`
+	} `json:"Self"`
+	CurrentTailnet *struct {
+		Name string `json:"Name"`
+	} `json:"CurrentTailnet"`
+}
+
+// policyFile is the subset of the tailnet policy file used by the startup self-check
+type policyFile struct {
+	TagOwners map[string][]string `json:"tagOwners"`
+}
+
+// SelfCheck verifies up front that the local node and tailnet policy can host services,
+// so misconfiguration shows up at startup rather than as a serve failure per container
+// Problems are logged as warnings and returned; they never stop DockTail from starting
+func (c *Client) SelfCheck(ctx context.Context, defaultTags []string) []string {
+	var problems []string
+
+	status, err := c.getLocalStatus(ctx)
+	if err != nil {
+		log.Warn().Err(err).Msg("Self-check: could not read local node status, skipping tag check")
+	} else if len(status.Self.Tags) == 0 {
+		problems = append(problems, fmt.Sprintf(
+			"node %q is not tagged; Tailscale Services require a tagged host, so every serve command will fail. "+
+				"Tag it with 'tailscale up --advertise-tags=tag:server' or in the admin console",
+			status.Self.HostName))
+	} else {
+		log.Debug().
+			Str("node", status.Self.HostName).
+			Strs("tags", status.Self.Tags).
+			Msg("Self-check: node is tagged")
+	}
+
+	if c.apiSyncEnabled {
+		policy, err := c.getPolicyFile(ctx)
+		if err != nil {
+			log.Warn().Err(err).Msg("Self-check: could not read tailnet policy file, skipping ACL tag check")
+		} else if missing := missingTagOwners(policy, defaultTags); len(missing) > 0 {
+			problems = append(problems, fmt.Sprintf(
+				"DEFAULT_SERVICE_TAGS %v are not defined in the tailnet policy's tagOwners; "+
+					"service definitions using them will be rejected", missing))
+		}
+	}
+
+	for _, problem := range problems {
+		log.Warn().Msg("Self-check: " + problem)
+	}
+	if len(problems) == 0 {
+		log.Info().Msg("Self-check passed")
+	}
+
+	return problems
+}
+
+// getLocalStatus runs 'tailscale status --json' for the local node
+func (c *Client) getLocalStatus(ctx context.Context) (*localStatus, error) {
+	cmd := c.command(ctx, "status", "--json")
+	output, err := c.output(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("tailscale status failed: %w", err)
+	}
+
+	var status localStatus
+	if err := json.Unmarshal([]byte(stripWarnings(output)), &status); err != nil {
+		return nil, fmt.Errorf("failed to parse tailscale status: %w", err)
+	}
+	return &status, nil
+}
+
+// getPolicyFile fetches the tailnet policy file as JSON
+// Requires the policy_file:read scope for OAuth clients
+func (c *Client) getPolicyFile(ctx context.Context) (*policyFile, error) {
+	apiURL := fmt.Sprintf("%s/api/v2/tailnet/%s/acl", c.baseURL, url.PathEscape(c.tailnet))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GET request: %w", err)
+	}
+	// Without this the API returns HuJSON, which may contain comments
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.doAPI(req)
+	if err != nil {
+		return nil, fmt.Errorf("GET request failed: %w", err)
+	}
+	defer closeBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("GET API returned error status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var policy policyFile
+	if err := json.NewDecoder(resp.Body).Decode(&policy); err != nil {
+		return nil, fmt.Errorf("failed to decode policy file: %w", err)
+	}
+	return &policy, nil
+}
+
+// missingTagOwners returns the tags that have no tagOwners entry in the policy file
+func missingTagOwners(policy *policyFile, tags []string) []string {
+	var missing []string
+	for _, tag := range tags {
+		if _, ok := policy.TagOwners[tag]; !ok {
+			missing = append(missing, tag)
+		}
+	}
+	return missing
+}
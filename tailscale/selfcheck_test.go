@@ -0,0 +1,44 @@
+package tailscale
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMissingTagOwners(t *testing.T) {
+	var policy policyFile
+	input := `{"tagOwners": {"tag:container": ["autogroup:admin"], "tag:server": []}}`
+	if err := json.Unmarshal([]byte(input), &policy); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		tags []string
+		want []string
+	}{
+		{"all defined", []string{"tag:container", "tag:server"}, nil},
+		{"one missing", []string{"tag:container", "tag:web"}, []string{"tag:web"}},
+		{"no tags", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := missingTagOwners(&policy, tt.tags); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("missingTagOwners() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalStatusParsing(t *testing.T) {
+	input := `{"Self": {"HostName": "docker-host", "Tags": ["tag:server"]}, "Peer": {}}`
+	var status localStatus
+	if err := json.Unmarshal([]byte(input), &status); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if status.Self.HostName != "docker-host" || !reflect.DeepEqual(status.Self.Tags, []string{"tag:server"}) {
+		t.Errorf("unexpected status: %+v", status.Self)
+	}
+}
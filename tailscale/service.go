@@ -4,25 +4,26 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os/exec"
 	"strings"
 
-	"github.com/rs/zerolog/log"
-
 	apptypes "github.com/marvinvr/docktail/types"
 )
 
 // GetCurrentServices retrieves the current Tailscale service status using CLI
 func (c *Client) GetCurrentServices(ctx context.Context) (map[string]ServiceEndpoint, error) {
-	cmd := exec.CommandContext(ctx, "tailscale", "serve", "status", "--json")
-	output, err := cmd.CombinedOutput()
+	cmd := c.command(ctx, "serve", "status", "--json")
+	output, err := c.combinedOutput(cmd)
 	if err != nil {
 		stderr := string(output)
 		// Empty config is not an error
 		if isNotFoundError(stderr) {
 			log.Debug().Msg("No existing Tailscale services found")
+			c.recordServeStatus(&TailscaleStatus{})
 			return make(map[string]ServiceEndpoint), nil
 		}
+		if isSocketUnavailableError(stderr) {
+			return nil, fmt.Errorf("%w: %s", ErrTailscaledUnavailable, strings.TrimSpace(stderr))
+		}
 		return nil, fmt.Errorf("failed to get tailscale status: %w (output: %s)", err, stderr)
 	}
 
@@ -43,6 +44,7 @@ func (c *Client) GetCurrentServices(ctx context.Context) (map[string]ServiceEndp
 	log.Debug().
 		Int("total_services_in_status", len(status.Services)).
 		Msg("Parsed Tailscale status JSON")
+	c.recordServeStatus(&status)
 
 	services := make(map[string]ServiceEndpoint)
 
@@ -74,6 +76,14 @@ func (c *Client) GetCurrentServices(ctx context.Context) (map[string]ServiceEndp
 							destination = handler.Proxy
 							break
 						}
+						if handler.Redirect != "" {
+							destination = "redirect:" + handler.Redirect
+							break
+						}
+						if handler.Path != "" {
+							destination = handler.Path
+							break
+						}
 					}
 					break
 				}
@@ -110,29 +120,32 @@ func (c *Client) GetCurrentServices(ctx context.Context) (map[string]ServiceEndp
 // If adding fails due to config conflict, it clears (with drain) and retries
 func (c *Client) addService(ctx context.Context, svc *apptypes.ContainerService) error {
 	serviceName := fmt.Sprintf("svc:%s", svc.ServiceName)
-	destination := buildDestination(svc)
+	destination := BuildDestination(svc)
+	warnTargetCAFallback(svc)
 
 	// Map service protocol to CLI flag (this is what Tailscale exposes)
-	var protocolFlag string
-	switch svc.ServiceProtocol {
-	case "http":
-		protocolFlag = "--http"
-	case "https":
-		protocolFlag = "--https"
-	case "tcp", "tls-terminated-tcp":
-		protocolFlag = "--tcp"
-	default:
-		return fmt.Errorf("unsupported service protocol: %s", svc.ServiceProtocol)
+	protocolFlag, err := serveProtocolFlag(svc.ServiceProtocol)
+	if err != nil {
+		return err
+	}
+
+	// tailscale serve has no SNI or certificate flags: TLS is always terminated with the
+	// certificate for the service's MagicDNS name, so an SNI for another name can't be served
+	if svc.TLSSNI != "" && !strings.HasPrefix(svc.TLSSNI, svc.ServiceName+".") && svc.TLSSNI != svc.ServiceName {
+		log.Warn().
+			Str("service", serviceName).
+			Str("tls_sni", svc.TLSSNI).
+			Msg("TLS SNI does not match the service's MagicDNS name; Tailscale only presents the certificate for <service>.<tailnet>.ts.net, so clients using this SNI will fail verification")
 	}
 
 	// Build the command: tailscale serve --service=svc:<name> --<protocol>=<port> <destination>
 	portArg := fmt.Sprintf("%s=%s", protocolFlag, svc.Port)
 	serviceArg := fmt.Sprintf("--service=%s", serviceName)
 
-	cmd := exec.CommandContext(ctx, "tailscale", "serve", serviceArg, portArg, destination)
+	args := []string{"serve", serviceArg, portArg, destination}
 
 	log.Debug().
-		Str("command", cmd.String()).
+		Str("command", "tailscale "+strings.Join(args, " ")).
 		Str("service", serviceName).
 		Str("service_protocol", svc.ServiceProtocol).
 		Str("service_port", svc.Port).
@@ -140,7 +153,7 @@ func (c *Client) addService(ctx context.Context, svc *apptypes.ContainerService)
 		Str("destination", destination).
 		Msg("Executing tailscale serve command")
 
-	output, err := cmd.CombinedOutput()
+	output, err := c.runWithRetry(ctx, args...)
 	if err != nil {
 		stderr := string(output)
 
@@ -161,8 +174,7 @@ func (c *Client) addService(ctx context.Context, svc *apptypes.ContainerService)
 				Str("service", serviceName).
 				Msg("Retrying add after clearing conflicting config")
 
-			retryCmd := exec.CommandContext(ctx, "tailscale", "serve", serviceArg, portArg, destination)
-			retryOutput, retryErr := retryCmd.CombinedOutput()
+			retryOutput, retryErr := c.runWithRetry(ctx, args...)
 			if retryErr != nil {
 				return fmt.Errorf("failed to add service after clearing: %w\nOutput: %s", retryErr, string(retryOutput))
 			}
@@ -193,11 +205,53 @@ func (c *Client) addService(ctx context.Context, svc *apptypes.ContainerService)
 	log.Debug().
 		Str("output", string(output)).
 		Str("service", serviceName).
+		Str("node", c.nodeName).
 		Msg("Service added successfully")
 
 	return nil
 }
 
+// serveProtocolFlag maps a service protocol to its tailscale serve flag
+func serveProtocolFlag(protocol string) (string, error) {
+	switch protocol {
+	case "http":
+		return "--http", nil
+	case "https":
+		return "--https", nil
+	case "tcp", "tls-terminated-tcp":
+		return "--tcp", nil
+	default:
+		return "", fmt.Errorf("unsupported service protocol: %s", protocol)
+	}
+}
+
+// removeServicePort turns off one port of a service that keeps serving its other ports
+// Unlike removeService, the service itself is neither drained nor cleared
+func (c *Client) removeServicePort(ctx context.Context, endpoint ServiceEndpoint) error {
+	if !isManagedService(endpoint.ServiceName) {
+		return fmt.Errorf("refusing to modify service '%s': not managed by DockTail (missing 'svc:' prefix)", endpoint.ServiceName)
+	}
+
+	protocolFlag, err := serveProtocolFlag(endpoint.Protocol)
+	if err != nil {
+		return err
+	}
+
+	args := []string{"serve", "--service=" + endpoint.ServiceName, fmt.Sprintf("%s=%s", protocolFlag, endpoint.Port), "off"}
+
+	log.Debug().
+		Str("command", "tailscale "+strings.Join(args, " ")).
+		Str("service", endpoint.ServiceName).
+		Str("port", endpoint.Port).
+		Msg("Turning off a single service port")
+
+	output, err := c.runWithRetry(ctx, args...)
+	if err != nil && !isNotFoundError(string(output)) {
+		return fmt.Errorf("failed to remove port %s from service: %w\nOutput: %s", endpoint.Port, err, string(output))
+	}
+	return nil
+}
+
 // clearServiceOnly clears a service configuration without draining
 // Used when updating service config (protocol change, etc) where service continues running
 func (c *Client) clearServiceOnly(ctx context.Context, serviceName string) error {
@@ -205,14 +259,14 @@ func (c *Client) clearServiceOnly(ctx context.Context, serviceName string) error
 		Str("service", serviceName).
 		Msg("Clearing service configuration (no drain - service will be reconfigured)")
 
-	cmd := exec.CommandContext(ctx, "tailscale", "serve", "clear", serviceName)
+	cmd := c.command(ctx, "serve", "clear", serviceName)
 
 	log.Debug().
 		Str("command", cmd.String()).
 		Str("service", serviceName).
 		Msg("Executing tailscale serve clear command")
 
-	output, err := cmd.CombinedOutput()
+	output, err := c.combinedOutput(cmd)
 	if err != nil {
 		stderr := string(output)
 		// Ignore errors if service doesn't exist
@@ -252,14 +306,14 @@ func (c *Client) removeService(ctx context.Context, serviceName string) error {
 
 	// Step 1: Drain the service to gracefully close existing connections
 	// This is important for security - prevents stale services from staying accessible
-	drainCmd := exec.CommandContext(ctx, "tailscale", "serve", "drain", serviceName)
+	drainCmd := c.command(ctx, "serve", "drain", serviceName)
 
 	log.Debug().
 		Str("command", drainCmd.String()).
 		Str("service", serviceName).
 		Msg("Draining service to close existing connections")
 
-	drainOutput, drainErr := drainCmd.CombinedOutput()
+	drainOutput, drainErr := c.combinedOutput(drainCmd)
 	if drainErr != nil {
 		stderr := string(drainOutput)
 		// Only warn if drain fails - we'll still try to clear
@@ -281,14 +335,14 @@ func (c *Client) removeService(ctx context.Context, serviceName string) error {
 	}
 
 	// Step 2: Clear the service configuration
-	clearCmd := exec.CommandContext(ctx, "tailscale", "serve", "clear", serviceName)
+	clearCmd := c.command(ctx, "serve", "clear", serviceName)
 
 	log.Debug().
 		Str("command", clearCmd.String()).
 		Str("service", serviceName).
 		Msg("Clearing service configuration")
 
-	clearOutput, clearErr := clearCmd.CombinedOutput()
+	clearOutput, clearErr := c.combinedOutput(clearCmd)
 	if clearErr != nil {
 		stderr := string(clearOutput)
 		// Ignore errors if service doesn't exist
@@ -311,8 +365,8 @@ func (c *Client) removeService(ctx context.Context, serviceName string) error {
 // DrainService gracefully drains a service
 func (c *Client) DrainService(ctx context.Context, serviceName string) error {
 	fullName := fmt.Sprintf("svc:%s", serviceName)
-	cmd := exec.CommandContext(ctx, "tailscale", "serve", "drain", fullName)
-	if output, err := cmd.CombinedOutput(); err != nil {
+	cmd := c.command(ctx, "serve", "drain", fullName)
+	if output, err := c.combinedOutput(cmd); err != nil {
 		return fmt.Errorf("failed to drain service %s: %w\nOutput: %s", fullName, err, string(output))
 	}
 	log.Info().Str("service", fullName).Msg("Drained service")
@@ -0,0 +1,73 @@
+package tailscale
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// defaultTailnet is the API's placeholder for the tailnet the credentials belong to
+const defaultTailnet = "-"
+
+// Tailnet returns the tailnet used in API calls ("-" unless set or resolved)
+func (c *Client) Tailnet() string {
+	return c.tailnet
+}
+
+// ResolveTailnet replaces the "-" tailnet with its actual name, so logs and API calls say which
+// tailnet is managed. The name comes from the local node, and is only used once the API confirms
+// the credentials can reach services in it; otherwise "-" is kept
+// Must be called before reconciliation starts
+func (c *Client) ResolveTailnet(ctx context.Context) {
+	if !c.apiSyncEnabled || c.tailnet != defaultTailnet {
+		return
+	}
+
+	status, err := c.getLocalStatus(ctx)
+	if err != nil {
+		log.Warn().Err(err).Msg("Could not resolve the default tailnet name, using '-'")
+		return
+	}
+	if status.CurrentTailnet == nil || status.CurrentTailnet.Name == "" {
+		log.Debug().Msg("Local node reports no tailnet name, using '-'")
+		return
+	}
+	c.resolveTailnet(ctx, status.CurrentTailnet.Name)
+}
+
+// resolveTailnet switches to the named tailnet if the API accepts it for these credentials
+func (c *Client) resolveTailnet(ctx context.Context, name string) {
+	if err := c.checkTailnet(ctx, name); err != nil {
+		log.Warn().
+			Err(err).
+			Str("tailnet", name).
+			Msg("Credentials can't use the local node's tailnet by name, using '-'")
+		return
+	}
+	c.tailnet = name
+	log.Info().Str("tailnet", name).Msg("Resolved default tailnet")
+}
+
+// checkTailnet lists the services of a tailnet to confirm the credentials can manage them
+func (c *Client) checkTailnet(ctx context.Context, name string) error {
+	apiURL := fmt.Sprintf("%s/api/v2/tailnet/%s/services", c.baseURL, url.PathEscape(name))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create GET request: %w", err)
+	}
+
+	resp, err := c.doAPI(req)
+	if err != nil {
+		return fmt.Errorf("GET request failed: %w", err)
+	}
+	defer closeBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("GET API returned error status %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
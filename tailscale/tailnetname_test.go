@@ -0,0 +1,46 @@
+package tailscale
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResolveTailnet(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The credentials only belong to example.com
+		if r.URL.Path != "/api/v2/tailnet/example.com/services" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(`{"vipServices":[]}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name  string
+		local string
+		want  string
+	}{
+		{"accepted by the API", "example.com", "example.com"},
+		{"rejected by the API", "other.org", "-"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{tailnet: "-", baseURL: server.URL, httpClient: server.Client(), apiSyncEnabled: true}
+			c.resolveTailnet(context.Background(), tt.local)
+			if got := c.Tailnet(); got != tt.want {
+				t.Errorf("Tailnet() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// An explicit tailnet is never replaced
+	c := &Client{tailnet: "corp.example", baseURL: server.URL, httpClient: server.Client(), apiSyncEnabled: true}
+	c.ResolveTailnet(context.Background())
+	if got := c.Tailnet(); got != "corp.example" {
+		t.Errorf("Tailnet() = %q, want the configured tailnet", got)
+	}
+}
@@ -0,0 +1,39 @@
+package tailscale
+
+import (
+	"errors"
+	"fmt"
+
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+// ErrTCPTimeoutUnsupported is reported for services with docktail.service.tcp-timeout or idle-timeout
+// labels while tailscale serve can't apply them
+var ErrTCPTimeoutUnsupported = errors.New("tcp timeouts are not supported by tailscale serve")
+
+// serveSupportsTCPTimeouts reports whether tailscale serve can set TCP connection or idle timeouts
+// No CLI release has a flag or serve config field for them, so services asking for them are refused
+// instead of being served with timeouts other than the ones they were labelled with
+const serveSupportsTCPTimeouts = false
+
+// withoutUnsupportedTimeouts drops desired services that set TCP timeouts, recording an error for each
+// affected container; any serve config they already have is removed like that of a stopped container
+func withoutUnsupportedTimeouts(desired []*apptypes.ContainerService, result *ReconcileResult) []*apptypes.ContainerService {
+	if serveSupportsTCPTimeouts {
+		return desired
+	}
+
+	kept := make([]*apptypes.ContainerService, 0, len(desired))
+	for _, svc := range desired {
+		if svc.TCPTimeout == 0 && svc.IdleTimeout == 0 {
+			kept = append(kept, svc)
+			continue
+		}
+		log.Warn().
+			Str("service", svc.ServiceName).
+			Str("container", svc.ContainerName).
+			Msg("Service sets TCP timeouts, which tailscale serve can't apply; not serving it")
+		result.Failed[svc.ContainerID] = fmt.Errorf("%w: remove the %s and %s labels from %s and enable TCP keepalives on the client or server instead", ErrTCPTimeoutUnsupported, apptypes.LabelTCPTimeout, apptypes.LabelIdleTimeout, svc.ContainerName)
+	}
+	return kept
+}
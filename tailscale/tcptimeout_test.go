@@ -0,0 +1,27 @@
+package tailscale
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	apptypes "github.com/marvinvr/docktail/types"
+)
+
+func TestWithoutUnsupportedTimeouts(t *testing.T) {
+	web := &apptypes.ContainerService{ContainerID: "web", ContainerName: "web", ServiceName: "web"}
+	db := &apptypes.ContainerService{ContainerID: "db", ContainerName: "db", ServiceName: "db", IdleTimeout: time.Hour}
+	result := &ReconcileResult{Failed: make(map[string]error)}
+
+	kept := withoutUnsupportedTimeouts([]*apptypes.ContainerService{web, db}, result)
+
+	if len(kept) != 1 || kept[0] != web {
+		t.Errorf("expected only web to be kept, got %v", kept)
+	}
+	if !errors.Is(result.Failed["db"], ErrTCPTimeoutUnsupported) {
+		t.Errorf("db error = %v, want ErrTCPTimeoutUnsupported", result.Failed["db"])
+	}
+	if result.Failed["web"] != nil {
+		t.Errorf("web should not fail, got %v", result.Failed["web"])
+	}
+}
@@ -0,0 +1,81 @@
+package tailscale
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// secretFlags are CLI flags whose value is never logged, in --flag=value or --flag value form
+var secretFlags = []string{"--authkey", "--auth-key", "--client-secret", "--api-key"}
+
+// combinedOutput runs a CLI command like cmd.CombinedOutput, tracing it with TRACE_TAILSCALE
+func (c *Client) combinedOutput(cmd *exec.Cmd) ([]byte, error) {
+	start := time.Now()
+	output, err := cmd.CombinedOutput()
+	c.trace(cmd, output, nil, err, time.Since(start))
+	return output, err
+}
+
+// output runs a CLI command like cmd.Output, tracing it with TRACE_TAILSCALE
+// stderr is only traced when the command fails, as exec captures it only then
+func (c *Client) output(cmd *exec.Cmd) ([]byte, error) {
+	start := time.Now()
+	output, err := cmd.Output()
+	var stderr []byte
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		stderr = exitErr.Stderr
+	}
+	c.trace(cmd, output, stderr, err, time.Since(start))
+	return output, err
+}
+
+// trace logs a CLI invocation's full argv and raw output, before stripWarnings or any parsing
+func (c *Client) trace(cmd *exec.Cmd, output, stderr []byte, err error, elapsed time.Duration) {
+	if !c.traceCLI {
+		return
+	}
+	event := log.Debug().
+		Strs("argv", redactArgs(cmd.Args)).
+		Str("output", string(output)).
+		Dur("duration", elapsed)
+	if stderr != nil {
+		event = event.Str("stderr", string(stderr))
+	}
+	if cmd.ProcessState != nil {
+		event = event.Int("exit_code", cmd.ProcessState.ExitCode())
+	}
+	event.Err(err).Msg("tailscale CLI trace")
+}
+
+// redactArgs masks the values of secretFlags and anything that looks like a Tailscale key
+func redactArgs(args []string) []string {
+	redacted := make([]string, len(args))
+	maskNext := false
+	for i, arg := range args {
+		switch {
+		case maskNext:
+			redacted[i] = "***"
+			maskNext = false
+		case strings.Contains(arg, "tskey-"):
+			redacted[i] = redactKey(arg)
+		default:
+			redacted[i] = arg
+			for _, flag := range secretFlags {
+				if arg == flag {
+					maskNext = true
+				} else if strings.HasPrefix(arg, flag+"=") {
+					redacted[i] = flag + "=***"
+				}
+			}
+		}
+	}
+	return redacted
+}
+
+// redactKey keeps what precedes a tskey- secret, e.g. --authkey=tskey-auth-abc becomes --authkey=***
+func redactKey(arg string) string {
+	return arg[:strings.Index(arg, "tskey-")] + "***"
+}
@@ -0,0 +1,36 @@
+package tailscale
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRedactArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{
+			[]string{"tailscale", "--socket=/run/ts.sock", "serve", "status", "--json"},
+			[]string{"tailscale", "--socket=/run/ts.sock", "serve", "status", "--json"},
+		},
+		{
+			[]string{"tailscale", "up", "--authkey=tskey-auth-abc123"},
+			[]string{"tailscale", "up", "--authkey=***"},
+		},
+		{
+			[]string{"tailscale", "up", "--auth-key", "file:/run/key"},
+			[]string{"tailscale", "up", "--auth-key", "***"},
+		},
+		{
+			[]string{"tailscale", "login", "tskey-client-xyz"},
+			[]string{"tailscale", "login", "***"},
+		},
+	}
+
+	for _, tt := range tests {
+		if got := redactArgs(tt.args); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("redactArgs(%v) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
@@ -1,19 +1,21 @@
 package tailscale
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os/exec"
 	"strings"
-
-	"github.com/rs/zerolog/log"
+	"time"
 
 	apptypes "github.com/marvinvr/docktail/types"
 )
 
 // stripWarnings removes warning messages from Tailscale CLI output
-// Warnings appear before the JSON and need to be stripped for parsing
+// Warnings appear before the JSON (object or array) and need to be stripped for parsing
 func stripWarnings(output []byte) string {
 	outputStr := string(output)
-	jsonStart := strings.Index(outputStr, "{")
+	jsonStart := strings.IndexAny(outputStr, "{[")
 	if jsonStart > 0 {
 		outputStr = outputStr[jsonStart:]
 		log.Debug().
@@ -44,15 +46,135 @@ func isUntaggedNodeError(stderr string) bool {
 	return strings.Contains(stderr, "service hosts must be tagged nodes")
 }
 
+// ErrTailscaledUnavailable indicates the tailscaled socket is missing or refusing connections,
+// typically because tailscaled is restarting
+var ErrTailscaledUnavailable = errors.New("tailscaled is not reachable")
+
+// isSocketUnavailableError checks if the CLI failed because it couldn't reach tailscaled
+func isSocketUnavailableError(stderr string) bool {
+	return strings.Contains(stderr, "doesn't appear to be running") ||
+		strings.Contains(stderr, "connection refused") ||
+		strings.Contains(stderr, "no such file or directory")
+}
+
+// isTerminalError checks if a CLI error is one of the known classes that retrying won't fix
+func isTerminalError(stderr string) bool {
+	return isNotFoundError(stderr) ||
+		isConfigConflictError(stderr) ||
+		isUntaggedNodeError(stderr)
+}
+
+// retryBaseDelay is the delay before the first retry, doubled on each subsequent attempt
+var retryBaseDelay = 500 * time.Millisecond
+
+// retryMaxDelay caps the backoff, since every retry blocks the reconcile loop
+const retryMaxDelay = 30 * time.Second
+
+// retryDelay returns the backoff delay before the given retry attempt (1-based), at most retryMaxDelay
+// Doubling stops at the cap, so a large attempt number can't overflow into a negative delay
+func retryDelay(attempt int) time.Duration {
+	delay := retryBaseDelay
+	for i := 1; i < attempt && delay < retryMaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, retryMaxDelay)
+}
+
+// command builds a tailscale CLI command addressed to this client's tailscaled socket
+// Each tailnet is served by its own tailscaled, so the socket selects which node is configured
+func (c *Client) command(ctx context.Context, args ...string) *exec.Cmd {
+	if c.socketPath != "" {
+		args = append([]string{"--socket=" + c.socketPath}, args...)
+	}
+	return exec.CommandContext(ctx, "tailscale", args...)
+}
+
+// runWithRetry runs a tailscale CLI command, retrying transient failures with exponential backoff
+// Known terminal errors are returned immediately so callers can handle them
+func (c *Client) runWithRetry(ctx context.Context, args ...string) ([]byte, error) {
+	maxAttempts := c.retryMax
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	var output []byte
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		output, err = c.combinedOutput(c.command(ctx, args...))
+		if err == nil || isTerminalError(string(output)) || attempt == maxAttempts {
+			return output, err
+		}
+
+		delay := retryDelay(attempt)
+		log.Debug().
+			Err(err).
+			Int("attempt", attempt).
+			Int("max_attempts", maxAttempts).
+			Dur("backoff", delay).
+			Str("command", "tailscale "+strings.Join(args, " ")).
+			Str("output", string(output)).
+			Msg("Transient tailscale CLI failure, retrying")
+
+		select {
+		case <-ctx.Done():
+			return output, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+	return output, err
+}
+
 // isManagedService checks if a service name has the "svc:" prefix
 // This indicates it's managed by DockTail and safe to modify
 func isManagedService(serviceName string) bool {
 	return strings.HasPrefix(serviceName, "svc:")
 }
 
-// buildDestination constructs the destination URL for a service
-func buildDestination(svc *apptypes.ContainerService) string {
+// BuildDestination constructs the destination URL for a service
+func BuildDestination(svc *apptypes.ContainerService) string {
+	if svc.Redirect != "" {
+		// e.g., redirect:https://${HOST}${REQUEST_URI}
+		return "redirect:" + svc.Redirect
+	}
+
+	if svc.ServePath != "" {
+		// e.g., /srv/maintenance.html (tailscaled serves the file itself)
+		return svc.ServePath
+	}
+
+	if svc.UnixSocket != "" {
+		// e.g., unix+http:///run/app/app.sock
+		return fmt.Sprintf("unix+%s://%s", svc.Protocol, svc.UnixSocket)
+	}
+
 	// Use the service protocol directly in the destination URL
 	// The protocol flag and destination protocol should match the service configuration
-	return fmt.Sprintf("%s://%s:%s", svc.Protocol, svc.IPAddress, svc.TargetPort)
+	return fmt.Sprintf("%s://%s:%s", backendScheme(svc, svc.Protocol), svc.IPAddress, svc.TargetPort)
+}
+
+// serveSupportsTargetCA reports whether tailscale serve can verify a backend against a custom CA
+// No CLI release has a flag or URL scheme for it yet (only https, verified against the system
+// roots, and https+insecure), so backends with docktail.service.target-ca fall back to https+insecure
+const serveSupportsTargetCA = false
+
+// backendScheme returns the URL scheme used to reach an https backend with the given protocol
+// Backends with a target CA can't be verified by tailscaled, so they skip verification instead
+// of failing against the system roots
+func backendScheme(svc *apptypes.ContainerService, protocol string) string {
+	if svc.TargetCA != "" && protocol == "https" && !serveSupportsTargetCA {
+		return "https+insecure"
+	}
+	return protocol
+}
+
+// warnTargetCAFallback logs that a service's target CA can't be passed to tailscale serve
+func warnTargetCAFallback(svc *apptypes.ContainerService) {
+	if svc.TargetCA == "" || serveSupportsTargetCA {
+		return
+	}
+	log.Warn().
+		Str("container", svc.ContainerName).
+		Str("service", svc.ServiceName).
+		Str("target_ca", svc.TargetCA).
+		Msg("tailscale serve cannot verify backends against a custom CA, falling back to https+insecure")
 }
@@ -2,6 +2,7 @@ package tailscale
 
 import (
 	"testing"
+	"time"
 
 	apptypes "github.com/marvinvr/docktail/types"
 )
@@ -37,6 +38,21 @@ func TestStripWarnings(t *testing.T) {
 			input:    []byte(""),
 			expected: "",
 		},
+		{
+			name:     "warning before JSON array",
+			input:    []byte("Warning: some tailscale warning\n[{\"name\":\"svc:web\"}]"),
+			expected: `[{"name":"svc:web"}]`,
+		},
+		{
+			name:     "array at position 0 unchanged",
+			input:    []byte(`["a","b"]`),
+			expected: `["a","b"]`,
+		},
+		{
+			name:     "warning only, no JSON",
+			input:    []byte("Warning: client version mismatch\n"),
+			expected: "Warning: client version mismatch\n",
+		},
 		{
 			name:     "brace at position 0 unchanged",
 			input:    []byte("{\"already\":\"clean\"}"),
@@ -123,6 +139,73 @@ func TestIsUntaggedNodeError(t *testing.T) {
 	}
 }
 
+func TestIsSocketUnavailableError(t *testing.T) {
+	tests := []struct {
+		name     string
+		stderr   string
+		expected bool
+	}{
+		{"daemon not running", "failed to connect to local tailscaled; it doesn't appear to be running (sudo systemctl start tailscaled ?)", true},
+		{"connection refused", "dial unix /var/run/tailscale/tailscaled.sock: connect: connection refused", true},
+		{"socket missing", "dial unix /var/run/tailscale/tailscaled.sock: connect: no such file or directory", true},
+		{"unrelated error", "service hosts must be tagged nodes", false},
+		{"empty string", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := isSocketUnavailableError(tt.stderr)
+			if result != tt.expected {
+				t.Errorf("isSocketUnavailableError(%q) = %v, want %v", tt.stderr, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestIsTerminalError(t *testing.T) {
+	tests := []struct {
+		name     string
+		stderr   string
+		expected bool
+	}{
+		{"not found is terminal", "error: service not found", true},
+		{"config conflict is terminal", "port is already serving TCP", true},
+		{"untagged node is terminal", "service hosts must be tagged nodes", true},
+		{"socket busy is transient", "failed to connect to local tailscaled: resource temporarily unavailable", false},
+		{"empty output is transient", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := isTerminalError(tt.stderr)
+			if result != tt.expected {
+				t.Errorf("isTerminalError(%q) = %v, want %v", tt.stderr, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempt  int
+		expected time.Duration
+	}{
+		{1, 500 * time.Millisecond},
+		{2, 1 * time.Second},
+		{3, 2 * time.Second},
+		{4, 4 * time.Second},
+		{7, 30 * time.Second},
+		{36, 30 * time.Second},
+		{100, 30 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := retryDelay(tt.attempt); got != tt.expected {
+			t.Errorf("retryDelay(%d) = %v, want %v", tt.attempt, got, tt.expected)
+		}
+	}
+}
+
 func TestIsManagedService(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -162,6 +245,14 @@ func TestBuildDestination(t *testing.T) {
 			},
 			expected: "http://172.17.0.2:8080",
 		},
+		{
+			name: "unix socket service",
+			svc: &apptypes.ContainerService{
+				Protocol:   "http",
+				UnixSocket: "/run/app/app.sock",
+			},
+			expected: "unix+http:///run/app/app.sock",
+		},
 		{
 			name: "HTTPS service",
 			svc: &apptypes.ContainerService{
@@ -171,6 +262,16 @@ func TestBuildDestination(t *testing.T) {
 			},
 			expected: "https://172.17.0.3:443",
 		},
+		{
+			name: "HTTPS service with target CA",
+			svc: &apptypes.ContainerService{
+				Protocol:   "https",
+				IPAddress:  "172.17.0.3",
+				TargetPort: "8200",
+				TargetCA:   "/certs/ca.pem",
+			},
+			expected: "https+insecure://172.17.0.3:8200",
+		},
 		{
 			name: "TCP service",
 			svc: &apptypes.ContainerService{
@@ -202,9 +303,9 @@ func TestBuildDestination(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := buildDestination(tt.svc)
+			result := BuildDestination(tt.svc)
 			if result != tt.expected {
-				t.Errorf("buildDestination() = %q, want %q", result, tt.expected)
+				t.Errorf("BuildDestination() = %q, want %q", result, tt.expected)
 			}
 		})
 	}
@@ -0,0 +1,149 @@
+package tailscale
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MinVersion is the oldest tailscale release DockTail supports
+// Tailscale Services (tailscale serve --service=svc:<name>) first shipped in 1.86, and every
+// release since takes https+insecure only as a URL scheme (https+insecure://host:port), the one
+// form BuildDestination emits; enforcing it is what lets DockTail skip a flag-form translation
+const MinVersion = "1.86.0"
+
+// ErrUnsupportedVersion is returned by CheckVersion when the CLI or tailscaled is older than MinVersion
+var ErrUnsupportedVersion = errors.New("unsupported tailscale version")
+
+// cliVersion is the subset of 'tailscale version --json' DockTail reports
+type cliVersion struct {
+	Short string `json:"short"`
+	Long  string `json:"long"`
+	// Daemon is the tailscaled version when the CLI warned it differs from its own (empty = same)
+	Daemon string `json:"-"`
+}
+
+// daemonVersionWarning matches the CLI's warning when tailscaled runs another version,
+// e.g. Warning: client version "1.86.2" != tailscaled server version "1.84.0"
+var daemonVersionWarning = regexp.MustCompile(`tailscaled server version "([^"]+)"`)
+
+// getCLIVersion runs 'tailscale version --json' to record which CLI builds the serve commands
+func (c *Client) getCLIVersion(ctx context.Context) (*cliVersion, error) {
+	cmd := c.command(ctx, "version", "--json")
+	output, err := c.output(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("tailscale version failed: %w", err)
+	}
+	return parseCLIVersion(output)
+}
+
+// parseCLIVersion decodes 'tailscale version --json' output
+func parseCLIVersion(output []byte) (*cliVersion, error) {
+	var version cliVersion
+	if err := json.Unmarshal([]byte(stripWarnings(output)), &version); err != nil {
+		return nil, fmt.Errorf("failed to parse tailscale version: %w", err)
+	}
+	if version.Short == "" {
+		return nil, fmt.Errorf("tailscale version output has no version")
+	}
+	if m := daemonVersionWarning.FindSubmatch(output); m != nil {
+		version.Daemon = string(m[1])
+	}
+	return &version, nil
+}
+
+// CheckVersion detects the tailscale CLI and tailscaled versions, records them for Version,
+// and fails with ErrUnsupportedVersion if either is older than MinVersion
+// A version that can't be detected or parsed is logged and allowed, since it can't be compared
+func (c *Client) CheckVersion(ctx context.Context) error {
+	version, err := c.getCLIVersion(ctx)
+	if err != nil {
+		log.Warn().Err(err).Msg("Could not determine tailscale version, skipping the minimum version check")
+		return nil
+	}
+
+	c.version = version.Short
+	event := log.Info().
+		Str("cli_version", version.Short).
+		Str("cli_version_long", version.Long)
+	if version.Daemon != "" {
+		// tailscaled applies the serve config, so its version is the one that counts
+		c.version = version.Daemon
+		event = event.Str("tailscaled_version", version.Daemon)
+	}
+	event.Msg("Tailscale version detected")
+
+	if version.Daemon != "" {
+		log.Warn().
+			Str("cli_version", version.Short).
+			Str("tailscaled_version", version.Daemon).
+			Msg("tailscale CLI and tailscaled versions differ; serve features follow the tailscaled version, upgrade both to match")
+	}
+
+	return checkMinVersion(version)
+}
+
+// checkMinVersion fails with ErrUnsupportedVersion if the CLI or tailscaled is older than MinVersion
+func checkMinVersion(version *cliVersion) error {
+	for _, v := range []string{version.Short, version.Daemon} {
+		if v == "" {
+			continue
+		}
+		older, ok := versionOlder(v, MinVersion)
+		if !ok {
+			log.Warn().Str("version", v).Msg("Could not parse tailscale version, skipping the minimum version check")
+			continue
+		}
+		if older {
+			return fmt.Errorf("%w: tailscale %s is older than %s, the first release with Tailscale Services (serve --service) and the https+insecure:// destination scheme DockTail uses; upgrade tailscale and tailscaled", ErrUnsupportedVersion, v, MinVersion)
+		}
+	}
+	return nil
+}
+
+// Version returns the tailscale version recorded by CheckVersion: tailscaled's when it differs
+// from the CLI's, empty if it couldn't be detected
+func (c *Client) Version() string {
+	return c.version
+}
+
+// versionOlder reports whether version is older than minimum, comparing major.minor.patch
+// Suffixes like -t1234abcd or -dev are ignored; ok is false if either can't be parsed
+func versionOlder(version, minimum string) (older bool, ok bool) {
+	v, ok := parseVersion(version)
+	if !ok {
+		return false, false
+	}
+	m, ok := parseVersion(minimum)
+	if !ok {
+		return false, false
+	}
+	for i := range v {
+		if v[i] != m[i] {
+			return v[i] < m[i], true
+		}
+	}
+	return false, true
+}
+
+// parseVersion splits a version like 1.86.2-t1234abcd into its major, minor and patch numbers
+func parseVersion(version string) ([3]int, bool) {
+	var parts [3]int
+	core, _, _ := strings.Cut(strings.TrimPrefix(version, "v"), "-")
+	fields := strings.Split(core, ".")
+	if len(fields) < 2 || len(fields) > 3 {
+		return parts, false
+	}
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return parts, false
+		}
+		parts[i] = n
+	}
+	return parts, true
+}
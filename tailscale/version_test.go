@@ -0,0 +1,91 @@
+package tailscale
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseCLIVersion(t *testing.T) {
+	tests := []struct {
+		name       string
+		output     string
+		want       string
+		wantDaemon string
+		wantErr    bool
+	}{
+		{
+			name:   "version json",
+			output: `{"majorMinorPatch":"1.86.2","short":"1.86.2","long":"1.86.2-t1234abcd-g5678ef90","unstableBranch":false}`,
+			want:   "1.86.2",
+		},
+		{
+			name:       "with warning prefix",
+			output:     "Warning: client version \"1.86.2\" != tailscaled server version \"1.84.0\"\n{\"short\":\"1.86.2\",\"long\":\"1.86.2-t1234abcd\"}",
+			want:       "1.86.2",
+			wantDaemon: "1.84.0",
+		},
+		{name: "plain text", output: "1.86.2\n  tailscale commit: 1234abcd", wantErr: true},
+		{name: "no version", output: `{}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseCLIVersion([]byte(tt.output))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: parseCLIVersion() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err == nil && got.Short != tt.want {
+			t.Errorf("%s: parseCLIVersion() = %s, want %s", tt.name, got.Short, tt.want)
+		}
+		if err == nil && got.Daemon != tt.wantDaemon {
+			t.Errorf("%s: parseCLIVersion() daemon = %q, want %q", tt.name, got.Daemon, tt.wantDaemon)
+		}
+	}
+}
+
+func TestVersionOlder(t *testing.T) {
+	tests := []struct {
+		version string
+		older   bool
+		ok      bool
+	}{
+		{"1.86.0", false, true},
+		{"1.86.2-t1234abcd-g5678ef90", false, true},
+		{"1.90.1", false, true},
+		{"2.0.0", false, true},
+		{"1.84.3", true, true},
+		{"1.9.0", true, true},
+		{"1.87", false, true},
+		{"unknown", false, false},
+	}
+
+	for _, tt := range tests {
+		older, ok := versionOlder(tt.version, MinVersion)
+		if older != tt.older || ok != tt.ok {
+			t.Errorf("versionOlder(%q, %q) = %v, %v, want %v, %v", tt.version, MinVersion, older, ok, tt.older, tt.ok)
+		}
+	}
+}
+
+func TestCheckMinVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		version cliVersion
+		wantErr bool
+	}{
+		{"supported", cliVersion{Short: "1.86.2"}, false},
+		{"old cli", cliVersion{Short: "1.84.3"}, true},
+		{"old tailscaled", cliVersion{Short: "1.86.2", Daemon: "1.84.0"}, true},
+		{"unparseable", cliVersion{Short: "unknown"}, false},
+	}
+
+	for _, tt := range tests {
+		err := checkMinVersion(&tt.version)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: checkMinVersion() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+		if err != nil && !errors.Is(err, ErrUnsupportedVersion) {
+			t.Errorf("%s: checkMinVersion() error = %v, want ErrUnsupportedVersion", tt.name, err)
+		}
+	}
+}
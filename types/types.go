@@ -1,21 +1,56 @@
 package types
 
+import "time"
+
 // ContainerService represents a parsed container with its Tailscale service configuration
 type ContainerService struct {
-	ContainerID      string
-	ContainerName    string
-	ServiceName      string
-	Port             string   // Tailscale service port (e.g., "443")
-	TargetPort       string   // Container/host port to proxy to (e.g., "9080")
-	ServiceProtocol  string   // Protocol Tailscale uses (e.g., "https", "http", "tcp")
-	Protocol         string   // Protocol the container speaks (e.g., "http", "https", "tcp")
-	Tags             []string // Tailscale service tags (e.g., ["tag:container", "tag:web"])
-	IPAddress        string
-	FunnelEnabled    bool   // Enable Tailscale Funnel (public internet access)
-	FunnelPort       string // Container port for funnel (separate from service port)
-	FunnelTargetPort string // Host port that maps to FunnelPort
-	FunnelFunnelPort string // Public-facing port (443, 8443, or 10000 for HTTPS)
-	FunnelProtocol   string // Funnel protocol (https, tcp, tls-terminated-tcp)
+	ContainerID     string
+	ContainerName   string
+	ServiceName     string
+	AutoName        bool     // ServiceName was derived from the container name (AUTO_SERVICE_NAME)
+	Port            string   // Tailscale service port (e.g., "443")
+	TargetPort      string   // Container/host port to proxy to (e.g., "9080")
+	ServiceProtocol string   // Protocol Tailscale uses (e.g., "https", "http", "tcp")
+	Protocol        string   // Protocol the container speaks (e.g., "http", "https", "tcp")
+	Tags            []string // Tailscale service tags (e.g., ["tag:container", "tag:web"])
+	FunnelTags      []string // Service tags used instead of Tags while funnel is enabled (empty = Tags)
+	AllowedTags     []string // Tags granted access to the service in the policy file (empty = central ACL only)
+	IPAddress       string
+	Network         string         // Docker network IPAddress was taken from (direct mode only)
+	FunnelEnabled   bool           // Enable Tailscale Funnel (public internet access)
+	FunnelOnly      bool           // Only configure funnel, without the internal svc: service
+	Funnels         []FunnelConfig // One entry per public funnel port
+	Comment         string         // Service description shown in the Tailscale admin console
+	TLSSNI          string         // Hostname clients send as SNI for tls-terminated-tcp services
+	Group           string         // Containers sharing a group back one service (one active, the rest on standby)
+	UnixSocket      string         // Unix socket path to proxy to instead of IPAddress:TargetPort
+	HTTPSRedirect   bool           // Also serve HTTP on port 80, redirecting to the HTTPS endpoint
+	Redirect        string         // Redirect target URL served instead of proxying (set on generated redirect entries)
+	MaintenancePage string         // HTML file served instead of proxying while the backend is unhealthy
+	ServePath       string         // File or directory served instead of proxying (docktail.service.serve-path, or the maintenance page while it is up)
+	Tailnet         string         // Tailnet to serve on (empty = the default tailnet)
+	SkipOnError     bool           // Stop retrying after an apply failure until the container is recreated (on-error=skip)
+	TargetCA        string         // CA bundle the https backend's certificate is issued by (docktail.service.target-ca)
+	BasicAuth       *BasicAuth     // HTTP basic auth credential to protect the service with (nil = none)
+	TCPTimeout      time.Duration  // Connection timeout for tcp/tls-terminated-tcp services (0 = tailscale's default)
+	IdleTimeout     time.Duration  // Idle timeout for tcp/tls-terminated-tcp services (0 = tailscale's default)
+	StartedAt       time.Time      // When the container was last started, as reported by Docker (zero if unknown)
+}
+
+// BasicAuth is an HTTP basic auth credential from the docktail.service.basic-auth.* labels
+type BasicAuth struct {
+	User         string
+	PasswordHash string `json:"-"` // bcrypt hash, kept out of --explain output
+	SecretFile   string // File the hash was read from (empty when given inline)
+}
+
+// FunnelConfig is a single public funnel entry for a container
+type FunnelConfig struct {
+	Port           string // Container port for funnel (separate from service port)
+	TargetPort     string // Host port that maps to Port (or the container port in direct mode)
+	FunnelPort     string // Public-facing port (443, 8443, or 10000 for HTTPS)
+	Protocol       string // Funnel protocol (https, tcp, tls-terminated-tcp)
+	TargetProtocol string // Protocol the backend speaks behind an https funnel (http, https, https+insecure; empty for tcp funnels)
 }
 
 // TailscaleServiceConfig represents the JSON structure for Tailscale service configuration
@@ -38,10 +73,38 @@ const (
 	LabelTarget           = "docktail.service.port"
 	LabelTargetProtocol   = "docktail.service.protocol"
 	LabelTags             = "docktail.tags"
+	LabelTagsMode         = "docktail.tags-mode"       // How docktail.tags combine with DEFAULT_SERVICE_TAGS: replace (default) or append
+	LabelComment          = "docktail.service.comment" // Service description (default: "managed by docktail: <container_name>")
+	LabelFunnelPrefix     = "docktail.funnel."         // Indexed entries: docktail.funnel.<n>.port, .funnel-port, .protocol
 	LabelFunnelEnable     = "docktail.funnel.enable"
 	LabelFunnelPort       = "docktail.funnel.port"        // Container port (like service.port)
 	LabelFunnelFunnelPort = "docktail.funnel.funnel-port" // Public port (443, 8443, 10000)
 	LabelFunnelProtocol   = "docktail.funnel.protocol"
-	LabelDirect           = "docktail.service.direct"  // Direct container IP proxying (default: true, set to "false" to use published ports)
-	LabelNetwork          = "docktail.service.network" // Docker network to use for container IP (default: bridge or first available)
+	LabelFunnelTags       = "docktail.funnel.tags"               // Tags for the service definition while funnel is enabled (default: the service tags)
+	LabelFunnelTarget     = "docktail.funnel.target-protocol"    // Protocol https funnels proxy to (default: the service's backend protocol)
+	LabelFunnelOnly       = "docktail.service.funnel-only"       // Only configure funnel, skipping the internal serve config (requires funnel.enable)
+	LabelDirect           = "docktail.service.direct"            // Direct container IP proxying (default: true, set to "false" to use published ports)
+	LabelHostPort         = "docktail.service.host-port"         // Published host port to proxy to when the container port is published more than once
+	LabelDestPort         = "docktail.service.dest-port"         // Port the backend listens on when it differs from docktail.service.port (direct and host-network modes)
+	LabelNetwork          = "docktail.service.network"           // Docker network to use for container IP (default: bridge or first available)
+	LabelWaitReady        = "docktail.service.wait-ready"        // Block until the backend accepts TCP connections (true, false, or a timeout duration)
+	LabelSkipReachability = "docktail.service.skip-reachability" // Skip the best-effort TCP dial to direct-mode backends (default: SKIP_REACHABILITY)
+	LabelKeepOnPause      = "docktail.service.keep-on-pause"     // Keep the service of a paused container (default: true, "false" removes it like a stopped one)
+	LabelUseDNS           = "docktail.service.use-dns"           // Proxy to the container name instead of its IP (requires a user-defined network)
+	LabelTLSSNI           = "docktail.service.tls-sni"           // SNI hostname for tls-terminated-tcp services (must match the service's MagicDNS name)
+	LabelGroup            = "docktail.service.group"             // Combine replicas into one service with failover between them
+	LabelUnixSocket       = "docktail.service.unix-socket"       // Proxy to a unix socket path (as seen by tailscaled) instead of a port
+	LabelServePath        = "docktail.service.serve-path"        // Serve this file or directory (as seen by tailscaled) instead of proxying
+	LabelHTTPSRedirect    = "docktail.service.https-redirect"    // Redirect HTTP port 80 to the HTTPS service (https services only)
+	LabelMaintenancePage  = "docktail.service.maintenance-page"  // HTML file (as seen by tailscaled) served while the backend is unhealthy
+	LabelAllowedTags      = "docktail.service.allowed-tags"      // Only these tags may reach the service (written as a grant with ACL_SYNC=true)
+	LabelStage            = "docktail.service.stage"             // Stage matched against STAGE_SELECTOR, to split containers between DockTail instances
+	LabelOnError          = "docktail.service.on-error"          // What to do after an apply failure: retry every loop (default) or skip until the container is recreated
+	LabelTailnet          = "docktail.service.tailnet"           // Serve on one of the tailnets configured with TAILNETS (default: DEFAULT_TAILNET)
+	LabelTargetCA         = "docktail.service.target-ca"         // PEM CA bundle to verify an https backend with instead of skipping verification
+	LabelBasicAuthUser    = "docktail.service.basic-auth.user"   // User name for HTTP basic auth (http/https services)
+	LabelBasicAuthHash    = "docktail.service.basic-auth.hash"   // bcrypt hash of the basic auth password
+	LabelBasicAuthSecret  = "docktail.service.basic-auth.secret" // File holding the bcrypt hash, e.g. a Docker secret (instead of basic-auth.hash)
+	LabelTCPTimeout       = "docktail.service.tcp-timeout"       // Connection timeout for tcp/tls-terminated-tcp services (Go duration)
+	LabelIdleTimeout      = "docktail.service.idle-timeout"      // Idle timeout for tcp/tls-terminated-tcp services (Go duration)
 )